@@ -0,0 +1,145 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"k8s.io/org/pkg/escalation"
+	"k8s.io/org/pkg/orgconfig"
+
+	"github.com/sirupsen/logrus"
+	"sigs.k8s.io/prow/pkg/config/org"
+	"sigs.k8s.io/yaml"
+)
+
+var errEscalation = errors.New("privilege escalation(s) detected")
+
+type options struct {
+	base    string
+	head    string
+	root    string
+	baseRev string
+	headRev string
+	failOn  string
+	labels  bool
+}
+
+func main() {
+	var o options
+	flag.StringVar(&o.base, "base", "", "Path to the merged config before the change")
+	flag.StringVar(&o.head, "head", "", "Path to the merged config after the change")
+	flag.StringVar(&o.root, "root", ".", "Root of the k/org repo to load the configs of --base-rev and --head-rev from")
+	flag.StringVar(&o.baseRev, "base-rev", "", "Git revision to merge the configs of before the change, instead of reading --base")
+	flag.StringVar(&o.headRev, "head-rev", "", "Git revision to merge the configs of after the change, instead of reading --head. Defaults to the working tree with --base-rev")
+	flag.StringVar(&o.failOn, "fail-on", "high", "Exit non-zero on escalations of at least this risk: high, medium or none")
+	flag.BoolVar(&o.labels, "labels", false, "Print the labels for the escalations, one per line, instead of describing them")
+	flag.Parse()
+
+	if err := run(o, os.Stdout); err != nil {
+		logrus.Fatal(err)
+	}
+}
+
+func run(o options, out io.Writer) error {
+	if (o.base == "") == (o.baseRev == "") {
+		return errors.New("exactly one of --base and --base-rev is required")
+	}
+	if o.head != "" && o.headRev != "" {
+		return errors.New("--head and --head-rev cannot be used together")
+	}
+	if o.head == "" && o.headRev == "" && o.baseRev == "" {
+		return errors.New("--head is required with --base")
+	}
+	var threshold escalation.Risk
+	if o.failOn != "none" {
+		var err error
+		if threshold, err = escalation.ParseRisk(o.failOn); err != nil {
+			return fmt.Errorf("--fail-on: %s", err)
+		}
+	}
+
+	base, err := load(o.root, o.base, o.baseRev)
+	if err != nil {
+		return err
+	}
+	head, err := load(o.root, o.head, o.headRev)
+	if err != nil {
+		return err
+	}
+
+	escalations := escalation.Find(*base, *head)
+	if o.labels {
+		for _, label := range escalation.Labels(escalations) {
+			fmt.Fprintln(out, label)
+		}
+	} else {
+		for _, e := range escalations {
+			fmt.Fprintln(out, e)
+		}
+	}
+	if threshold != 0 && escalation.MaxRisk(escalations) >= threshold {
+		return errEscalation
+	}
+	return nil
+}
+
+// load reads the merged config at path if set, or merges the org configs of
+// the repo at root as of rev, or of its working tree if rev is empty too.
+func load(root, path, rev string) (*org.FullConfig, error) {
+	if path != "" {
+		return loadConfig(path)
+	}
+	repo := &orgconfig.Repo{Root: root}
+	if rev != "" {
+		var err error
+		if repo, err = orgconfig.RepoAt(root, rev); err != nil {
+			return nil, err
+		}
+	} else {
+		rev = "the working tree"
+	}
+	names, err := repo.OrgNames()
+	if err != nil {
+		return nil, fmt.Errorf("listing orgs at %s: %s", rev, err)
+	}
+	cfg := org.FullConfig{Orgs: map[string]org.Config{}}
+	for _, name := range names {
+		o, err := repo.LoadOrg(name)
+		if err != nil {
+			return nil, fmt.Errorf("loading %s at %s: %v", name, rev, err)
+		}
+		cfg.Orgs[name] = o.Config
+	}
+	return &cfg, nil
+}
+
+func loadConfig(path string) (*org.FullConfig, error) {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %v", path, err)
+	}
+	var cfg org.FullConfig
+	if err := yaml.Unmarshal(buf, &cfg); err != nil {
+		return nil, fmt.Errorf("unmarshal %s: %v", path, err)
+	}
+	return &cfg, nil
+}
@@ -0,0 +1,174 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, "base.yaml")
+	head := filepath.Join(dir, "head.yaml")
+	if err := os.WriteFile(base, []byte(`orgs:
+  kubernetes:
+    teams:
+      sig-foo:
+        members: [bob]
+`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(head, []byte(`orgs:
+  kubernetes:
+    teams:
+      sig-foo:
+        maintainers: [bob]
+`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name     string
+		o        options
+		expected string
+		err      error
+	}{
+		{
+			name:     "below threshold",
+			o:        options{base: base, head: head, failOn: "high"},
+			expected: "medium: kubernetes: bob added as maintainer of team sig-foo [team-maintainer-added]\n",
+		},
+		{
+			name:     "labels",
+			o:        options{base: base, head: head, failOn: "medium", labels: true},
+			expected: "privilege-escalation/team-maintainer-added\n",
+			err:      errEscalation,
+		},
+		{
+			name: "no escalations",
+			o:    options{base: head, head: base, failOn: "medium"},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var out bytes.Buffer
+			if err := run(c.o, &out); !errors.Is(err, c.err) {
+				t.Errorf("expected error %v, got %v", c.err, err)
+			}
+			if out.String() != c.expected {
+				t.Errorf("expected output %q, got %q", c.expected, out.String())
+			}
+		})
+	}
+}
+
+func TestRunAgainstRevision(t *testing.T) {
+	root := t.TempDir()
+	write := func(file, contents string) {
+		t.Helper()
+		path := filepath.Join(root, file)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("config/kubernetes/org.yaml", "admins: [alice]\nmembers: [bob]\n")
+	write("config/kubernetes/sig-foo/teams.yaml", `teams:
+  sig-foo:
+    members: [bob]
+`)
+
+	r, err := git.PlainInit(root, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := r.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Add("config"); err != nil {
+		t.Fatal(err)
+	}
+	sig := &object.Signature{Name: "escalations", Email: "escalations@example.com", When: time.Now()}
+	if _, err := w.Commit("base", &git.CommitOptions{Author: sig}); err != nil {
+		t.Fatal(err)
+	}
+
+	// the renamed team is matched to sig-foo, only the new maintainer is
+	// an escalation
+	write("config/kubernetes/sig-foo/teams.yaml", `teams:
+  sig-foo-renamed:
+    previously: [sig-foo]
+    maintainers: [alice]
+    members: [bob]
+`)
+
+	cases := []struct {
+		name     string
+		o        options
+		expected string
+		err      error
+	}{
+		{
+			name:     "working tree against a revision",
+			o:        options{root: root, baseRev: "HEAD", failOn: "high"},
+			expected: "medium: kubernetes: alice added as maintainer of team sig-foo-renamed [team-maintainer-added]\n",
+		},
+		{
+			name: "revision against itself",
+			o:    options{root: root, baseRev: "HEAD", headRev: "HEAD", failOn: "medium"},
+		},
+		{
+			name: "unknown revision",
+			o:    options{root: root, baseRev: "no-such-branch", failOn: "medium"},
+			err:  errAny,
+		},
+		{
+			name: "both a base file and revision",
+			o:    options{root: root, base: "base.yaml", baseRev: "HEAD", failOn: "medium"},
+			err:  errAny,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var out bytes.Buffer
+			err := run(c.o, &out)
+			switch {
+			case c.err == errAny && err == nil:
+				t.Errorf("expected an error")
+			case c.err != errAny && !errors.Is(err, c.err):
+				t.Errorf("expected error %v, got %v", c.err, err)
+			}
+			if out.String() != c.expected {
+				t.Errorf("expected output %q, got %q", c.expected, out.String())
+			}
+		})
+	}
+}
+
+// errAny matches any non-nil error in test cases.
+var errAny = errors.New("any error")
@@ -18,12 +18,7 @@ package helpers
 
 import (
 	"fmt"
-	"os"
 	"strings"
-
-	"sigs.k8s.io/prow/pkg/config/org"
-
-	"sigs.k8s.io/yaml"
 )
 
 func ParseKeyValue(s string) (string, string) {
@@ -56,15 +51,3 @@ func (fm FlagMap) Set(s string) error {
 	fm[k] = v
 	return nil
 }
-
-func UnmarshalPathToOrgConfig(path string) (*org.Config, error) {
-	buf, err := os.ReadFile(path)
-	if err != nil {
-		return nil, fmt.Errorf("read: %v", err)
-	}
-	var cfg org.Config
-	if err := yaml.Unmarshal(buf, &cfg); err != nil {
-		return nil, fmt.Errorf("unmarshal: %v", err)
-	}
-	return &cfg, nil
-}
@@ -0,0 +1,206 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/prow/pkg/github"
+
+	"k8s.io/org/pkg/orgconfig"
+)
+
+// orgAdminSource is listed as the source of the access of org admins.
+const orgAdminSource = "org admin"
+
+// AccessReport lists the teams and users with access to a repo.
+type AccessReport struct {
+	Org  string `json:"org"`
+	Repo string `json:"repo"`
+	// BasePermission is the permission every org member has on the repo.
+	BasePermission string       `json:"basePermission"`
+	Teams          []TeamAccess `json:"teams"`
+	Users          []UserAccess `json:"users"`
+	// Warnings is set if the repo is not referenced anywhere in the config of
+	// the org, which usually means it is misspelled or does not exist.
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// TeamAccess is the effective permission of a team on a repo.
+type TeamAccess struct {
+	// Team is the full name of the team, with parent teams separated by "/".
+	Team       string `json:"team"`
+	Permission string `json:"permission"`
+	// InheritedFrom is set to the full name of the parent team granting the
+	// permission if the team does not list the repo itself.
+	InheritedFrom string `json:"inheritedFrom,omitempty"`
+}
+
+// UserAccess is the highest permission of a user on a repo, above the base
+// permission of org members.
+type UserAccess struct {
+	User       string `json:"user"`
+	Permission string `json:"permission"`
+	// Via lists the teams granting the permission, or "org admin".
+	Via []string `json:"via"`
+}
+
+// RepoAccess builds the access report of the repo, given as <org>/<repo>, and
+// writes it to out.
+func RepoAccess(o Options, orgRepo string, out io.Writer) error {
+	orgName, repoName, ok := strings.Cut(orgRepo, "/")
+	if !ok || orgName == "" || repoName == "" || strings.Contains(repoName, "/") {
+		return fmt.Errorf("invalid repo %q, must be <org>/<repo>", orgRepo)
+	}
+	repo := &orgconfig.Repo{Root: o.RepoRoot, AllowOverride: o.AllowOverride}
+	orgCfg, err := repo.LoadOrg(orgName)
+	if err != nil {
+		return err
+	}
+
+	report := repoAccess(orgCfg, repoName)
+	if o.AccessFormat == reportFormatJSON {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	}
+	writeAccessText(out, report)
+	return nil
+}
+
+func repoAccess(o *orgconfig.Org, repoName string) AccessReport {
+	report := AccessReport{
+		Org:            o.Name,
+		Repo:           repoName,
+		BasePermission: string(github.Read),
+		Teams:          []TeamAccess{},
+		Users:          []UserAccess{},
+	}
+	if p := o.Config.DefaultRepositoryPermission; p != nil {
+		report.BasePermission = string(*p)
+	}
+
+	users := map[string]*UserAccess{}
+	grant := func(login string, permission github.RepoPermissionLevel, via string) {
+		key := github.NormLogin(login)
+		u, ok := users[key]
+		switch {
+		case !ok || orgconfig.PermissionRank(permission) > orgconfig.PermissionRank(github.RepoPermissionLevel(u.Permission)):
+			users[key] = &UserAccess{User: login, Permission: string(permission), Via: []string{via}}
+		case permission == github.RepoPermissionLevel(u.Permission) && !slices.Contains(u.Via, via):
+			u.Via = append(u.Via, via)
+		}
+	}
+	for _, admin := range o.Config.Admins {
+		grant(admin, github.Admin, orgAdminSource)
+	}
+
+	for _, t := range o.Teams() {
+		access, ok := teamAccess(o, t, repoName)
+		if !ok {
+			continue
+		}
+		report.Teams = append(report.Teams, access)
+		for _, login := range append(append([]string{}, t.Maintainers...), t.Members...) {
+			grant(login, github.RepoPermissionLevel(access.Permission), access.Team)
+		}
+	}
+
+	referenced := len(report.Teams) > 0
+	for name := range o.Config.Repos {
+		referenced = referenced || strings.EqualFold(name, repoName)
+	}
+	if !referenced {
+		report.Warnings = append(report.Warnings, fmt.Sprintf("repo %s is not referenced by any team or in the repos of org %s, only org admins and members have access", repoName, o.Name))
+	}
+
+	for _, u := range users {
+		if orgconfig.PermissionRank(github.RepoPermissionLevel(u.Permission)) > orgconfig.PermissionRank(github.RepoPermissionLevel(report.BasePermission)) ||
+			slices.Contains(u.Via, orgAdminSource) {
+			sort.Strings(u.Via)
+			report.Users = append(report.Users, *u)
+		}
+	}
+	sort.SliceStable(report.Teams, func(i, j int) bool {
+		a, b := report.Teams[i], report.Teams[j]
+		if a.Permission != b.Permission {
+			return orgconfig.PermissionRank(github.RepoPermissionLevel(a.Permission)) > orgconfig.PermissionRank(github.RepoPermissionLevel(b.Permission))
+		}
+		return a.Team < b.Team
+	})
+	sort.Slice(report.Users, func(i, j int) bool {
+		a, b := report.Users[i], report.Users[j]
+		if a.Permission != b.Permission {
+			return orgconfig.PermissionRank(github.RepoPermissionLevel(a.Permission)) > orgconfig.PermissionRank(github.RepoPermissionLevel(b.Permission))
+		}
+		return strings.ToLower(a.User) < strings.ToLower(b.User)
+	})
+	return report
+}
+
+// teamAccess returns the highest permission of the team on the repo, listed
+// by the team itself or inherited from one of its parents.
+func teamAccess(o *orgconfig.Org, t orgconfig.Team, repoName string) (TeamAccess, bool) {
+	var access TeamAccess
+	var best github.RepoPermissionLevel
+	// the team itself goes first so that it wins over parents with the same
+	// permission
+	teams := []orgconfig.Team{t}
+	for i := len(t.Parents) - 1; i >= 0; i-- {
+		if parent, ok := o.Team(t.Parents[i]); ok {
+			teams = append(teams, parent)
+		}
+	}
+	for _, team := range teams {
+		for name, permission := range team.Repos {
+			if !strings.EqualFold(name, repoName) || orgconfig.PermissionRank(permission) <= orgconfig.PermissionRank(best) {
+				continue
+			}
+			best = permission
+			access = TeamAccess{Team: t.FullName(), Permission: string(permission)}
+			if team.FullName() != t.FullName() {
+				access.InheritedFrom = team.FullName()
+			}
+		}
+	}
+	return access, best != ""
+}
+
+func writeAccessText(w io.Writer, r AccessReport) {
+	fmt.Fprintf(w, "%s/%s:\n", r.Org, r.Repo)
+	for _, warning := range r.Warnings {
+		fmt.Fprintf(w, "  warning: %s\n", warning)
+	}
+	fmt.Fprintf(w, "  org members: %s\n", r.BasePermission)
+	fmt.Fprintf(w, "  teams (%d):\n", len(r.Teams))
+	for _, t := range r.Teams {
+		inherited := ""
+		if t.InheritedFrom != "" {
+			inherited = fmt.Sprintf(" (inherited from %s)", t.InheritedFrom)
+		}
+		fmt.Fprintf(w, "    %-8s %s%s\n", t.Permission, t.Team, inherited)
+	}
+	fmt.Fprintf(w, "  users (%d):\n", len(r.Users))
+	for _, u := range r.Users {
+		fmt.Fprintf(w, "    %-8s %s (%s)\n", u.Permission, u.User, strings.Join(u.Via, ", "))
+	}
+}
@@ -0,0 +1,79 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestRepoAccess(t *testing.T) {
+	root := setupRepoRoot(t)
+	writeFile(t, root, "config/kubernetes/sig-network/sig-network-leads/teams.yaml", `teams:
+  sig-network-leads-emeritus:
+    members:
+    - aojea
+    - shaneutt
+  sig-network-leads-triage:
+    members:
+    - thockin
+    repos:
+      ingress-gce: triage
+      kube-proxy: write
+`)
+
+	expected := `kubernetes/Ingress-GCE:
+  org members: read
+  teams (3):
+    admin    sig-network-leads
+    admin    sig-network-leads/sig-network-leads-emeritus (inherited from sig-network-leads)
+    admin    sig-network-leads/sig-network-leads-triage (inherited from sig-network-leads)
+  users (5):
+    admin    aojea (sig-network-leads, sig-network-leads/sig-network-leads-emeritus)
+    admin    cblecker (org admin, sig-network-leads)
+    admin    k8s-ci-robot (org admin)
+    admin    shaneutt (sig-network-leads/sig-network-leads-emeritus)
+    admin    thockin (sig-network-leads, sig-network-leads/sig-network-leads-triage)
+`
+	var out bytes.Buffer
+	if err := RepoAccess(Options{RepoRoot: root, AccessFormat: reportFormatText}, "kubernetes/Ingress-GCE", &out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out.String() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, out.String())
+	}
+
+	expected = `kubernetes/ingress-gc:
+  warning: repo ingress-gc is not referenced by any team or in the repos of org kubernetes, only org admins and members have access
+  org members: read
+  teams (0):
+  users (2):
+    admin    cblecker (org admin)
+    admin    k8s-ci-robot (org admin)
+`
+	out.Reset()
+	if err := RepoAccess(Options{RepoRoot: root, AccessFormat: reportFormatText}, "kubernetes/ingress-gc", &out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out.String() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, out.String())
+	}
+
+	if err := RepoAccess(Options{RepoRoot: root}, "kubernetes", &out); err == nil {
+		t.Errorf("expected an error for a repo without an org")
+	}
+}
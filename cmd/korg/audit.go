@@ -20,15 +20,14 @@ import (
 	"bufio"
 	"bytes"
 	"encoding/csv"
-	"encoding/json"
 	"fmt"
 	"io"
-	"net/http"
 	"os"
 	"sort"
 	"strings"
 
 	"github.com/olekukonko/tablewriter"
+	"sigs.k8s.io/prow/pkg/github"
 )
 
 type Contribution struct {
@@ -62,9 +61,15 @@ type AuditOptions struct {
 	Period            string
 	ActivityThreshold int
 	OutputFile        string
+	Format            string
 	ExceptionsFile    string
 	CheckOwners       bool
 	CheckTeams        bool
+	IncludeReadOnly   bool
+	Apply             bool
+	DevStatsURLs      []string
+	ContributionsFile string
+	OwnersPath        string
 }
 
 type UserInfo struct {
@@ -73,6 +78,10 @@ type UserInfo struct {
 	Orgs          []string
 	Teams         map[string][]string
 	IsOwner       bool
+	// OwnersFiles lists the OWNERS file entries of the user, if known.
+	OwnersFiles []OwnerEntry
+	// ExceptionReason is set for users listed in the exceptions file.
+	ExceptionReason string
 }
 
 type Exception struct {
@@ -82,6 +91,7 @@ type Exception struct {
 
 func GetAllUsersInOrgs(o Options, orgs []string) (map[string]UserInfo, error) {
 	users := make(map[string]UserInfo)
+	o.Orgs = orgs
 	config, err := LoadOrgs(o)
 	if err != nil {
 		return nil, err
@@ -124,114 +134,6 @@ func GetAllUsersInOrgs(o Options, orgs []string) (map[string]UserInfo, error) {
 	return users, nil
 }
 
-func GetContributions(period string) (map[string]Contribution, error) {
-	sources := []string{
-		"https://k8s.devstats.cncf.io/api/ds/query",
-		"https://etcd.devstats.cncf.io/api/ds/query",
-	}
-
-	combined := make(map[string]Contribution)
-
-	for _, url := range sources {
-		contribs, err := fetchContributionsFromDevStats(period, url)
-		if err != nil {
-			return nil, err
-		}
-
-		sourceName := "unknown"
-		if strings.Contains(url, "k8s.devstats") {
-			sourceName = "Kubernetes"
-		} else if strings.Contains(url, "etcd.devstats") {
-			sourceName = "etcd"
-		}
-
-		for username, contrib := range contribs {
-			if existing, found := combined[username]; found {
-				existing.ContribCount += contrib.ContribCount
-				combined[username] = existing
-				fmt.Printf("Merged user %s from %s: new total = %d\n", username, sourceName, existing.ContribCount)
-			} else {
-				combined[username] = contrib
-				fmt.Printf("Added user %s from %s with %d contributions\n", username, sourceName, contrib.ContribCount)
-			}
-		}
-	}
-
-	fmt.Printf("Total combined unique contributors: %d\n", len(combined))
-	return combined, nil
-}
-
-func fetchContributionsFromDevStats(period string, url string) (map[string]Contribution, error) {
-	fmt.Printf("Fetching contributions from %s\n", url)
-
-	postBody := DevStatsRequest{
-		Queries: []Query{
-			{
-				RefID:        "A",
-				DatasourceID: 1,
-				RawSQL: fmt.Sprintf(`select
-  sub."Rank",
-  sub.name as name,
-  sub.value
-from (
-  select row_number() over (order by sum(value) desc) as "Rank",
-    split_part(name, '$$$', 1) as name,
-    sum(value) as value
-  from
-    shdev
-  where
-    series = 'hdev_contributionsallall'
-    and period = '%s'
-  group by
-    split_part(name, '$$$', 1)
-) sub`, period),
-				Format: "table",
-			},
-		},
-	}
-
-	requestBody, err := json.Marshal(postBody)
-	if err != nil {
-		return nil, err
-	}
-
-	resp, err := http.Post(url, "application/json", bytes.NewBuffer(requestBody))
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("bad error code from devstats: %d: %w", resp.StatusCode, err)
-	}
-
-	var parsed map[string]map[string]map[string][]Frames
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-	err = json.Unmarshal(body, &parsed)
-	if err != nil {
-		return nil, fmt.Errorf("unable to parse json from devstats: %w", err)
-	}
-
-	ranks := parsed["results"]["A"]["frames"][0].Data.Items[0]
-	usernames := parsed["results"]["A"]["frames"][0].Data.Items[1]
-	contribCounts := parsed["results"]["A"]["frames"][0].Data.Items[2]
-
-	contribs := make(map[string]Contribution)
-	for i := 0; i < len(ranks); i++ {
-		username := usernames[i].(string)
-		contribs[username] = Contribution{
-			Rank:         int(ranks[i].(float64)),
-			Username:     username,
-			ContribCount: int(contribCounts[i].(float64)),
-			Orgs:         []string{},
-		}
-	}
-	return contribs, nil
-}
-
 func ReadExceptions(filepath string) ([]Exception, error) {
 	var exceptions []Exception
 
@@ -253,8 +155,16 @@ func ReadExceptions(filepath string) ([]Exception, error) {
 	return exceptions, nil
 }
 
+// contributionsOf returns the contributions of username. Contributions are
+// keyed by github.NormLogin as the sources don't use the casing of the org
+// configs.
+func contributionsOf(contribs map[string]Contribution, username string) (Contribution, bool) {
+	c, found := contribs[github.NormLogin(username)]
+	return c, found
+}
+
 func usernameNotInContributors(contribs map[string]Contribution, username string) bool {
-	_, found := contribs[username]
+	_, found := contributionsOf(contribs, username)
 
 	return !found
 }
@@ -264,7 +174,7 @@ func usernameBelowActivityThreshold(contribs map[string]Contribution, username s
 		return false
 	}
 
-	if contribs[strings.ToLower(username)].ContribCount <= activityThreshold {
+	if c, _ := contributionsOf(contribs, username); c.ContribCount <= activityThreshold {
 		return true
 	}
 
@@ -273,7 +183,7 @@ func usernameBelowActivityThreshold(contribs map[string]Contribution, username s
 
 func usernameInExceptions(exceptionalUsers []string, username string) bool {
 	for _, exceptionalUser := range exceptionalUsers {
-		if exceptionalUser == username {
+		if strings.EqualFold(exceptionalUser, username) {
 			return true
 		}
 	}
@@ -282,11 +192,25 @@ func usernameInExceptions(exceptionalUsers []string, username string) bool {
 }
 
 func OrgAudit(o Options) error {
-	fmt.Printf("Running analysis with a lookback period of %s and activity threshold of %d\n", o.Period, o.ActivityThreshold)
+	// the report may be written to stdout, so progress is logged to stderr
+	log := os.Stderr
 
+	out := io.Writer(os.Stdout)
+	if o.OutputFile != "" {
+		f, err := os.Create(o.OutputFile)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+
+	fmt.Fprintf(log, "Running analysis with a lookback period of %s and activity threshold of %d\n", o.Period, o.ActivityThreshold)
+
+	exceptionReasons := map[string]string{}
 	var exceptionalUsers []string
 	if o.ExceptionsFile != "" {
-		fmt.Printf("reading exceptions from %s\n", o.ExceptionsFile)
+		fmt.Fprintf(log, "reading exceptions from %s\n", o.ExceptionsFile)
 		exceptions, err := ReadExceptions(o.ExceptionsFile)
 		if err != nil {
 			return err
@@ -295,11 +219,12 @@ func OrgAudit(o Options) error {
 		// build indexable map for exceptions
 		for _, exception := range exceptions {
 			exceptionalUsers = append(exceptionalUsers, exception.Username)
+			exceptionReasons[github.NormLogin(exception.Username)] = exception.Reason
 		}
 
-		// Print exceptions to stdout
-		fmt.Println("Total Exceptions:", len(exceptions))
-		table := tablewriter.NewWriter(os.Stdout)
+		// Print exceptions
+		fmt.Fprintln(log, "Total Exceptions:", len(exceptions))
+		table := tablewriter.NewWriter(log)
 		table.SetHeader([]string{"Username", "Reason"})
 		for _, exception := range exceptions {
 			table.Append([]string{exception.Username, exception.Reason})
@@ -307,35 +232,49 @@ func OrgAudit(o Options) error {
 		table.Render()
 	}
 
-	fmt.Println("fetching data from devstats")
-	contributions, err := GetContributions(o.Period)
+	fmt.Fprintln(log, "fetching contributions")
+	contributions, err := newContributionSource(o.AuditOptions).Contributions(o.Period)
 	if err != nil {
 		return err
 	}
 
-	fmt.Println("total contributors:", len(contributions))
+	fmt.Fprintln(log, "total contributors:", len(contributions))
+
+	orgs := o.Orgs
+	if len(orgs) == 0 {
+		registry, err := loadOrgRegistry(o.RepoRoot)
+		if err != nil {
+			return err
+		}
+		orgs = registry.Names(o.IncludeReadOnly)
+	}
 
-	fmt.Println("fetching org members")
-	users, err := GetAllUsersInOrgs(o, validOrgs)
+	fmt.Fprintf(log, "fetching org members of %s\n", strings.Join(orgs, ", "))
+	users, err := GetAllUsersInOrgs(o, orgs)
 	if err != nil {
 		return err
 	}
 
-	fmt.Println("filtering org members")
+	fmt.Fprintln(log, "filtering org members")
 	var orgMembersBelowThresholdAfterException []UserInfo
+	var exceptedMembersBelowThreshold []UserInfo
 	for _, userInfo := range users {
-		if usernameInExceptions(exceptionalUsers, userInfo.Username) {
-			fmt.Printf("username %s in exceptions. skipping...\n", userInfo.Username)
+		if !usernameNotInContributors(contributions, userInfo.Username) &&
+			!usernameBelowActivityThreshold(contributions, userInfo.Username, o.ActivityThreshold) {
 			continue
 		}
-		if usernameNotInContributors(contributions, userInfo.Username) ||
-			usernameBelowActivityThreshold(contributions, userInfo.Username, o.ActivityThreshold) {
-
-			userInfo.Contributions = contributions[userInfo.Username].ContribCount
-			orgMembersBelowThresholdAfterException = append(orgMembersBelowThresholdAfterException, userInfo)
 
-			fmt.Println("user below threshold or not in devstats:", userInfo.Username, " contributions: ", contributions[userInfo.Username].ContribCount)
+		contribution, _ := contributionsOf(contributions, userInfo.Username)
+		userInfo.Contributions = contribution.ContribCount
+		if usernameInExceptions(exceptionalUsers, userInfo.Username) {
+			fmt.Fprintf(log, "username %s in exceptions. skipping...\n", userInfo.Username)
+			userInfo.ExceptionReason = exceptionReasons[github.NormLogin(userInfo.Username)]
+			exceptedMembersBelowThreshold = append(exceptedMembersBelowThreshold, userInfo)
+			continue
 		}
+
+		orgMembersBelowThresholdAfterException = append(orgMembersBelowThresholdAfterException, userInfo)
+		fmt.Fprintln(log, "user below threshold or not in devstats:", userInfo.Username, " contributions: ", contribution.ContribCount)
 	}
 
 	// sort users for readability
@@ -345,68 +284,48 @@ func OrgAudit(o Options) error {
 
 	// populate if member is an owner
 	if o.CheckOwners {
+		resolver, err := newOwnerResolver(o.AuditOptions)
+		if err != nil {
+			return err
+		}
 		for i, member := range orgMembersBelowThresholdAfterException {
-			isOwner, err := IsOwner(member.Username)
+			isOwner, entries, err := resolver.Owners(member.Username)
 			if err != nil {
 				return err
 			}
-			fmt.Printf("checking if user %s is owner: %v\n", member.Username, isOwner)
+			fmt.Fprintf(log, "checking if user %s is owner: %v\n", member.Username, isOwner)
 			member.IsOwner = isOwner
+			member.OwnersFiles = entries
 			orgMembersBelowThresholdAfterException[i] = member
 		}
 	}
 
-	fmt.Println("Total \"Org Members\":", len(users))
-	fmt.Println("Total \"Org Members\" below threshold after exceptions:", len(orgMembersBelowThresholdAfterException))
+	fmt.Fprintln(log, "Total \"Org Members\":", len(users))
+	fmt.Fprintln(log, "Total \"Org Members\" below threshold after exceptions:", len(orgMembersBelowThresholdAfterException))
 
-	f, err := os.Create(o.OutputFile)
-	if err != nil {
-		return err
+	report := AuditReport{
+		Period:            o.Period,
+		ActivityThreshold: o.ActivityThreshold,
+		Orgs:              orgs,
+		Members:           []AuditRecord{},
 	}
+	for _, u := range append(orgMembersBelowThresholdAfterException, exceptedMembersBelowThreshold...) {
+		report.Members = append(report.Members, newAuditRecord(u, o.CheckOwners && u.ExceptionReason == ""))
+	}
+	sort.SliceStable(report.Members, func(i, j int) bool {
+		return report.Members[i].Username < report.Members[j].Username
+	})
 
-	w := bufio.NewWriter(f)
-	table := tablewriter.NewWriter(w)
-	table.SetAutoWrapText(false)
-
-	headers := []string{"Username", "Orgs"}
-	if o.CheckTeams {
-		headers = append(headers, "Teams")
+	w := bufio.NewWriter(out)
+	if err := writeAuditReport(w, report, o); err != nil {
+		return err
 	}
-	if o.CheckOwners {
-		headers = append(headers, "Owner", "Owners Link")
+	if err := w.Flush(); err != nil {
+		return err
 	}
-	table.SetHeader(headers)
-
-	table.SetBorders(tablewriter.Border{Left: true, Top: false, Right: true, Bottom: false})
-	table.SetCenterSeparator("|")
-
-	for _, v := range orgMembersBelowThresholdAfterException {
-		row := []string{
-			v.Username,
-			strings.Join(v.Orgs, ", "),
-		}
 
-		if o.CheckTeams {
-			teams := []string{}
-			for org, team := range v.Teams {
-				teams = append(teams, fmt.Sprintf("%s: %s", org, strings.Join(team, ", ")))
-			}
-			row = append(row, strings.Join(teams, "; "))
-		}
-
-		if o.CheckOwners {
-			if v.IsOwner {
-				row = append(row, "yes", fmt.Sprintf("https://go.k8s.io/owners/%s", v.Username))
-			} else {
-				row = append(row, "no", "")
-			}
-		}
-
-		table.Append(row)
+	if o.Apply {
+		return ApplyAudit(o, orgMembersBelowThresholdAfterException, orgs)
 	}
-	table.Render()
-
-	w.Flush()
-
 	return nil
 }
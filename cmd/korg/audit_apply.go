@@ -0,0 +1,73 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ApplyAudit removes the inactive members found by an audit of orgs from
+// every org and team they belong to, in a single commit. Org admins and
+// approvers in OWNERS files under config/ are kept, as are users found to be
+// owners when running with --check-owners.
+func ApplyAudit(o Options, inactive []UserInfo, orgs []string) error {
+	if !o.Confirm {
+		fmt.Println("!!! running in dry-run mode. pass --confirm to persist changes.")
+	}
+
+	approvers, err := findConfigApprovers(o.RepoRoot)
+	if err != nil {
+		return fmt.Errorf("reading OWNERS files: %s", err)
+	}
+
+	b, err := newBatch(o)
+	if err != nil {
+		return err
+	}
+	for _, u := range inactive {
+		if files, ok := approvers[strings.ToLower(u.Username)]; ok {
+			b.skip("user %s is an approver in %s", u.Username, strings.Join(files, ", "))
+			continue
+		}
+		if u.IsOwner {
+			b.skip("user %s is listed in OWNERS files", u.Username)
+			continue
+		}
+		b.planRemoveFromOrgsAndTeams(u.Username, orgs)
+	}
+
+	return b.apply(auditCommitMessage(o, orgs, b.users()))
+}
+
+func auditCommitMessage(o Options, orgs []string, removed []string) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "remove %d inactive members\n\n", len(removed))
+	fmt.Fprintf(&sb, "Audit parameters:\n")
+	fmt.Fprintf(&sb, "  orgs: %s\n", strings.Join(orgs, ", "))
+	fmt.Fprintf(&sb, "  period: %s\n", o.Period)
+	fmt.Fprintf(&sb, "  activity threshold: %d\n", o.ActivityThreshold)
+	if o.ExceptionsFile != "" {
+		fmt.Fprintf(&sb, "  exceptions file: %s\n", o.ExceptionsFile)
+	}
+	fmt.Fprintf(&sb, "  check owners: %t\n", o.CheckOwners)
+	fmt.Fprintf(&sb, "\nRemoved members:\n")
+	for _, u := range removed {
+		fmt.Fprintf(&sb, "- %s\n", u)
+	}
+	return sb.String()
+}
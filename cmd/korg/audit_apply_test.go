@@ -0,0 +1,176 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+
+	"k8s.io/org/pkg/orgconfig"
+)
+
+func TestRemoveFromAllTeams(t *testing.T) {
+	root := setupRepoRoot(t)
+
+	orgCfg, err := (&orgconfig.Repo{Root: root}).LoadOrg("kubernetes")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var changes orgconfig.Changes
+	if err := removeFromAllTeams(&changes, orgCfg, "CBlecker"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	file := "config/kubernetes/sig-network/teams.yaml"
+	expected := []orgconfig.Edit{
+		{
+			File:   file,
+			Path:   []string{"teams", "sig-network-leads", "maintainers"},
+			Value:  "CBlecker",
+			Remove: true,
+		},
+	}
+	if files := changes.Files(); !reflect.DeepEqual(files, []string{file}) {
+		t.Fatalf("expected edits of %s, got %v", file, files)
+	}
+	if edits := changes.Edits(file); !reflect.DeepEqual(edits, expected) {
+		t.Errorf("expected %#v, got %#v", expected, edits)
+	}
+}
+
+func TestAuditMatchesLoginsCaseInsensitively(t *testing.T) {
+	contribs := map[string]Contribution{
+		"bob": {Username: "bob", ContribCount: 3},
+	}
+	if usernameNotInContributors(contribs, "Bob") {
+		t.Errorf("expected Bob to match the contributions of bob")
+	}
+	if usernameBelowActivityThreshold(contribs, "Bob", 1) {
+		t.Errorf("expected Bob to be above the activity threshold")
+	}
+	if !usernameInExceptions([]string{"BOB"}, "Bob") {
+		t.Errorf("expected Bob to match the exception for BOB")
+	}
+}
+
+func TestApplyAudit(t *testing.T) {
+	root := setupRepoRoot(t)
+	writeFile(t, root, "config/kubernetes/OWNERS", "approvers:\n- zed\n")
+
+	o := Options{Confirm: true, RepoRoot: root}
+	o.Period = "y"
+	inactive := []UserInfo{
+		{Username: "08volt", IsOwner: true},
+		{Username: "aojea"},
+		{Username: "cblecker"},
+		{Username: "thockin"},
+		{Username: "zed"},
+	}
+	if err := ApplyAudit(o, inactive, []string{"kubernetes"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	orgYAML, err := orgconfig.ParseDocument(readTestdata(t, "yamledit/org.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	teamsYAML, err := orgconfig.ParseDocument(readTestdata(t, "yamledit/teams.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := orgYAML.RemoveFromList("aojea", "members"); err != nil {
+		t.Fatal(err)
+	}
+	for _, u := range []string{"aojea", "thockin"} {
+		if _, err := teamsYAML.RemoveFromList(u, "teams", "sig-network-leads", "members"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if got := mustReadFile(t, filepath.Join(root, "config/kubernetes/org.yaml")); string(got) != string(orgYAML.Bytes()) {
+		t.Errorf("unexpected org.yaml contents:\n%s", got)
+	}
+	if got := mustReadFile(t, filepath.Join(root, "config/kubernetes/sig-network/teams.yaml")); string(got) != string(teamsYAML.Bytes()) {
+		t.Errorf("unexpected teams.yaml contents:\n%s", got)
+	}
+
+	r, err := git.PlainOpen(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	head, err := r.Head()
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := r.CommitObject(head.Hash())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `remove 2 inactive members
+
+Audit parameters:
+  orgs: kubernetes
+  period: y
+  activity threshold: 0
+  check owners: false
+
+Removed members:
+- aojea
+- thockin
+`
+	if c.Message != expected {
+		t.Errorf("expected commit message %q, got %q", expected, c.Message)
+	}
+}
+
+func TestOrgAuditApplyMatchesLoginsCaseInsensitively(t *testing.T) {
+	root := setupRepoRoot(t)
+	// the contributions use a different casing than org.yaml for Bob and aojea
+	writeFile(t, root, "contributions.json", `[
+  {"username": "08volt", "contributions": 3},
+  {"username": "249043822", "contributions": 3},
+  {"username": "AOJEA", "contributions": 3},
+  {"username": "bob", "contributions": 3},
+  {"username": "CBlecker", "contributions": 3},
+  {"username": "k8s-ci-robot", "contributions": 3},
+  {"username": "thockin", "contributions": 3},
+  {"username": "shaneutt", "contributions": 3}
+]`)
+
+	o := Options{Confirm: true, RepoRoot: root, Orgs: []string{"kubernetes"}}
+	o.Period = "y"
+	o.Apply = true
+	o.Format = auditFormatTable
+	o.OutputFile = filepath.Join(t.TempDir(), "report.txt")
+	o.ContributionsFile = filepath.Join(root, "contributions.json")
+	if err := OrgAudit(o); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	orgYAML := string(mustReadFile(t, filepath.Join(root, "config/kubernetes/org.yaml")))
+	for _, member := range []string{"aojea", "Bob"} {
+		if !strings.Contains(orgYAML, "- "+member+"\n") {
+			t.Errorf("expected active member %s to be kept, got:\n%s", member, orgYAML)
+		}
+	}
+	if strings.Contains(orgYAML, "zed") {
+		t.Errorf("expected inactive member zed to be removed, got:\n%s", orgYAML)
+	}
+}
@@ -0,0 +1,254 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+)
+
+const (
+	auditFormatTable    = "table"
+	auditFormatMarkdown = "markdown"
+	auditFormatJSON     = "json"
+	auditFormatCSV      = "csv"
+)
+
+var auditFormats = []string{auditFormatTable, auditFormatMarkdown, auditFormatJSON, auditFormatCSV}
+
+// AuditReport is the machine readable result of an audit.
+type AuditReport struct {
+	Period            string        `json:"period"`
+	ActivityThreshold int           `json:"activityThreshold"`
+	Orgs              []string      `json:"orgs"`
+	Members           []AuditRecord `json:"members"`
+}
+
+// AuditRecord describes a single member below the activity threshold. Members
+// listed in the exceptions file are included with their exception reason.
+type AuditRecord struct {
+	Username      string              `json:"username"`
+	Orgs          []string            `json:"orgs"`
+	Teams         map[string][]string `json:"teams"`
+	Contributions int                 `json:"contributions"`
+	// IsOwner is nil if owners were not checked.
+	IsOwner *bool `json:"isOwner"`
+	// OwnersFiles is only populated when OWNERS files are scanned locally.
+	OwnersFiles     []OwnerEntry `json:"ownersFiles,omitempty"`
+	ExceptionReason string       `json:"exceptionReason"`
+}
+
+func newAuditRecord(u UserInfo, checkOwners bool) AuditRecord {
+	r := AuditRecord{
+		Username:        u.Username,
+		Orgs:            append([]string{}, u.Orgs...),
+		Teams:           map[string][]string{},
+		Contributions:   u.Contributions,
+		ExceptionReason: u.ExceptionReason,
+	}
+	sort.Strings(r.Orgs)
+	for org, teams := range u.Teams {
+		r.Teams[org] = append([]string{}, teams...)
+		sort.Strings(r.Teams[org])
+	}
+	if checkOwners {
+		isOwner := u.IsOwner
+		r.IsOwner = &isOwner
+		r.OwnersFiles = u.OwnersFiles
+	}
+	return r
+}
+
+func validateAuditFormat(format string) error {
+	for _, f := range auditFormats {
+		if f == format {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid format %q, must be one of: %s", format, strings.Join(auditFormats, ", "))
+}
+
+func writeAuditReport(w io.Writer, report AuditReport, o Options) error {
+	switch o.Format {
+	case auditFormatJSON:
+		e := json.NewEncoder(w)
+		e.SetIndent("", "  ")
+		return e.Encode(report)
+	case auditFormatCSV:
+		return writeAuditCSV(w, report.Members)
+	case auditFormatMarkdown:
+		return writeAuditMarkdown(w, report.Members, o)
+	default:
+		writeAuditTable(w, report.Members, o)
+		return nil
+	}
+}
+
+func writeAuditCSV(w io.Writer, records []AuditRecord) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"username", "orgs", "teams", "contributions", "is_owner", "owners_files", "exception_reason"}); err != nil {
+		return err
+	}
+	for _, r := range records {
+		isOwner := ""
+		if r.IsOwner != nil {
+			isOwner = strconv.FormatBool(*r.IsOwner)
+		}
+		err := cw.Write([]string{
+			r.Username,
+			strings.Join(r.Orgs, ";"),
+			strings.Join(qualifiedTeams(r.Teams), ";"),
+			strconv.Itoa(r.Contributions),
+			isOwner,
+			strings.Join(ownersFileNames(r.OwnersFiles), ";"),
+			r.ExceptionReason,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// qualifiedTeams flattens teams per org into sorted org/team names.
+func qualifiedTeams(teams map[string][]string) []string {
+	out := []string{}
+	for org, names := range teams {
+		for _, name := range names {
+			out = append(out, org+"/"+name)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+func ownersFileNames(entries []OwnerEntry) []string {
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		names = append(names, e.String())
+	}
+	return names
+}
+
+// auditRows renders the human readable columns used by the table and
+// markdown formats.
+func auditRows(records []AuditRecord, o Options) ([]string, [][]string) {
+	headers := []string{"Username", "Orgs"}
+	if o.CheckTeams {
+		headers = append(headers, "Teams")
+	}
+	if o.CheckOwners {
+		headers = append(headers, "Owner", "Owners Link")
+	}
+	if o.ExceptionsFile != "" {
+		headers = append(headers, "Exception")
+	}
+
+	rows := [][]string{}
+	for _, v := range records {
+		row := []string{
+			v.Username,
+			strings.Join(v.Orgs, ", "),
+		}
+
+		if o.CheckTeams {
+			orgs := make([]string, 0, len(v.Teams))
+			for org := range v.Teams {
+				orgs = append(orgs, org)
+			}
+			sort.Strings(orgs)
+
+			teams := []string{}
+			for _, org := range orgs {
+				teams = append(teams, fmt.Sprintf("%s: %s", org, strings.Join(v.Teams[org], ", ")))
+			}
+			row = append(row, strings.Join(teams, "; "))
+		}
+
+		if o.CheckOwners {
+			switch {
+			case v.IsOwner == nil:
+				row = append(row, "", "")
+			case *v.IsOwner && len(v.OwnersFiles) > 0:
+				row = append(row, "yes", strings.Join(ownersFileNames(v.OwnersFiles), ", "))
+			case *v.IsOwner:
+				row = append(row, "yes", fmt.Sprintf("https://go.k8s.io/owners/%s", v.Username))
+			default:
+				row = append(row, "no", "")
+			}
+		}
+
+		if o.ExceptionsFile != "" {
+			row = append(row, v.ExceptionReason)
+		}
+
+		rows = append(rows, row)
+	}
+	return headers, rows
+}
+
+func writeAuditTable(w io.Writer, records []AuditRecord, o Options) {
+	table := tablewriter.NewWriter(w)
+	table.SetAutoWrapText(false)
+
+	headers, rows := auditRows(records, o)
+	table.SetHeader(headers)
+
+	table.SetBorders(tablewriter.Border{Left: true, Top: false, Right: true, Bottom: false})
+	table.SetCenterSeparator("|")
+
+	table.AppendBulk(rows)
+	table.Render()
+}
+
+func writeAuditMarkdown(w io.Writer, records []AuditRecord, o Options) error {
+	headers, rows := auditRows(records, o)
+
+	writeRow := func(cells []string) error {
+		escaped := make([]string, len(cells))
+		for i, c := range cells {
+			escaped[i] = strings.ReplaceAll(c, "|", "\\|")
+		}
+		_, err := fmt.Fprintf(w, "| %s |\n", strings.Join(escaped, " | "))
+		return err
+	}
+
+	if err := writeRow(headers); err != nil {
+		return err
+	}
+	separator := make([]string, len(headers))
+	for i := range separator {
+		separator[i] = "---"
+	}
+	if err := writeRow(separator); err != nil {
+		return err
+	}
+	for _, row := range rows {
+		if err := writeRow(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteAuditReport(t *testing.T) {
+	report := AuditReport{
+		Period:            "y",
+		ActivityThreshold: 5,
+		Orgs:              []string{"kubernetes", "kubernetes-sigs"},
+		Members: []AuditRecord{
+			newAuditRecord(UserInfo{
+				Username:      "alice",
+				Contributions: 3,
+				Orgs:          []string{"kubernetes-sigs", "kubernetes"},
+				Teams: map[string][]string{
+					"kubernetes":      {"sig-network-leads", "sig-network-bugs"},
+					"kubernetes-sigs": {"sig-docs"},
+				},
+				IsOwner: true,
+			}, true),
+			newAuditRecord(UserInfo{
+				Username: "bob|builder",
+				Orgs:     []string{"kubernetes"},
+			}, true),
+			newAuditRecord(UserInfo{
+				Username: "dave",
+				Orgs:     []string{"kubernetes"},
+				IsOwner:  true,
+				OwnersFiles: []OwnerEntry{
+					{File: "kubernetes/kubernetes/OWNERS", Role: "approver"},
+					{File: "kubernetes/org/OWNERS", Role: "reviewer", Alias: "sig-contribex-leads"},
+				},
+			}, true),
+			newAuditRecord(UserInfo{
+				Username:        "carol",
+				Orgs:            []string{"kubernetes"},
+				ExceptionReason: "on leave",
+			}, false),
+		},
+	}
+
+	o := Options{AuditOptions: AuditOptions{CheckTeams: true, CheckOwners: true, ExceptionsFile: "exceptions.csv"}}
+	for _, format := range auditFormats {
+		t.Run(format, func(t *testing.T) {
+			o.Format = format
+			var b bytes.Buffer
+			if err := writeAuditReport(&b, report, o); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			compareGolden(t, filepath.Join("audit", "report."+format), b.Bytes())
+		})
+	}
+}
+
+func TestValidateAuditFormat(t *testing.T) {
+	for _, format := range auditFormats {
+		if err := validateAuditFormat(format); err != nil {
+			t.Errorf("unexpected error for %s: %v", format, err)
+		}
+	}
+	if err := validateAuditFormat("yaml"); err == nil {
+		t.Errorf("expected error for an unknown format")
+	}
+}
@@ -0,0 +1,505 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/prow/pkg/config/org"
+	"sigs.k8s.io/yaml"
+
+	"k8s.io/org/pkg/orgconfig"
+)
+
+// ManifestEntry is a single user in a batch manifest. Orgs default to the
+// orgs passed with --org and sponsors to the ones passed with --sponsor when
+// empty.
+type ManifestEntry struct {
+	Username string   `json:"username"`
+	Orgs     []string `json:"orgs,omitempty"`
+	Teams    []string `json:"teams,omitempty"`
+	Sponsors []string `json:"sponsors,omitempty"`
+}
+
+// Manifest is the YAML form of a batch manifest:
+//
+//	members:
+//	- username: user1
+//	  orgs: [kubernetes, kubernetes-sigs]
+//	  teams: [sig-release/milestone-maintainers]
+//	  sponsors: [alice, bob]
+type Manifest struct {
+	Members []ManifestEntry `json:"members"`
+}
+
+// ReadManifest reads a batch manifest from a YAML file or, if the file has a
+// .csv extension, from a CSV file with a header row containing a username
+// column and optional orgs, teams and sponsors columns. Multiple values in a
+// CSV cell are separated by commas or semicolons.
+func ReadManifest(path string) ([]ManifestEntry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		return parseCSVManifest(data)
+	}
+
+	var m Manifest
+	if err := yaml.Unmarshal(data, &m, yaml.DisallowUnknownFields); err != nil {
+		return nil, fmt.Errorf("unable to unmarshal manifest from %s: %s", path, err)
+	}
+	return m.Members, nil
+}
+
+func parseCSVManifest(data []byte) ([]ManifestEntry, error) {
+	r := csv.NewReader(bytes.NewBuffer(data))
+	r.TrimLeadingSpace = true
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("manifest is empty")
+	}
+
+	columns := map[string]int{}
+	for i, name := range records[0] {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	if _, ok := columns["username"]; !ok {
+		return nil, fmt.Errorf("manifest header must contain a username column")
+	}
+
+	splitCell := func(record []string, column string) []string {
+		i, ok := columns[column]
+		if !ok || i >= len(record) {
+			return nil
+		}
+		values := strings.FieldsFunc(record[i], func(r rune) bool {
+			return r == ',' || r == ';' || r == ' '
+		})
+		if len(values) == 0 {
+			return nil
+		}
+		return values
+	}
+
+	var entries []ManifestEntry
+	for _, record := range records[1:] {
+		entries = append(entries, ManifestEntry{
+			Username: strings.TrimSpace(record[columns["username"]]),
+			Orgs:     splitCell(record, "orgs"),
+			Teams:    splitCell(record, "teams"),
+			Sponsors: splitCell(record, "sponsors"),
+		})
+	}
+	return entries, nil
+}
+
+// batch accumulates changes to config files so they can be validated up
+// front and then written and committed together.
+type batch struct {
+	o        Options
+	registry orgRegistry
+
+	// orgs caches the loaded orgs by name. Planned changes are applied to
+	// the cached orgs so that later entries in the batch see the effect of
+	// earlier ones.
+	orgs    map[string]*orgconfig.Org
+	changes orgconfig.Changes
+
+	// affiliations and existing are used to check the sponsors of new org
+	// members against the org configs as they were before the batch, so
+	// that users added by the batch cannot sponsor each other.
+	affiliations map[string]string
+	existing     map[string]*org.Config
+	// sponsors are the values of the Sponsored-by trailers of the commit.
+	sponsors          []string
+	sponsorOverridden bool
+
+	skipped []string
+	errs    []string
+}
+
+func newBatch(o Options) (*batch, error) {
+	registry, err := loadOrgRegistry(o.RepoRoot)
+	if err != nil {
+		return nil, err
+	}
+	return &batch{
+		o:        o,
+		registry: registry,
+		orgs:     map[string]*orgconfig.Org{},
+		existing: map[string]*org.Config{},
+	}, nil
+}
+
+func (b *batch) org(name string) (*orgconfig.Org, error) {
+	if o, ok := b.orgs[name]; ok {
+		return o, nil
+	}
+	repo := &orgconfig.Repo{Root: b.o.RepoRoot, AllowOverride: b.o.AllowOverride}
+	o, err := repo.LoadOrg(name)
+	if err != nil {
+		return nil, err
+	}
+	b.orgs[name] = o
+	return o, nil
+}
+
+func (b *batch) record(e orgconfig.Edit) {
+	b.changes.Add(e)
+}
+
+func (b *batch) skip(format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	fmt.Printf("skipping: %s\n", msg)
+	b.skipped = append(b.skipped, msg)
+}
+
+func (b *batch) fail(format string, args ...interface{}) {
+	b.errs = append(b.errs, fmt.Sprintf(format, args...))
+}
+
+// entryTargets resolves the orgs and teams of an entry, recording an error
+// and returning false if they are invalid or, when writable is set, if any
+// org is read-only.
+func (b *batch) entryTargets(e ManifestEntry, writable bool) ([]string, []teamRef, bool) {
+	if e.Username == "" {
+		b.fail("entry with orgs %v has no username", e.Orgs)
+		return nil, nil, false
+	}
+
+	orgs := e.Orgs
+	if len(orgs) == 0 {
+		orgs = b.o.Orgs
+	}
+	if len(orgs) == 0 {
+		b.fail("%s: no orgs specified", e.Username)
+		return nil, nil, false
+	}
+	if err := b.registry.Validate(orgs, writable); err != nil {
+		b.fail("%s: %s", e.Username, err)
+		return nil, nil, false
+	}
+
+	teams, err := parseTeamRefs(e.Teams)
+	if err != nil {
+		b.fail("%s: %s", e.Username, err)
+		return nil, nil, false
+	}
+	if len(teams) > 0 && len(orgs) != 1 {
+		b.fail("%s: teams can only be specified for a single org", e.Username)
+		return nil, nil, false
+	}
+	return orgs, teams, true
+}
+
+func (b *batch) planAdd(e ManifestEntry) {
+	orgs, teams, ok := b.entryTargets(e, true)
+	if !ok {
+		return
+	}
+
+	for _, orgName := range orgs {
+		orgCfg, err := b.org(orgName)
+		if err != nil {
+			b.fail("%s: reading config: %s", e.Username, err)
+			return
+		}
+
+		if orgCfg.IsMember(e.Username) {
+			b.skip("user %s already exists in org %s", e.Username, orgName)
+		} else {
+			if !b.checkSponsors(e, orgName) {
+				return
+			}
+			fmt.Printf("adding %s to %s org\n", e.Username, orgName)
+			edit, _ := orgCfg.AddMember(e.Username)
+			b.record(edit)
+		}
+
+		for _, t := range teams {
+			b.planAddToTeam(e.Username, orgCfg, t)
+		}
+	}
+}
+
+// checkSponsors checks the sponsors of a user new to the org, recording an
+// error and returning false if they don't qualify and the check isn't
+// overridden.
+func (b *batch) checkSponsors(e ManifestEntry, orgName string) bool {
+	sponsors := e.Sponsors
+	if len(sponsors) == 0 {
+		sponsors = b.o.Sponsors
+	}
+
+	relativeConfigPath := fmt.Sprintf(orgConfigPathFormat, orgName)
+	existing, ok := b.existing[relativeConfigPath]
+	if !ok {
+		var err error
+		if existing, err = orgconfig.ReadFile(filepath.Join(b.o.RepoRoot, relativeConfigPath), nil); err != nil {
+			b.fail("%s: reading config: %s", e.Username, err)
+			return false
+		}
+		b.existing[relativeConfigPath] = existing
+	}
+
+	if problems := sponsorProblems(e.Username, orgName, existing, sponsors, b.affiliations); len(problems) > 0 {
+		if b.o.SponsorOverride == "" {
+			b.fail("%s: %s", e.Username, strings.Join(problems, "; "))
+			return false
+		}
+		fmt.Printf("overriding the sponsor check of %s for %s org: %s\n", e.Username, orgName, strings.Join(problems, "; "))
+		b.sponsorOverridden = true
+	}
+	for _, sponsor := range sponsors {
+		b.sponsors = append(b.sponsors, fmt.Sprintf("%s for %s", sponsor, e.Username))
+	}
+	return true
+}
+
+func (b *batch) planAddToTeam(username string, orgCfg *orgconfig.Org, t teamRef) {
+	key, err := teamListKey(username, &orgCfg.Config, b.o.Maintainer)
+	if err != nil {
+		b.fail("%s", err)
+		return
+	}
+
+	team, err := resolveTeam(orgCfg, t)
+	if err != nil {
+		b.fail("%s: %s", username, err)
+		return
+	}
+
+	e, added, err := orgCfg.AddTeamMember(team.Name, username, orgconfig.Role(key))
+	if err != nil {
+		b.fail("%s: %s", username, err)
+		return
+	}
+	if !added {
+		b.skip("user %s already exists in team %s", username, t)
+		return
+	}
+	fmt.Printf("adding %s to %s team in %s org\n", username, t, orgCfg.Name)
+	b.record(e)
+}
+
+func (b *batch) planRemove(e ManifestEntry) {
+	orgs, teams, ok := b.entryTargets(e, false)
+	if !ok {
+		return
+	}
+
+	for _, orgName := range orgs {
+		orgCfg, err := b.org(orgName)
+		if err != nil {
+			b.fail("%s: reading config: %s", e.Username, err)
+			return
+		}
+
+		if len(teams) > 0 {
+			for _, t := range teams {
+				b.planRemoveFromTeam(e.Username, orgCfg, t)
+			}
+			continue
+		}
+
+		if orgCfg.IsAdmin(e.Username) {
+			b.fail("user %s is an admin for org %s", e.Username, orgName)
+			continue
+		}
+
+		edit, removed := orgCfg.RemoveMember(e.Username)
+		if !removed {
+			b.skip("user %s doesn't exist in org %s", e.Username, orgName)
+			continue
+		}
+		fmt.Printf("removing %s from %s org\n", e.Username, orgName)
+		b.record(edit)
+		if err := removeFromAllTeams(&b.changes, orgCfg, e.Username); err != nil {
+			b.fail("%s: %s", e.Username, err)
+			return
+		}
+	}
+}
+
+func (b *batch) planRemoveFromTeam(username string, orgCfg *orgconfig.Org, t teamRef) {
+	team, err := resolveTeam(orgCfg, t)
+	if err != nil {
+		b.fail("%s: %s", username, err)
+		return
+	}
+
+	e, removed, err := orgCfg.RemoveTeamMember(team.Name, username)
+	if err != nil {
+		b.fail("%s: %s", username, err)
+		return
+	}
+	if !removed {
+		b.skip("user %s doesn't exist in team %s", username, t)
+		return
+	}
+	fmt.Printf("removing %s from %s team in %s org\n", username, t, orgCfg.Name)
+	b.record(e)
+}
+
+// planRemoveFromOrgsAndTeams removes username from each of orgs as well as
+// from every team of those orgs they are a member or maintainer of. Users
+// that are admins of any of the orgs are skipped.
+func (b *batch) planRemoveFromOrgsAndTeams(username string, orgs []string) {
+	for _, orgName := range orgs {
+		orgCfg, err := b.org(orgName)
+		if err != nil {
+			b.fail("%s: reading config: %s", username, err)
+			return
+		}
+		if orgCfg.IsAdmin(username) {
+			b.skip("user %s is an admin for org %s", username, orgName)
+			return
+		}
+	}
+
+	for _, orgName := range orgs {
+		orgCfg, err := b.org(orgName)
+		if err != nil {
+			b.fail("%s: reading config: %s", username, err)
+			return
+		}
+		if edit, removed := orgCfg.RemoveMember(username); removed {
+			fmt.Printf("removing %s from %s org\n", username, orgName)
+			b.record(edit)
+		}
+		if err := removeFromAllTeams(&b.changes, orgCfg, username); err != nil {
+			b.fail("%s: %s", username, err)
+			return
+		}
+	}
+}
+
+// apply validates the planned batch, writes the changes and commits them as a
+// single commit if running with --confirm.
+func (b *batch) apply(message string) error {
+	if len(b.errs) > 0 {
+		return fmt.Errorf("manifest has %d error(s), no changes were made:\n  %s", len(b.errs), strings.Join(b.errs, "\n  "))
+	}
+
+	fmt.Printf("%d change(s), %d skipped\n", b.changes.Len(), len(b.skipped))
+	files := b.changes.Files()
+	if len(files) == 0 {
+		fmt.Println("nothing to do")
+		return nil
+	}
+	fmt.Printf("config files modified: %s\n", strings.Join(files, ", "))
+
+	if !b.o.Confirm {
+		return nil
+	}
+
+	if err := writeChanges(b.o, &b.changes); err != nil {
+		return err
+	}
+
+	fmt.Println("committing changes")
+	if err := commitChanges(b.o.RepoRoot, files, message); err != nil {
+		return fmt.Errorf("committing changes: %s", err)
+	}
+	return nil
+}
+
+// users returns the sorted, de-duplicated list of users with pending changes.
+func (b *batch) users() []string {
+	seen := map[string]bool{}
+	var users []string
+	for _, file := range b.changes.Files() {
+		for _, e := range b.changes.Edits(file) {
+			if !seen[strings.ToLower(e.Value)] {
+				seen[strings.ToLower(e.Value)] = true
+				users = append(users, e.Value)
+			}
+		}
+	}
+	sort.Slice(users, func(i, j int) bool {
+		return strings.ToLower(users[i]) < strings.ToLower(users[j])
+	})
+	return users
+}
+
+func batchCommitMessage(verb string, b *batch) string {
+	users := b.users()
+	return fmt.Sprintf("%s %d members\n\n%s\n", verb, len(users), strings.Join(users, "\n"))
+}
+
+// AddMembersFromManifest adds every user in the manifest at o.FromFile to
+// their orgs and teams in a single commit.
+func AddMembersFromManifest(o Options) error {
+	entries, err := ReadManifest(o.FromFile)
+	if err != nil {
+		return fmt.Errorf("reading manifest: %s", err)
+	}
+
+	if !o.Confirm {
+		fmt.Println("!!! running in dry-run mode. pass --confirm to persist changes.")
+	}
+
+	b, err := newBatch(o)
+	if err != nil {
+		return err
+	}
+	if b.affiliations, err = loadAffiliations(o.RepoRoot); err != nil {
+		return err
+	}
+	for _, e := range entries {
+		b.planAdd(e)
+	}
+
+	message := batchCommitMessage("add", b)
+	if trailers := sponsorTrailers(b.sponsors, b.sponsorOverridden, o.SponsorOverride); trailers != "" {
+		message = strings.TrimSuffix(message, "\n") + trailers + "\n"
+	}
+	return b.apply(message)
+}
+
+// RemoveMembersFromManifest removes every user in the manifest at o.FromFile
+// from their orgs, or only from the listed teams, in a single commit.
+func RemoveMembersFromManifest(o Options) error {
+	entries, err := ReadManifest(o.FromFile)
+	if err != nil {
+		return fmt.Errorf("reading manifest: %s", err)
+	}
+
+	if !o.Confirm {
+		fmt.Println("!!! running in dry-run mode. pass --confirm to persist changes.")
+	}
+
+	b, err := newBatch(o)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		b.planRemove(e)
+	}
+	return b.apply(batchCommitMessage("remove", b))
+}
@@ -0,0 +1,227 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+
+	"k8s.io/org/pkg/orgconfig"
+)
+
+func writeManifest(t *testing.T, name, contents string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestReadManifest(t *testing.T) {
+	expected := []ManifestEntry{
+		{Username: "alice", Orgs: []string{"kubernetes", "kubernetes-sigs"}},
+		{Username: "bob", Orgs: []string{"kubernetes"}, Teams: []string{"sig-network/sig-network-leads"}},
+		{Username: "carol", Sponsors: []string{"alice", "bob"}},
+	}
+
+	cases := []struct {
+		name     string
+		contents string
+	}{
+		{
+			name: "members.csv",
+			contents: `username,orgs,teams,sponsors
+alice,"kubernetes,kubernetes-sigs",,
+bob,kubernetes,sig-network/sig-network-leads,
+carol,,,alice;bob
+`,
+		},
+		{
+			name: "members.yaml",
+			contents: `members:
+- username: alice
+  orgs: [kubernetes, kubernetes-sigs]
+- username: bob
+  orgs: [kubernetes]
+  teams: [sig-network/sig-network-leads]
+- username: carol
+  sponsors: [alice, bob]
+`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			entries, err := ReadManifest(writeManifest(t, c.name, c.contents))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(entries, expected) {
+				t.Errorf("expected %#v, got %#v", expected, entries)
+			}
+		})
+	}
+}
+
+func TestAddMembersFromManifest(t *testing.T) {
+	root := setupRepoRoot(t)
+	manifest := writeManifest(t, "members.csv", `username,teams,sponsors
+alice,,cblecker;aojea
+aojea,sig-network/sig-network-leads,
+Bob,sig-network/sig-network-leads,
+zoe,sig-network/sig-network-solo,
+`)
+	// zoe is sponsored by the sponsors passed with --sponsor
+	o := Options{Confirm: true, RepoRoot: root, Orgs: []string{"kubernetes"}, FromFile: manifest, Sponsors: []string{"cblecker", "Bob"}}
+
+	if err := AddMembersFromManifest(o); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	orgYAML, err := orgconfig.ParseDocument(readTestdata(t, "yamledit/org.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	teamsYAML, err := orgconfig.ParseDocument(readTestdata(t, "yamledit/teams.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, u := range []string{"alice", "zoe"} {
+		if _, err := orgYAML.AddToList(u, "members"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := teamsYAML.AddToList("Bob", "teams", "sig-network-leads", "members"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := teamsYAML.AddToList("zoe", "teams", "sig-network-solo", "members"); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := mustReadFile(t, filepath.Join(root, "config/kubernetes/org.yaml")); string(got) != string(orgYAML.Bytes()) {
+		t.Errorf("unexpected org.yaml contents:\n%s", got)
+	}
+	if got := mustReadFile(t, filepath.Join(root, "config/kubernetes/sig-network/teams.yaml")); string(got) != string(teamsYAML.Bytes()) {
+		t.Errorf("unexpected teams.yaml contents:\n%s", got)
+	}
+
+	r, err := git.PlainOpen(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	log, err := r.Log(&git.LogOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var messages []string
+	for {
+		c, err := log.Next()
+		if err != nil {
+			break
+		}
+		messages = append(messages, c.Message)
+	}
+	expected := []string{"add 3 members\n\nalice\nBob\nzoe\n\n" +
+		"Sponsored-by: cblecker for alice\nSponsored-by: aojea for alice\nSponsored-by: cblecker for zoe\nSponsored-by: Bob for zoe\n"}
+	if !reflect.DeepEqual(messages, expected) {
+		t.Errorf("expected commits %q, got %q", expected, messages)
+	}
+}
+
+func TestAddMembersFromManifestValidatesUpFront(t *testing.T) {
+	root := setupRepoRoot(t)
+	manifest := writeManifest(t, "members.yaml", `members:
+- username: alice
+- username: bob
+  teams: [sig-network/no-such-team]
+- username: carol
+  orgs: [not-an-org]
+`)
+	o := Options{Confirm: true, RepoRoot: root, Orgs: []string{"kubernetes"}, FromFile: manifest}
+
+	if err := AddMembersFromManifest(o); err == nil {
+		t.Fatalf("expected error")
+	}
+	compareGolden(t, "yamledit/org.yaml", mustReadFile(t, filepath.Join(root, "config/kubernetes/org.yaml")))
+}
+
+func TestAddMembersFromManifestChecksSponsors(t *testing.T) {
+	root := setupRepoRoot(t)
+	// alice has no sponsors and carol is sponsored by alice, who isn't a
+	// member before the batch
+	manifest := writeManifest(t, "members.yaml", `members:
+- username: alice
+- username: carol
+  sponsors: [cblecker, alice]
+- username: Bob
+  teams: [sig-network/sig-network-solo]
+`)
+	o := Options{Confirm: true, RepoRoot: root, Orgs: []string{"kubernetes"}, FromFile: manifest}
+
+	err := AddMembersFromManifest(o)
+	if err == nil {
+		t.Fatalf("expected an error adding new members without sponsors")
+	}
+	for _, expected := range []string{
+		"alice: alice needs 2 sponsors who are members of kubernetes org, got 0",
+		"carol: sponsor alice is not a member of kubernetes org",
+	} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("expected error to contain %q, got %v", expected, err)
+		}
+	}
+	if strings.Contains(err.Error(), "Bob") {
+		t.Errorf("expected no sponsor check for existing member Bob, got %v", err)
+	}
+	compareGolden(t, "yamledit/org.yaml", mustReadFile(t, filepath.Join(root, "config/kubernetes/org.yaml")))
+	if got := commitMessages(t, root); len(got) > 0 {
+		t.Errorf("expected no commits, got %q", got)
+	}
+
+	o.SponsorOverride = "bootstrapping the sig"
+	if err := AddMembersFromManifest(o); err != nil {
+		t.Fatalf("unexpected error with an override: %v", err)
+	}
+	expected := []string{"add 3 members\n\nalice\nBob\ncarol\n\n" +
+		"Sponsored-by: cblecker for carol\nSponsored-by: alice for carol\nSponsor-check-override: bootstrapping the sig\n"}
+	if got := commitMessages(t, root); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected commits %q, got %q", expected, got)
+	}
+}
+
+func TestRemoveMembersFromManifest(t *testing.T) {
+	root := setupRepoRoot(t)
+	manifest := writeManifest(t, "members.csv", `username,teams
+bob,
+alice,
+thockin,sig-network/sig-network-leads
+`)
+	o := Options{Confirm: true, RepoRoot: root, Orgs: []string{"kubernetes"}, FromFile: manifest}
+
+	if err := RemoveMembersFromManifest(o); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	compareGolden(t, "yamledit/org-remove.golden", mustReadFile(t, filepath.Join(root, "config/kubernetes/org.yaml")))
+	compareGolden(t, "yamledit/teams-remove-head-comment.golden", mustReadFile(t, filepath.Join(root, "config/kubernetes/sig-network/teams.yaml")))
+}
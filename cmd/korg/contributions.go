@@ -0,0 +1,294 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"sigs.k8s.io/prow/pkg/github"
+)
+
+var defaultDevStatsURLs = []string{
+	"https://k8s.devstats.cncf.io/api/ds/query",
+	"https://etcd.devstats.cncf.io/api/ds/query",
+}
+
+// ContributionSource provides the number of contributions made by each user
+// over a devstats period, keyed by github.NormLogin.
+type ContributionSource interface {
+	Contributions(period string) (map[string]Contribution, error)
+}
+
+// newContributionSource returns the snapshot file source if one is set and
+// queries devstats otherwise.
+func newContributionSource(o AuditOptions) ContributionSource {
+	if o.ContributionsFile != "" {
+		return snapshotSource{path: o.ContributionsFile}
+	}
+	return devStatsSource{urls: o.DevStatsURLs}
+}
+
+// devStatsSource queries the Grafana API of one or more devstats instances
+// and sums up the contributions of users across them.
+type devStatsSource struct {
+	urls []string
+}
+
+func (s devStatsSource) Contributions(period string) (map[string]Contribution, error) {
+	combined := make(map[string]Contribution)
+
+	for _, url := range s.urls {
+		contribs, err := fetchContributionsFromDevStats(period, url)
+		if err != nil {
+			return nil, err
+		}
+
+		sourceName := devStatsSourceName(url)
+		for username, contrib := range contribs {
+			if existing, found := combined[username]; found {
+				existing.ContribCount += contrib.ContribCount
+				combined[username] = existing
+				fmt.Fprintf(os.Stderr, "Merged user %s from %s: new total = %d\n", username, sourceName, existing.ContribCount)
+			} else {
+				combined[username] = contrib
+				fmt.Fprintf(os.Stderr, "Added user %s from %s with %d contributions\n", username, sourceName, contrib.ContribCount)
+			}
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "Total combined unique contributors: %d\n", len(combined))
+	return combined, nil
+}
+
+func devStatsSourceName(rawURL string) string {
+	switch {
+	case strings.Contains(rawURL, "k8s.devstats"):
+		return "Kubernetes"
+	case strings.Contains(rawURL, "etcd.devstats"):
+		return "etcd"
+	}
+	if u, err := url.Parse(rawURL); err == nil && u.Host != "" {
+		return u.Host
+	}
+	return "unknown"
+}
+
+func fetchContributionsFromDevStats(period string, url string) (map[string]Contribution, error) {
+	fmt.Fprintf(os.Stderr, "Fetching contributions from %s\n", url)
+
+	postBody := DevStatsRequest{
+		Queries: []Query{
+			{
+				RefID:        "A",
+				DatasourceID: 1,
+				RawSQL: fmt.Sprintf(`select
+  sub."Rank",
+  sub.name as name,
+  sub.value
+from (
+  select row_number() over (order by sum(value) desc) as "Rank",
+    split_part(name, '$$$', 1) as name,
+    sum(value) as value
+  from
+    shdev
+  where
+    series = 'hdev_contributionsallall'
+    and period = '%s'
+  group by
+    split_part(name, '$$$', 1)
+) sub`, period),
+				Format: "table",
+			},
+		},
+	}
+
+	requestBody, err := json.Marshal(postBody)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := http.Post(url, "application/json", bytes.NewBuffer(requestBody))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("bad error code from devstats %s: %d", url, resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	contribs, err := parseDevStatsResponse(body)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", url, err)
+	}
+	return contribs, nil
+}
+
+// parseDevStatsResponse extracts the rank, name and value columns of the
+// first frame of query A from a Grafana query response.
+func parseDevStatsResponse(body []byte) (map[string]Contribution, error) {
+	var parsed map[string]map[string]map[string][]Frames
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return nil, fmt.Errorf("unable to parse json from devstats: %w", err)
+	}
+
+	frames := parsed["results"]["A"]["frames"]
+	if len(frames) == 0 {
+		return nil, fmt.Errorf("no frames in devstats response")
+	}
+
+	contribs := make(map[string]Contribution)
+	items := frames[0].Data.Items
+	if len(items) == 0 {
+		// no contributions in the period
+		return contribs, nil
+	}
+	if len(items) < 3 {
+		return nil, fmt.Errorf("expected 3 columns in devstats response, got %d", len(items))
+	}
+
+	ranks, usernames, contribCounts := items[0], items[1], items[2]
+	if len(usernames) != len(ranks) || len(contribCounts) != len(ranks) {
+		return nil, fmt.Errorf("mismatched column lengths in devstats response: %d, %d, %d", len(ranks), len(usernames), len(contribCounts))
+	}
+
+	for i := 0; i < len(ranks); i++ {
+		rank, ok := ranks[i].(float64)
+		if !ok {
+			return nil, fmt.Errorf("row %d: invalid rank %v", i, ranks[i])
+		}
+		username, ok := usernames[i].(string)
+		if !ok {
+			return nil, fmt.Errorf("row %d: invalid name %v", i, usernames[i])
+		}
+		count, ok := contribCounts[i].(float64)
+		if !ok {
+			return nil, fmt.Errorf("row %d: invalid value %v for %s", i, contribCounts[i], username)
+		}
+		contribs[github.NormLogin(username)] = Contribution{
+			Rank:         int(rank),
+			Username:     username,
+			ContribCount: int(count),
+			Orgs:         []string{},
+		}
+	}
+	return contribs, nil
+}
+
+// snapshotSource reads contributions from a local JSON or CSV file so audits
+// can be run and reproduced without network access. The period is ignored,
+// the snapshot is expected to cover the period being audited.
+//
+// JSON snapshots are a list of {"username": "...", "contributions": N}
+// objects. CSV snapshots have a header with username and contributions
+// columns.
+type snapshotSource struct {
+	path string
+}
+
+type snapshotEntry struct {
+	Username      string `json:"username"`
+	Contributions int    `json:"contributions"`
+}
+
+func (s snapshotSource) Contributions(period string) (map[string]Contribution, error) {
+	fmt.Fprintf(os.Stderr, "Reading contributions from %s\n", s.path)
+
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []snapshotEntry
+	if strings.EqualFold(filepath.Ext(s.path), ".csv") {
+		entries, err = parseCSVSnapshot(data)
+	} else {
+		d := json.NewDecoder(bytes.NewReader(data))
+		d.DisallowUnknownFields()
+		err = d.Decode(&entries)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse contributions from %s: %s", s.path, err)
+	}
+
+	contribs := make(map[string]Contribution)
+	for _, e := range entries {
+		if e.Username == "" {
+			return nil, fmt.Errorf("%s: entry with %d contributions has no username", s.path, e.Contributions)
+		}
+		key := github.NormLogin(e.Username)
+		existing := contribs[key]
+		contribs[key] = Contribution{
+			Username:     e.Username,
+			ContribCount: existing.ContribCount + e.Contributions,
+			Orgs:         []string{},
+		}
+	}
+	return contribs, nil
+}
+
+func parseCSVSnapshot(data []byte) ([]snapshotEntry, error) {
+	r := csv.NewReader(bytes.NewBuffer(data))
+	r.TrimLeadingSpace = true
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("snapshot is empty")
+	}
+
+	columns := map[string]int{}
+	for i, name := range records[0] {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	usernameCol, ok := columns["username"]
+	if !ok {
+		return nil, fmt.Errorf("missing username column")
+	}
+	contribCol, ok := columns["contributions"]
+	if !ok {
+		return nil, fmt.Errorf("missing contributions column")
+	}
+
+	entries := make([]snapshotEntry, 0, len(records)-1)
+	for i, record := range records[1:] {
+		count, err := strconv.Atoi(strings.TrimSpace(record[contribCol]))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid contributions: %s", i+2, err)
+		}
+		entries = append(entries, snapshotEntry{
+			Username:      strings.TrimSpace(record[usernameCol]),
+			Contributions: count,
+		})
+	}
+	return entries, nil
+}
@@ -0,0 +1,207 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func devStatsResponse(columns string) string {
+	return fmt.Sprintf(`{"results": {"A": {"frames": [{"schema": {}, "data": {"values": %s}}]}}}`, columns)
+}
+
+// fakeDevStats serves response for queries of the given period and fails
+// every other request.
+func fakeDevStats(t *testing.T, period, response string) *httptest.Server {
+	t.Helper()
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req DevStatsRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if len(req.Queries) != 1 || !strings.Contains(req.Queries[0].RawSQL, fmt.Sprintf("period = '%s'", period)) {
+			http.Error(w, "unexpected query", http.StatusBadRequest)
+			return
+		}
+		fmt.Fprint(w, response)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func TestParseDevStatsResponse(t *testing.T) {
+	cases := []struct {
+		name     string
+		body     string
+		expected map[string]Contribution
+		err      bool
+	}{
+		{
+			name: "valid",
+			body: devStatsResponse(`[[1, 2], ["alice", "bob"], [10, 3]]`),
+			expected: map[string]Contribution{
+				"alice": {Rank: 1, Username: "alice", ContribCount: 10, Orgs: []string{}},
+				"bob":   {Rank: 2, Username: "bob", ContribCount: 3, Orgs: []string{}},
+			},
+		},
+		{
+			name:     "no contributions",
+			body:     devStatsResponse(`[]`),
+			expected: map[string]Contribution{},
+		},
+		{
+			name: "invalid json",
+			body: `{"results":`,
+			err:  true,
+		},
+		{
+			name: "no frames",
+			body: `{"results": {"A": {"frames": []}}}`,
+			err:  true,
+		},
+		{
+			name: "missing query",
+			body: `{"results": {}}`,
+			err:  true,
+		},
+		{
+			name: "missing column",
+			body: devStatsResponse(`[[1], ["alice"]]`),
+			err:  true,
+		},
+		{
+			name: "mismatched columns",
+			body: devStatsResponse(`[[1, 2], ["alice"], [10, 3]]`),
+			err:  true,
+		},
+		{
+			name: "non-string name",
+			body: devStatsResponse(`[[1], [42], [10]]`),
+			err:  true,
+		},
+		{
+			name: "non-numeric value",
+			body: devStatsResponse(`[[1], ["alice"], ["10"]]`),
+			err:  true,
+		},
+		{
+			name: "null rank",
+			body: devStatsResponse(`[[null], ["alice"], [10]]`),
+			err:  true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			contribs, err := parseDevStatsResponse([]byte(c.body))
+			switch {
+			case c.err && err == nil:
+				t.Fatalf("expected error, got %v", contribs)
+			case !c.err && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !c.err && !reflect.DeepEqual(contribs, c.expected) {
+				t.Errorf("expected %v, got %v", c.expected, contribs)
+			}
+		})
+	}
+}
+
+func TestDevStatsSource(t *testing.T) {
+	k8s := fakeDevStats(t, "q", devStatsResponse(`[[1, 2], ["alice", "bob"], [10, 3]]`))
+	etcd := fakeDevStats(t, "q", devStatsResponse(`[[1], ["bob"], [4]]`))
+
+	contribs, err := devStatsSource{urls: []string{k8s.URL, etcd.URL}}.Contributions("q")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := map[string]Contribution{
+		"alice": {Rank: 1, Username: "alice", ContribCount: 10, Orgs: []string{}},
+		"bob":   {Rank: 2, Username: "bob", ContribCount: 7, Orgs: []string{}},
+	}
+	if !reflect.DeepEqual(contribs, expected) {
+		t.Errorf("expected %v, got %v", expected, contribs)
+	}
+
+	if _, err := (devStatsSource{urls: []string{k8s.URL}}).Contributions("y"); err == nil {
+		t.Errorf("expected error for a failed request")
+	}
+}
+
+func TestSnapshotSource(t *testing.T) {
+	expected := map[string]Contribution{
+		"alice": {Username: "alice", ContribCount: 10, Orgs: []string{}},
+		"bob":   {Username: "bob", ContribCount: 0, Orgs: []string{}},
+	}
+
+	cases := []struct {
+		name     string
+		contents string
+		err      bool
+	}{
+		{
+			name:     "contributions.json",
+			contents: `[{"username": "alice", "contributions": 10}, {"username": "bob", "contributions": 0}]`,
+		},
+		{
+			name:     "contributions.csv",
+			contents: "username,contributions\nalice,10\nbob,0\n",
+		},
+		{
+			name:     "unknown-field.json",
+			contents: `[{"username": "alice", "count": 10}]`,
+			err:      true,
+		},
+		{
+			name:     "missing-username.json",
+			contents: `[{"contributions": 10}]`,
+			err:      true,
+		},
+		{
+			name:     "missing-column.csv",
+			contents: "username\nalice\n",
+			err:      true,
+		},
+		{
+			name:     "invalid-count.csv",
+			contents: "username,contributions\nalice,many\n",
+			err:      true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			contribs, err := snapshotSource{path: writeManifest(t, c.name, c.contents)}.Contributions("y")
+			switch {
+			case c.err && err == nil:
+				t.Fatalf("expected error, got %v", contribs)
+			case !c.err && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !c.err && !reflect.DeepEqual(contribs, expected) {
+				t.Errorf("expected %v, got %v", expected, contribs)
+			}
+		})
+	}
+}
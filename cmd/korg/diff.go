@@ -0,0 +1,464 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"sigs.k8s.io/prow/pkg/config/org"
+	"sigs.k8s.io/prow/pkg/github"
+
+	"k8s.io/org/pkg/orgconfig"
+)
+
+const (
+	diffFormatText     = "text"
+	diffFormatMarkdown = "markdown"
+)
+
+var diffFormats = []string{diffFormatText, diffFormatMarkdown}
+
+// OrgDiff summarizes the changes to the merged config of an org between two
+// revisions.
+type OrgDiff struct {
+	Org string
+	// Added and Removed are set if the org only exists in one revision.
+	Added   bool
+	Removed bool
+
+	MembersAdded   []string
+	MembersRemoved []string
+	AdminsAdded    []string
+	AdminsRemoved  []string
+	// Settings are changes to the org metadata and repo settings.
+	Settings []SettingChange
+	Teams    []TeamDiff
+}
+
+// TeamDiff summarizes the changes to a team between two revisions.
+type TeamDiff struct {
+	// Team is the full name of the team, with parent teams separated by "/".
+	Team        string
+	Added       bool
+	Removed     bool
+	RenamedFrom string
+
+	MembersAdded       []string
+	MembersRemoved     []string
+	MaintainersAdded   []string
+	MaintainersRemoved []string
+	Repos              []SettingChange
+	Settings           []SettingChange
+}
+
+// SettingChange is a changed setting, Old or New is empty if it is unset in
+// that revision.
+type SettingChange struct {
+	Name string
+	Old  string
+	New  string
+}
+
+func (d OrgDiff) empty() bool {
+	return !d.Added && !d.Removed && len(d.MembersAdded) == 0 && len(d.MembersRemoved) == 0 &&
+		len(d.AdminsAdded) == 0 && len(d.AdminsRemoved) == 0 && len(d.Settings) == 0 && len(d.Teams) == 0
+}
+
+// DiffOrgs loads the orgs at base and head, a git revision or the working
+// tree if empty, and writes a summary of the changes between them to out.
+func DiffOrgs(o Options, base, head string, out io.Writer) error {
+	baseRepo, err := orgconfig.RepoAt(o.RepoRoot, base)
+	if err != nil {
+		return err
+	}
+	baseRepo.AllowOverride = o.AllowOverride
+
+	headRepo := &orgconfig.Repo{Root: o.RepoRoot, AllowOverride: o.AllowOverride}
+	if head != "" {
+		if headRepo, err = orgconfig.RepoAt(o.RepoRoot, head); err != nil {
+			return err
+		}
+		headRepo.AllowOverride = o.AllowOverride
+	}
+
+	orgs := o.Orgs
+	if len(orgs) == 0 {
+		if orgs, err = diffOrgNames(baseRepo, headRepo); err != nil {
+			return err
+		}
+	}
+
+	var diffs []OrgDiff
+	for _, name := range orgs {
+		baseOrg, err := loadOrgAt(baseRepo, name, base)
+		if err != nil {
+			return err
+		}
+		headOrg, err := loadOrgAt(headRepo, name, head)
+		if err != nil {
+			return err
+		}
+		if baseOrg == nil && headOrg == nil {
+			return fmt.Errorf("org %s does not exist in either revision", name)
+		}
+		if d := diffOrg(name, baseOrg, headOrg); !d.empty() {
+			diffs = append(diffs, d)
+		}
+	}
+
+	if o.DiffFormat == diffFormatMarkdown {
+		writeDiffMarkdown(out, diffs)
+	} else {
+		writeDiffText(out, diffs)
+	}
+	return nil
+}
+
+func diffOrgNames(repos ...*orgconfig.Repo) ([]string, error) {
+	seen := map[string]bool{}
+	var names []string
+	for _, r := range repos {
+		orgs, err := r.OrgNames()
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range orgs {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// loadOrgAt loads the org from repo, returning nil if it does not exist.
+func loadOrgAt(repo *orgconfig.Repo, name, rev string) (*orgconfig.Org, error) {
+	if rev == "" {
+		rev = "the working tree"
+	}
+	names, err := repo.OrgNames()
+	if err != nil {
+		return nil, fmt.Errorf("listing orgs at %s: %s", rev, err)
+	}
+	for _, n := range names {
+		if n == name {
+			o, err := repo.LoadOrg(name)
+			if err != nil {
+				return nil, fmt.Errorf("loading %s at %s: %w", name, rev, err)
+			}
+			return o, nil
+		}
+	}
+	return nil, nil
+}
+
+func diffOrg(name string, base, head *orgconfig.Org) OrgDiff {
+	d := OrgDiff{Org: name, Added: base == nil, Removed: head == nil}
+	var baseCfg, headCfg org.Config
+	var baseTeams, headTeams []orgconfig.Team
+	if base != nil {
+		baseCfg, baseTeams = base.Config, base.Teams()
+	}
+	if head != nil {
+		headCfg, headTeams = head.Config, head.Teams()
+	}
+
+	d.MembersAdded, d.MembersRemoved = diffLogins(baseCfg.Members, headCfg.Members)
+	d.AdminsAdded, d.AdminsRemoved = diffLogins(baseCfg.Admins, headCfg.Admins)
+	d.Settings = diffSettings(
+		flattenSettings(struct {
+			org.Metadata
+			Repos map[string]org.Repo `json:"repos,omitempty"`
+		}{baseCfg.Metadata, baseCfg.Repos}),
+		flattenSettings(struct {
+			org.Metadata
+			Repos map[string]org.Repo `json:"repos,omitempty"`
+		}{headCfg.Metadata, headCfg.Repos}),
+	)
+	d.Teams = diffTeams(baseTeams, headTeams)
+	return d
+}
+
+// diffTeams matches teams by name, regardless of case and of their parent
+// team, or through the previously names of a team in head.
+func diffTeams(base, head []orgconfig.Team) []TeamDiff {
+	baseByName := map[string]orgconfig.Team{}
+	for _, t := range base {
+		baseByName[github.NormLogin(t.Name)] = t
+	}
+	inHead := map[string]bool{}
+	for _, t := range head {
+		inHead[github.NormLogin(t.Name)] = true
+	}
+
+	var diffs []TeamDiff
+	matched := map[string]bool{}
+	for _, t := range head {
+		key := github.NormLogin(t.Name)
+		b, ok := baseByName[key]
+		renamedFrom := ""
+		if !ok {
+			for _, previous := range t.Previously {
+				if p, found := baseByName[github.NormLogin(previous)]; found && !inHead[github.NormLogin(previous)] {
+					b, ok, key, renamedFrom = p, true, github.NormLogin(previous), p.Name
+					break
+				}
+			}
+		}
+		if !ok {
+			diffs = append(diffs, diffTeam(t.FullName(), nil, &t))
+			continue
+		}
+
+		matched[key] = true
+		d := diffTeam(t.FullName(), &b, &t)
+		d.RenamedFrom = renamedFrom
+		if oldParent, newParent := strings.Join(b.Parents, "/"), strings.Join(t.Parents, "/"); oldParent != newParent {
+			d.Settings = append([]SettingChange{{Name: "parent", Old: oldParent, New: newParent}}, d.Settings...)
+		}
+		if !d.empty() {
+			diffs = append(diffs, d)
+		}
+	}
+	for _, t := range base {
+		if !matched[github.NormLogin(t.Name)] {
+			diffs = append(diffs, diffTeam(t.FullName(), &t, nil))
+		}
+	}
+	sort.SliceStable(diffs, func(i, j int) bool {
+		return diffs[i].Team < diffs[j].Team
+	})
+	return diffs
+}
+
+func diffTeam(name string, base, head *orgconfig.Team) TeamDiff {
+	d := TeamDiff{Team: name, Added: base == nil, Removed: head == nil}
+	var b, h org.Team
+	if base != nil {
+		b = base.Team
+	}
+	if head != nil {
+		h = head.Team
+	}
+
+	d.MembersAdded, d.MembersRemoved = diffLogins(b.Members, h.Members)
+	d.MaintainersAdded, d.MaintainersRemoved = diffLogins(b.Maintainers, h.Maintainers)
+	if d.Removed {
+		// the repos and settings go away with the team
+		return d
+	}
+	d.Repos = diffSettings(flattenSettings(b.Repos), flattenSettings(h.Repos))
+	d.Settings = diffSettings(
+		flattenSettings(struct {
+			org.TeamMetadata
+			Previously []string `json:"previously,omitempty"`
+		}{b.TeamMetadata, b.Previously}),
+		flattenSettings(struct {
+			org.TeamMetadata
+			Previously []string `json:"previously,omitempty"`
+		}{h.TeamMetadata, h.Previously}),
+	)
+	return d
+}
+
+func (d TeamDiff) empty() bool {
+	return !d.Added && !d.Removed && d.RenamedFrom == "" && len(d.MembersAdded) == 0 && len(d.MembersRemoved) == 0 &&
+		len(d.MaintainersAdded) == 0 && len(d.MaintainersRemoved) == 0 && len(d.Repos) == 0 && len(d.Settings) == 0
+}
+
+// diffLogins returns the logins only in head and only in base, compared
+// case-insensitively, sorted.
+func diffLogins(base, head []string) ([]string, []string) {
+	only := func(a, b []string) []string {
+		in := map[string]bool{}
+		for _, login := range b {
+			in[github.NormLogin(login)] = true
+		}
+		var out []string
+		for _, login := range a {
+			if !in[github.NormLogin(login)] {
+				out = append(out, login)
+			}
+		}
+		sort.Slice(out, func(i, j int) bool {
+			return strings.ToLower(out[i]) < strings.ToLower(out[j])
+		})
+		return out
+	}
+	return only(head, base), only(base, head)
+}
+
+// flattenSettings maps the dotted path of every value of v, as marshalled to
+// JSON, to the value.
+func flattenSettings(v interface{}) map[string]string {
+	settings := map[string]string{}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return settings
+	}
+	var generic interface{}
+	if err := json.Unmarshal(b, &generic); err != nil {
+		return settings
+	}
+	var flatten func(prefix string, v interface{})
+	flatten = func(prefix string, v interface{}) {
+		switch v := v.(type) {
+		case map[string]interface{}:
+			for k, child := range v {
+				key := k
+				if prefix != "" {
+					key = prefix + "." + k
+				}
+				flatten(key, child)
+			}
+		case string:
+			if v == "" || strings.ContainsAny(v, "\n\t") {
+				v = strconv.Quote(v)
+			}
+			settings[prefix] = v
+		default:
+			b, _ := json.Marshal(v)
+			settings[prefix] = string(b)
+		}
+	}
+	flatten("", generic)
+	return settings
+}
+
+func diffSettings(base, head map[string]string) []SettingChange {
+	var changes []SettingChange
+	for name, old := range base {
+		if head[name] != old {
+			changes = append(changes, SettingChange{Name: name, Old: old, New: head[name]})
+		}
+	}
+	for name, value := range head {
+		if _, ok := base[name]; !ok {
+			changes = append(changes, SettingChange{Name: name, New: value})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Name < changes[j].Name
+	})
+	return changes
+}
+
+func (c SettingChange) String() string {
+	unset := func(v string) string {
+		if v == "" {
+			return "(unset)"
+		}
+		return v
+	}
+	return fmt.Sprintf("%s: %s -> %s", c.Name, unset(c.Old), unset(c.New))
+}
+
+func writeDiffText(w io.Writer, diffs []OrgDiff) {
+	if len(diffs) == 0 {
+		fmt.Fprintln(w, "no changes")
+		return
+	}
+	list := func(indent, label string, logins []string) {
+		if len(logins) > 0 {
+			fmt.Fprintf(w, "%s%s (%d): %s\n", indent, label, len(logins), strings.Join(logins, ", "))
+		}
+	}
+	for _, d := range diffs {
+		fmt.Fprintf(w, "%s%s:\n", d.Org, status(d.Added, d.Removed, ""))
+		list("  ", "members added", d.MembersAdded)
+		list("  ", "members removed", d.MembersRemoved)
+		list("  ", "admins added", d.AdminsAdded)
+		list("  ", "admins removed", d.AdminsRemoved)
+		for _, c := range d.Settings {
+			fmt.Fprintf(w, "  %s\n", c)
+		}
+		for _, t := range d.Teams {
+			fmt.Fprintf(w, "  team %s%s:\n", t.Team, status(t.Added, t.Removed, t.RenamedFrom))
+			list("    ", "members added", t.MembersAdded)
+			list("    ", "members removed", t.MembersRemoved)
+			list("    ", "maintainers added", t.MaintainersAdded)
+			list("    ", "maintainers removed", t.MaintainersRemoved)
+			for _, c := range t.Repos {
+				fmt.Fprintf(w, "    repo %s\n", c)
+			}
+			for _, c := range t.Settings {
+				fmt.Fprintf(w, "    %s\n", c)
+			}
+		}
+	}
+}
+
+func writeDiffMarkdown(w io.Writer, diffs []OrgDiff) {
+	if len(diffs) == 0 {
+		fmt.Fprintln(w, "No membership or settings changes.")
+		return
+	}
+	list := func(label string, logins []string) {
+		if len(logins) == 0 {
+			return
+		}
+		quoted := make([]string, 0, len(logins))
+		for _, login := range logins {
+			quoted = append(quoted, "`"+login+"`")
+		}
+		fmt.Fprintf(w, "- %s (%d): %s\n", label, len(logins), strings.Join(quoted, ", "))
+	}
+	settings := func(prefix string, changes []SettingChange) {
+		for _, c := range changes {
+			fmt.Fprintf(w, "- %s`%s`\n", prefix, c)
+		}
+	}
+	for i, d := range diffs {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "### %s%s\n\n", d.Org, status(d.Added, d.Removed, ""))
+		list("Members added", d.MembersAdded)
+		list("Members removed", d.MembersRemoved)
+		list("Admins added", d.AdminsAdded)
+		list("Admins removed", d.AdminsRemoved)
+		settings("", d.Settings)
+		for _, t := range d.Teams {
+			fmt.Fprintf(w, "\n#### Team `%s`%s\n\n", t.Team, status(t.Added, t.Removed, t.RenamedFrom))
+			list("Members added", t.MembersAdded)
+			list("Members removed", t.MembersRemoved)
+			list("Maintainers added", t.MaintainersAdded)
+			list("Maintainers removed", t.MaintainersRemoved)
+			settings("Repo ", t.Repos)
+			settings("", t.Settings)
+		}
+	}
+}
+
+func status(added, removed bool, renamedFrom string) string {
+	switch {
+	case added:
+		return " (added)"
+	case removed:
+		return " (removed)"
+	case renamedFrom != "":
+		return fmt.Sprintf(" (renamed from %s)", renamedFrom)
+	}
+	return ""
+}
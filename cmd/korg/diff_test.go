@@ -0,0 +1,143 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDiffOrgs(t *testing.T) {
+	root := setupRepoRoot(t)
+	files := []string{"config/kubernetes/org.yaml", "config/kubernetes/sig-network/teams.yaml"}
+	if err := commitChanges(root, files, "base"); err != nil {
+		t.Fatal(err)
+	}
+
+	writeFile(t, root, files[0], `admins:
+- cblecker
+- k8s-ci-robot
+- zed
+billing_email: github@kubernetes.io
+default_repository_permission: write
+description: Production-Grade Container Scheduling and Management
+members:
+- 08volt
+- "249043822"
+- alice
+- aojea
+name: Kubernetes
+`)
+	writeFile(t, root, files[1], `teams:
+  sig-network-leads:
+    description: SIG Network leads
+    maintainers:
+    - cblecker
+    members:
+    - aojea
+    - zed
+    privacy: closed
+    repos:
+      ingress-gce: write
+      kube-proxy: read
+  sig-network-single:
+    description: single member
+    members:
+    - shaneutt
+    previously:
+    - sig-network-solo
+    privacy: closed
+`)
+	if err := commitChanges(root, files, "head"); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, root, "config/kubernetes-sigs/org.yaml", "members:\n- bob\n")
+
+	text := `kubernetes:
+  members added (1): alice
+  members removed (2): Bob, zed
+  admins added (1): zed
+  default_repository_permission: read -> write
+  team sig-network-leads:
+    members added (1): zed
+    members removed (1): thockin
+    repo ingress-gce: admin -> write
+    repo kube-proxy: (unset) -> read
+  team sig-network-reviewers (removed):
+  team sig-network-single (renamed from sig-network-solo):
+    description: "Multi-line\ndescription\n" -> single member
+    previously: (unset) -> ["sig-network-solo"]
+`
+	markdown := "### kubernetes\n\n" +
+		"- Members added (1): `alice`\n" +
+		"- Members removed (2): `Bob`, `zed`\n" +
+		"- Admins added (1): `zed`\n" +
+		"- `default_repository_permission: read -> write`\n"
+	workingTree := "kubernetes-sigs (added):\n  members added (1): bob\n"
+
+	cases := []struct {
+		name     string
+		o        Options
+		head     string
+		expected string
+		prefix   bool
+	}{
+		{
+			name:     "text",
+			o:        Options{RepoRoot: root, Orgs: []string{"kubernetes"}, DiffFormat: diffFormatText},
+			head:     "HEAD",
+			expected: text,
+		},
+		{
+			name:     "markdown",
+			o:        Options{RepoRoot: root, Orgs: []string{"kubernetes"}, DiffFormat: diffFormatMarkdown},
+			head:     "HEAD",
+			expected: markdown,
+			prefix:   true,
+		},
+		{
+			name:     "working tree",
+			o:        Options{RepoRoot: root, DiffFormat: diffFormatText},
+			expected: workingTree,
+		},
+		{
+			name:     "no changes",
+			o:        Options{RepoRoot: root, DiffFormat: diffFormatText},
+			head:     "HEAD~1",
+			expected: "no changes\n",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			base := "HEAD~1"
+			if c.head == "" {
+				base = "HEAD"
+			}
+			var out bytes.Buffer
+			if err := DiffOrgs(c.o, base, c.head, &out); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			got := out.String()
+			if c.prefix && len(got) >= len(c.expected) {
+				got = got[:len(c.expected)]
+			}
+			if got != c.expected {
+				t.Errorf("expected:\n%s\ngot:\n%s", c.expected, out.String())
+			}
+		})
+	}
+}
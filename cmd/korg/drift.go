@@ -0,0 +1,205 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/prow/pkg/config/org"
+	"sigs.k8s.io/prow/pkg/github"
+
+	"k8s.io/org/pkg/ghstate"
+)
+
+// Drift is a difference between the state of an org on GitHub and its config
+// that was most likely made by hand.
+type Drift struct {
+	Kind    string
+	Team    string
+	Subject string
+	Detail  string
+	// Fixed is set if peribolos reverts the drift on its next run, otherwise
+	// it is left alone.
+	Fixed bool
+}
+
+// OrgDrift lists the drift of an org.
+type OrgDrift struct {
+	Org   string
+	Drift []Drift
+}
+
+// DriftOrgs compares the state of the orgs on GitHub, read from a snapshot or
+// fetched from GitHub, with the merged config and writes the drift to out.
+func DriftOrgs(o Options, out io.Writer) error {
+	configPath := o.PlanConfig
+	if configPath == "" {
+		configPath = filepath.Join(o.RepoRoot, defaultMergedConfig)
+	}
+	cfg, err := loadMergedConfig(configPath)
+	if err != nil {
+		return err
+	}
+
+	orgs := o.Orgs
+	if len(orgs) == 0 {
+		for name := range cfg.Orgs {
+			orgs = append(orgs, name)
+		}
+		sort.Strings(orgs)
+	}
+
+	var state *ghstate.State
+	source := "GitHub"
+	if o.FromGitHub {
+		client, err := newGitHubClient(o)
+		if err != nil {
+			return err
+		}
+		if state, err = client.Snapshot(orgs); err != nil {
+			return err
+		}
+	} else {
+		source = stateFile(o)
+		if state, err = ghstate.Load(source); err != nil {
+			return err
+		}
+	}
+
+	var drifts []OrgDrift
+	for _, name := range orgs {
+		orgCfg, ok := cfg.Orgs[name]
+		if !ok {
+			return fmt.Errorf("org %s is not in %s", name, configPath)
+		}
+		orgState, ok := state.Orgs[name]
+		if !ok {
+			return fmt.Errorf("org %s is not in the state snapshot %s", name, source)
+		}
+		if d := driftOrg(name, orgCfg, orgState); len(d.Drift) > 0 {
+			drifts = append(drifts, d)
+		}
+	}
+	writeDrift(out, drifts)
+	return nil
+}
+
+// driftOrg derives the drift from the changes peribolos would make, leaving
+// out the config changes that were not applied yet, like new members and
+// teams, and adds the pending invitations that peribolos ignores.
+func driftOrg(name string, cfg org.Config, state ghstate.Org) OrgDrift {
+	d := OrgDrift{Org: name}
+	plan := planOrg(name, cfg, state, nil)
+	// peribolos refuses to remove any member if too many are removed
+	removalsBlocked := len(plan.Warnings) > 0
+	for _, a := range plan.Actions {
+		switch a.Kind {
+		case planRemove:
+			drift := Drift{Kind: "out-of-band member", Subject: a.Subject, Detail: a.Old, Fixed: !removalsBlocked}
+			if removalsBlocked {
+				drift.Detail += fmt.Sprintf(", over --maximum-removal-delta=%.2f", maximumRemovalDelta)
+			}
+			d.Drift = append(d.Drift, drift)
+		case planOrgRole:
+			d.Drift = append(d.Drift, Drift{Kind: "role mismatch", Subject: a.Subject, Detail: fmt.Sprintf("%s on GitHub, %s in config", a.Old, a.New), Fixed: true})
+		case planDeleteTeam:
+			d.Drift = append(d.Drift, Drift{Kind: "unconfigured team", Team: a.Team, Fixed: true})
+		case planRemoveTeamMember:
+			d.Drift = append(d.Drift, Drift{Kind: "out-of-band team member", Team: a.Team, Subject: a.Subject, Detail: a.Old, Fixed: true})
+		case planTeamRole:
+			d.Drift = append(d.Drift, Drift{Kind: "role mismatch", Team: a.Team, Subject: a.Subject, Detail: fmt.Sprintf("%s on GitHub, %s in config", a.Old, a.New), Fixed: true})
+		case planUpdateRepo, planRevokeRepo:
+			d.Drift = append(d.Drift, Drift{Kind: "permission mismatch", Team: a.Team, Subject: "repo " + a.Subject, Detail: fmt.Sprintf("%s on GitHub, %s in config", unsetPermission(a.Old), unsetPermission(a.New)), Fixed: true})
+		case planUpdateTeam:
+			d.Drift = append(d.Drift, Drift{Kind: "setting mismatch", Team: a.Team, Subject: a.Subject, Detail: fmt.Sprintf("%q on GitHub, %q in config", a.Old, a.New), Fixed: true})
+		}
+	}
+
+	configured := map[string]bool{}
+	for _, login := range append(append([]string{}, cfg.Admins...), cfg.Members...) {
+		configured[github.NormLogin(login)] = true
+	}
+	invitations := append([]string{}, state.Invitations...)
+	sort.Slice(invitations, func(i, j int) bool {
+		return strings.ToLower(invitations[i]) < strings.ToLower(invitations[j])
+	})
+	for _, login := range invitations {
+		detail := "not in config"
+		if configured[github.NormLogin(login)] {
+			detail = "in config, not accepted yet"
+		}
+		d.Drift = append(d.Drift, Drift{Kind: "pending invitation", Subject: login, Detail: detail})
+	}
+	return d
+}
+
+func unsetPermission(p string) string {
+	if p == "" {
+		return "none"
+	}
+	return p
+}
+
+func (d Drift) String() string {
+	var b strings.Builder
+	b.WriteString(d.Kind + ": ")
+	switch {
+	case d.Team != "" && d.Subject != "":
+		fmt.Fprintf(&b, "team %s: %s", d.Team, d.Subject)
+	case d.Team != "":
+		fmt.Fprintf(&b, "team %s", d.Team)
+	default:
+		b.WriteString(d.Subject)
+	}
+	if d.Detail != "" {
+		fmt.Fprintf(&b, " (%s)", d.Detail)
+	}
+	return b.String()
+}
+
+func writeDrift(w io.Writer, drifts []OrgDrift) {
+	if len(drifts) == 0 {
+		fmt.Fprintln(w, "no drift")
+		return
+	}
+	for _, d := range drifts {
+		fmt.Fprintf(w, "%s:\n", d.Org)
+		for _, fixed := range []bool{true, false} {
+			var items []Drift
+			for _, drift := range d.Drift {
+				if drift.Fixed == fixed {
+					items = append(items, drift)
+				}
+			}
+			if len(items) == 0 {
+				continue
+			}
+			label := "ignored by peribolos"
+			if fixed {
+				label = "fixed by peribolos"
+			}
+			fmt.Fprintf(w, "  %s (%d):\n", label, len(items))
+			for _, drift := range items {
+				fmt.Fprintf(w, "    %s\n", drift)
+			}
+		}
+	}
+}
@@ -0,0 +1,83 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDriftOrgs(t *testing.T) {
+	dir := t.TempDir()
+	config := filepath.Join(dir, "gen-config.yaml")
+	if err := os.WriteFile(config, []byte(`orgs:
+  kubernetes:
+    admins: [cblecker]
+    members: [alice, bob, carol, dave, frank]
+    teams:
+      sig-foo:
+        privacy: closed
+        maintainers: [cblecker]
+        members: [alice]
+        repos:
+          foo: write
+      sig-new:
+        members: [dave]
+`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	state := filepath.Join(dir, "state.json")
+	if err := os.WriteFile(state, []byte(`{"orgs": {"kubernetes": {
+  "admins": ["cblecker", "alice"],
+  "members": ["bob", "carol", "erin"],
+  "invitations": ["Dave", "mallory"],
+  "teams": {
+    "sig-foo": {
+      "privacy": "secret",
+      "maintainers": ["cblecker"],
+      "members": ["alice", "erin"],
+      "repos": {"foo": "admin", "bar": "read"}
+    },
+    "handmade": {"members": ["erin"]}
+  }
+}}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `kubernetes:
+  fixed by peribolos (7):
+    role mismatch: alice (admin on GitHub, member in config)
+    out-of-band member: erin (member)
+    unconfigured team: team handmade
+    setting mismatch: team sig-foo: privacy ("secret" on GitHub, "closed" in config)
+    out-of-band team member: team sig-foo: erin (member)
+    permission mismatch: team sig-foo: repo bar (read on GitHub, none in config)
+    permission mismatch: team sig-foo: repo foo (admin on GitHub, write in config)
+  ignored by peribolos (2):
+    pending invitation: Dave (in config, not accepted yet)
+    pending invitation: mallory (not in config)
+`
+	var out bytes.Buffer
+	if err := DriftOrgs(Options{State: state, PlanConfig: config}, &out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out.String() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, out.String())
+	}
+}
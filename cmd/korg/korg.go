@@ -19,21 +19,16 @@ package main
 import (
 	"fmt"
 	"os"
-	"path/filepath"
 	"strings"
 
 	"github.com/spf13/cobra"
+
+	"k8s.io/org/pkg/diagnostics"
+	"k8s.io/org/pkg/ghstate"
+	"k8s.io/org/pkg/orgconfig"
 )
 
 var (
-	validOrgs = []string{
-		"etcd-io",
-		"kubernetes",
-		"kubernetes-client",
-		"kubernetes-csi",
-		"kubernetes-sigs",
-	}
-
 	orgConfigPathFormat = "config/%s/org.yaml"
 
 	addHelpText = `
@@ -41,79 +36,423 @@ Adds users to GitHub orgs and/or teams
 
 Add user to specified orgs:
 
-	korg add <github username> --org kubernetes --org kubernetes-sigs
-	korg add <github username> --org kubernetes,kubernetes-sigs
+	korg add <github username> --org kubernetes --org kubernetes-sigs --sponsor alice --sponsor bob
+	korg add <github username> --org kubernetes,kubernetes-sigs --sponsor alice,bob
+
+New members need two sponsors who are members of every org they are added to,
+and who are not listed under the same affiliation in config/affiliations.yaml:
+
+	affiliations:
+	  example-corp:
+	  - alice
+	  - carol
+
+The sponsors are recorded as Sponsored-by trailers of the commit. Pass
+--sponsor-override with a reason, recorded as a Sponsor-check-override trailer,
+to add a user whose sponsors don't pass the check.
+
+Add user to teams defined in config/<org>/<dir>/teams.yaml, adding them to the
+org first if needed:
 
-Note: Adding to teams is currently unsupported.
+	korg add <github username> --org kubernetes --team sig-release/milestone-maintainers
+
+Child teams are referenced by the directory of the teams.yaml file defining
+them, like top-level teams. Org admins are always added as team maintainers.
+Pass --maintainer to require that the user is added as a maintainer. Nothing is
+written unless every org and team can be updated.
+
+Add many users in a single commit from a CSV or YAML manifest. Orgs default to
+the ones passed with --org and sponsors to the ones passed with --sponsor;
+users that are already members are skipped. The sponsors of every new member
+are checked against the orgs as they were before the batch and recorded as
+"Sponsored-by: <sponsor> for <user>" trailers:
+
+	korg add --from-file members.csv --org kubernetes
+
+	# members.csv
+	username,orgs,teams,sponsors
+	user1,kubernetes;kubernetes-sigs,,alice;bob
+	user2,kubernetes,sig-release/milestone-maintainers,alice;carol
+
+	# members.yaml
+	members:
+	- username: user1
+	  orgs: [kubernetes, kubernetes-sigs]
+	  sponsors: [alice, bob]
 	`
 
 	removeHelpText = `
-Remove users from GitHub orgs
+Remove users from GitHub orgs and/or teams
 
-Remove user to specified orgs:
+Remove user from specified orgs, along with every team of those orgs they are
+a member or maintainer of:
 
 	korg remove <github username> --org kubernetes --org kubernetes-sigs
 
-Note: Removing from teams is currently unsupported.
+Remove user from teams without removing them from the org:
+
+	korg remove <github username> --org kubernetes --team sig-release/milestone-maintainers
+
+Remove many users in a single commit from a CSV or YAML manifest in the same
+format as "korg add --from-file":
+
+	korg remove --from-file inactive-members.csv --org kubernetes
+	`
+
+	auditHelpText = `
+Audit GitHub org members
+
+Audits all orgs configured under config/ unless --org is specified. Orgs marked
+as read-only in config/<org>/metadata.yaml are skipped unless
+--include-read-only is passed.
+
+Remove the inactive members from every org and team they belong to in a single
+commit. Org admins and approvers in OWNERS files under config/ are kept:
+
+	korg audit --apply --confirm --exceptions-file exceptions.csv
+
+Contributions are fetched from the Kubernetes and etcd devstats instances by
+default. Use --devstats-url to query other instances or --contributions-file to
+run the audit offline against a snapshot, either a JSON list of
+{"username": "...", "contributions": N} objects or a CSV file with username and
+contributions columns.
+
+--check-owners searches OWNERS files on cs.k8s.io by default. To check owners
+offline, and to list the OWNERS files and roles of each user in the report,
+point --owners-path at a directory of checked out repos or at a tar archive of
+their OWNERS and OWNERS_ALIASES files:
+
+	korg audit --check-owners --owners-path ~/go/src/k8s.io
+	`
+
+	validateHelpText = `
+Validate org configs against policy rules
+
+Validates all orgs configured under config/ unless --org is specified, and
+prints every violation with the file and line it was found at:
+
+	korg validate
+	korg validate --org kubernetes
+
+Rules can be disabled or have their severity changed for all orgs or per org
+in config/validation.yaml. Only violations with error severity fail
+validation:
+
+	requiredAdmins:
+	- k8s-ci-robot
+	minApprovers: 5
+	rules:
+	  team-sorted-members:
+	    severity: warning
+	orgs:
+	  kubernetes-retired:
+	    rules:
+	      owners-min-approvers:
+	        enabled: false
+
+List the available rules:
+
+	korg validate --list-rules
+	`
+
+	diffHelpText = `
+Summarize membership changes between two git revisions
+
+Loads the merged config of every org at both revisions, without checking them
+out, and prints the users added to or removed from each org, team membership
+and maintainer changes, repo permission changes per team and org setting
+changes. The working tree is compared against <base-ref> if <head-ref> is
+omitted:
+
+	korg diff origin/main
+	korg diff origin/main HEAD --org kubernetes
+
+Print the summary as markdown, e.g. to post it as a PR comment:
+
+	korg diff origin/main HEAD --format markdown
 	`
 
-	auditHelpText = "Audit GitHub org members"
+	planHelpText = `
+Preview the changes peribolos would make to GitHub
+
+Compares the merged config against a JSON snapshot of the actual state of the
+orgs and prints the invitations, removals, role changes, team creations,
+renames and deletions, team membership changes and team repo permission
+changes that admin/update.sh would make, without calling GitHub:
+
+	korg snapshot --org kubernetes
+	make config
+	korg plan
+	korg plan --state github-state.json --org kubernetes --config-path gen-config.yaml
+
+The snapshot, written by "korg snapshot" or by hand, lists the org admins, members and pending invitations, and every
+team with its parent, maintainers, members and repos:
+
+	{"orgs": {"kubernetes": {
+	  "admins": ["cblecker"],
+	  "members": ["alice"],
+	  "invitations": ["bob"],
+	  "teams": {"sig-foo": {
+	    "description": "SIG Foo", "privacy": "closed", "parent": "",
+	    "maintainers": ["cblecker"], "members": ["alice"],
+	    "repos": {"foo": "write"}}}}}}
+
+Org settings are not part of the snapshot and are not compared. Pass the
+--required-admins of admin/update.sh to leave them out of admin removals and
+demotions, as peribolos does.
+	`
+
+	snapshotHelpText = `
+Export the current state of GitHub orgs
+
+Lists the admins, members and pending invitations of each org, and every team
+with its parent, maintainers, members and repo permissions, through the GitHub
+REST API. Writes them as a JSON snapshot for "korg plan" and as org.yaml and
+teams.yaml files under <dump-dir>/<org>/ in the format of config/<org>/:
+
+	korg snapshot --org kubernetes --github-token-path ~/.github-token
+
+The token is read from $GITHUB_TOKEN unless --github-token-path is set. Point
+--github-endpoint at GitHub Enterprise or a fake server to query other APIs.
+	`
+
+	driftHelpText = `
+Report changes made to GitHub orgs outside of their config
+
+Compares the state of the orgs on GitHub with the merged config and lists the
+members, team members and teams that only exist on GitHub, role, repo
+permission and team setting mismatches, and pending invitations. Each one is
+reported as fixed or ignored by the next peribolos run:
+
+	make config
+	korg drift --from-github
+	korg drift --state github-state.json --org kubernetes
+
+Members, teams and permissions that are in the config but not on GitHub yet are
+not drift, see "korg plan" for the changes the next deploy makes.
+	`
+
+	whoisHelpText = `
+Show everything a user has across orgs, teams and OWNERS files
+
+Lists, for every org under config/ unless --org is specified, whether the user
+is an admin or member and the file listing them, the teams they are a member or
+maintainer of, and their effective permission on each repo granted through
+their teams, including the repos of parent teams. Also lists the OWNERS files
+under config/ naming the user:
+
+	korg whois <github username>
+	korg whois <github username> --org kubernetes --format json
+	`
+
+	accessHelpText = `
+List the teams and users with access to a repo
+
+Computes the effective permission of every team on the repo from the merged
+team configs, including the repos child teams inherit from their parent teams,
+and the highest permission of every user through those teams. Org admins have
+admin access to every repo. Users are only listed if they have more than the
+base permission of org members:
+
+	korg access kubernetes/kubernetes
+	korg access kubernetes/org --format json
+
+Warns if no team and no repo settings of the org reference the repo, which
+usually means it is misspelled.
+	`
+
+	renameUserHelpText = `
+Rename a user after they changed their GitHub login
+
+Replaces every occurrence of the old login, compared case-insensitively, in
+config/<org>/org.yaml and the teams.yaml files of every org, in the OWNERS
+files under config/ and in OWNERS_ALIASES. Comments are kept and lists are
+kept sorted. Refuses to rename if the new login is already listed anywhere:
+
+	korg rename-user <old github username> <new github username> --confirm
+
+All changes are committed together.
+	`
+
+	teamHelpText = `
+Create, rename, move and delete teams
+
+Teams are referenced as <org>/<dir>/<team> for teams defined in
+config/<org>/<dir>/teams.yaml, or <org>/<team> for teams defined in org.yaml.
+Every change is checked against the rules of "korg validate" and the
+restrictions config before anything is written, and committed on its own.
+
+Create a team with privacy: closed. Maintainers must be org admins, and org
+admins passed as members are listed as maintainers. A new directory needs the
+approvers of its OWNERS file:
+
+	korg team create kubernetes/sig-foo/foo-reviewers --description "Reviewers of foo" \
+		--maintainer cblecker --member alice --owner sig-foo-leads --confirm
+	korg team create kubernetes/sig-foo/foo-admins --description "Admins of foo" --parent foo-reviewers
+
+Rename a team, keeping its old name in previously so that peribolos renames it
+on GitHub:
+
+	korg team rename kubernetes/sig-foo/foo-reviewers foo-approvers --confirm
+
+Move a team, with its child teams, to another directory ("." is org.yaml) or
+under a parent team:
+
+	korg team move kubernetes/sig-foo/foo-admins --dir sig-bar --confirm
+	korg team move kubernetes/foo-admins --parent bar-admins --confirm
+
+Delete a team without child teams:
+
+	korg team delete kubernetes/sig-foo/foo-admins --confirm
+	`
+
+	repoHelpText = `
+Grant and revoke the access of teams to repos
+
+Teams are referenced as <org>/<dir>/<team> for teams defined in
+config/<org>/<dir>/teams.yaml, or <org>/<team> for teams defined in org.yaml.
+The permission is one of read, triage, write, maintain or admin. Like in the
+restrictions check, repos granted to top-level teams must be allowed by the
+restriction of config/restrictions.yaml matching the file defining the team:
+
+	korg repo grant kubernetes/sig-foo/foo-reviewers foo --permission write --confirm
+
+Granting a repo the team already lists changes its permission. Revoke the access
+of a team to a repo, leaving the access it inherits from parent teams as is:
+
+	korg repo revoke kubernetes/sig-foo/foo-reviewers foo --confirm
+	`
 )
 
 type Options struct {
 	// global options
-	Confirm  bool
-	RepoRoot string
-	Orgs     []string
-	Teams    []string
+	Confirm       bool
+	RepoRoot      string
+	Orgs          []string
+	Teams         []string
+	AllowOverride bool
+
+	// add/remove options
+	Maintainer      bool
+	FromFile        string
+	Sponsors        []string
+	SponsorOverride string
 
 	// audit options
 	AuditOptions
+
+	// validate options
+	ValidationConfig  string
+	ListRules         bool
+	GitHubAnnotations bool
+
+	// diff options
+	DiffFormat string
+
+	// plan/snapshot options
+	State      string
+	PlanConfig string
+
+	// plan options
+	RequiredAdmins []string
+
+	// snapshot/drift options
+	GitHubEndpoint  string
+	GitHubTokenPath string
+	DumpDir         string
+	FromGitHub      bool
+
+	// whois options
+	WhoisFormat string
+
+	// access options
+	AccessFormat string
+
+	// team options
+	TeamOptions
+
+	// repo options
+	Permission string
 }
 
 func AddMemberToOrgs(username string, options Options) error {
-	if invalidOrgs := findInvalidOrgs(options.Orgs); len(invalidOrgs) > 0 {
-		return fmt.Errorf("specified invalid orgs: %s", strings.Join(invalidOrgs, ", "))
+	if err := validateOrgs(options.RepoRoot, options.Orgs, true); err != nil {
+		return err
+	}
+
+	teams, err := parseTeamRefs(options.Teams)
+	if err != nil {
+		return err
+	}
+	if len(teams) > 0 && len(options.Orgs) != 1 {
+		return fmt.Errorf("teams can only be specified for a single org")
 	}
 
 	if !options.Confirm {
 		fmt.Println("!!! running in dry-run mode. pass --confirm to persist changes.")
 	}
 
-	configsModified := []string{}
-	for _, org := range options.Orgs {
-		fmt.Printf("adding %s to %s org\n", username, org)
-
-		relativeConfigPath := fmt.Sprintf(orgConfigPathFormat, org)
-		configPath := filepath.Join(options.RepoRoot, relativeConfigPath)
+	affiliations, err := loadAffiliations(options.RepoRoot)
+	if err != nil {
+		return err
+	}
 
-		config, err := readConfig(configPath)
+	// every org and team is checked before any config file is written
+	repo := &orgconfig.Repo{Root: options.RepoRoot, AllowOverride: options.AllowOverride}
+	var changes orgconfig.Changes
+	var sponsorOverridden bool
+	orgsModified := []string{}
+	for _, org := range options.Orgs {
+		orgCfg, err := repo.LoadOrg(org)
 		if err != nil {
 			return fmt.Errorf("reading config: %s", err)
 		}
 
-		if stringInSliceCaseAgnostic(config.Members, username) || stringInSliceCaseAgnostic(config.Admins, username) {
-			return fmt.Errorf("user %s already exists in org %s", username, org)
-		}
+		if orgCfg.IsMember(username) {
+			if len(teams) == 0 {
+				return fmt.Errorf("user %s already exists in org %s", username, org)
+			}
+			fmt.Printf("%s is already a member of %s org\n", username, org)
+		} else {
+			overridden, err := verifySponsors(options, username, org, &orgCfg.Config, affiliations)
+			if err != nil {
+				return err
+			}
+			sponsorOverridden = sponsorOverridden || overridden
 
-		newMembers := append(config.Members, username)
-		config.Members = newMembers
-		caseAgnosticSort(config.Members)
+			fmt.Printf("adding %s to %s org\n", username, org)
+			e, _ := orgCfg.AddMember(username)
+			changes.Add(e)
+			orgsModified = append(orgsModified, org)
+		}
 
-		if options.Confirm {
-			fmt.Printf("saving config for %s org\n", org)
-			if err := saveConfig(configPath, config); err != nil {
-				return fmt.Errorf("saving config: %s", err)
+		if len(teams) > 0 {
+			if err := AddMemberToTeams(&changes, orgCfg, username, teams, options); err != nil {
+				return err
 			}
 		}
-
-		configsModified = append(configsModified, relativeConfigPath)
 	}
+	configsModified := changes.Files()
+	fmt.Printf("config files modified: %s\n", strings.Join(configsModified, ", "))
 
 	if options.Confirm {
+		if err := writeChanges(options, &changes); err != nil {
+			return err
+		}
+
 		fmt.Println("committing changes")
 
-		message := fmt.Sprintf("add %s to %s", username, strings.Join(options.Orgs, ", "))
+		var targets []string
+		if len(orgsModified) > 0 {
+			targets = append(targets, strings.Join(orgsModified, ", "))
+		}
+		if len(teams) > 0 {
+			targets = append(targets, fmt.Sprintf("%s teams %s", options.Orgs[0], strings.Join(options.Teams, ", ")))
+		}
+		message := fmt.Sprintf("add %s to %s", username, strings.Join(targets, " and "))
+		if len(orgsModified) > 0 {
+			message += sponsorTrailers(options.Sponsors, sponsorOverridden, options.SponsorOverride)
+		}
 		if err := commitChanges(options.RepoRoot, configsModified, message); err != nil {
 			return fmt.Errorf("committing changes: %s", err)
 		}
@@ -121,6 +460,19 @@ func AddMemberToOrgs(username string, options Options) error {
 	return nil
 }
 
+// userArgs expects a single username unless users are read from a manifest.
+func userArgs(o *Options) cobra.PositionalArgs {
+	return func(cmd *cobra.Command, args []string) error {
+		if o.FromFile != "" {
+			if len(args) > 0 {
+				return fmt.Errorf("usernames cannot be specified together with --from-file")
+			}
+			return nil
+		}
+		return cobra.ExactArgs(1)(cmd, args)
+	}
+}
+
 func main() {
 	rootCmd := &cobra.Command{
 		Use:   "korg",
@@ -131,28 +483,46 @@ func main() {
 	rootCmd.PersistentFlags().BoolVar(&o.Confirm, "confirm", false, "confirm the changes")
 	rootCmd.PersistentFlags().StringVar(&o.RepoRoot, "root", ".", "root of the k/org repo")
 	rootCmd.PersistentFlags().StringSliceVar(&o.Orgs, "org", []string{}, "orgs to add the user to")
+	rootCmd.PersistentFlags().BoolVar(&o.AllowOverride, "allow-override", false, "allow teams.yaml files to redefine a top-level team defined in an earlier file when loading orgs. overrides are logged")
 
 	addCmd := &cobra.Command{
 		Use:   "add",
 		Short: "Add members to org and/or teams",
 		Long:  addHelpText,
-		Args:  cobra.ExactArgs(1),
+		Args:  userArgs(&o),
 		PreRunE: func(cmd *cobra.Command, args []string) error {
 			if len(args) > 1 {
 				return fmt.Errorf("add only adds one user at a time. specified %d", len(args))
 			}
 
+			if cmd.Flags().Changed("sponsor-override") && strings.TrimSpace(o.SponsorOverride) == "" {
+				return fmt.Errorf("--sponsor-override requires a reason")
+			}
+
+			if o.FromFile != "" {
+				return validateOrgs(o.RepoRoot, o.Orgs, true)
+			}
+
 			if len(o.Orgs) == 0 {
 				return fmt.Errorf("please specify atleast one org to add the user to")
 			}
 
-			if invalidOrgs := findInvalidOrgs(o.Orgs); len(invalidOrgs) > 0 {
-				return fmt.Errorf("specified invalid orgs: %s", strings.Join(invalidOrgs, ", "))
+			if err := validateOrgs(o.RepoRoot, o.Orgs, true); err != nil {
+				return err
+			}
+
+			if len(o.Teams) > 0 && len(o.Orgs) != 1 {
+				return fmt.Errorf("teams can only be specified for a single org")
 			}
 
 			return nil
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+			if o.FromFile != "" {
+				return AddMembersFromManifest(o)
+			}
+
 			user := args[0]
 			if len(o.Orgs) > 0 {
 				return AddMemberToOrgs(user, o)
@@ -164,17 +534,29 @@ func main() {
 
 	removeCmd := &cobra.Command{
 		Use:   "remove",
-		Short: "Remove members from org",
+		Short: "Remove members from org and/or teams",
 		Long:  removeHelpText,
-		Args:  cobra.ExactArgs(1),
+		Args:  userArgs(&o),
 		PreRunE: func(cmd *cobra.Command, args []string) error {
-			if invalidOrgs := findInvalidOrgs(o.Orgs); len(invalidOrgs) > 0 {
-				return fmt.Errorf("specified invalid orgs: %s", strings.Join(invalidOrgs, ", "))
+			if err := validateOrgs(o.RepoRoot, o.Orgs, false); err != nil {
+				return err
+			}
+
+			if o.FromFile != "" {
+				return nil
+			}
+
+			if len(o.Teams) > 0 && len(o.Orgs) != 1 {
+				return fmt.Errorf("teams can only be specified for a single org")
 			}
 
 			return nil
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if o.FromFile != "" {
+				return RemoveMembersFromManifest(o)
+			}
+
 			user := args[0]
 			if len(o.Orgs) > 0 {
 				return RemoveMemberFromOrgs(o, user)
@@ -184,22 +566,35 @@ func main() {
 		},
 	}
 
+	// korg add flags
+	addCmd.Flags().StringSliceVar(&o.Teams, "team", []string{}, "teams to add the user to, as <dir>/<team> relative to config/<org>/")
+	addCmd.Flags().StringVar(&o.FromFile, "from-file", "", "add all users listed in a CSV or YAML manifest in a single commit")
+	addCmd.Flags().BoolVar(&o.Maintainer, "maintainer", false, "add the user to teams as a maintainer. the user must be an org admin")
+	addCmd.Flags().StringSliceVar(&o.Sponsors, "sponsor", []string{}, fmt.Sprintf("members of the org sponsoring the membership of a new user. %d with different affiliations are required", requiredSponsors))
+	addCmd.Flags().StringVar(&o.SponsorOverride, "sponsor-override", "", "reason for adding a new user whose sponsors don't pass the sponsor check")
+
 	// korg remove flags
 	removeCmd.Flags().StringSliceVar(&o.Orgs, "org", []string{}, "orgs to remove the user from")
+	removeCmd.Flags().StringSliceVar(&o.Teams, "team", []string{}, "teams to remove the user from, as <dir>/<team> relative to config/<org>/")
+	removeCmd.Flags().StringVar(&o.FromFile, "from-file", "", "remove all users listed in a CSV or YAML manifest in a single commit")
 
 	auditCmd := &cobra.Command{
 		Use:   "audit",
 		Short: "Audit GitHub org members",
 		Long:  auditHelpText,
 		PreRunE: func(cmd *cobra.Command, args []string) error {
-			if invalidOrgs := findInvalidOrgs(o.Orgs); len(invalidOrgs) > 0 {
-				return fmt.Errorf("specified invalid orgs: %s", strings.Join(invalidOrgs, ", "))
+			if err := validateOrgs(o.RepoRoot, o.Orgs, false); err != nil {
+				return err
 			}
 
 			if o.ActivityThreshold < 0 {
 				return fmt.Errorf("activity threshold cannot be negative")
 			}
 
+			if err := validateAuditFormat(o.Format); err != nil {
+				return err
+			}
+
 			// TODO: Check if exceptions file is of the right format, if defined
 
 			return nil
@@ -212,15 +607,272 @@ func main() {
 	// korg audit flags
 	auditCmd.Flags().IntVar(&o.ActivityThreshold, "activity-threshold", 0, "minimum activity to be considered active. default: 0")
 	auditCmd.Flags().StringVar(&o.Period, "period", "y", "period to look back for activity. possible values are defined in https://github.com/cncf/devstats/blob/master/docs/periods.md. default: y (Year)")
-	auditCmd.Flags().StringVar(&o.OutputFile, "output-file", "", "parse owners files. default: none")
+	auditCmd.Flags().StringVar(&o.OutputFile, "output-file", "", "file to write the audit report to. default: stdout")
+	auditCmd.Flags().StringVar(&o.Format, "format", auditFormatTable, fmt.Sprintf("format of the audit report. one of: %s", strings.Join(auditFormats, ", ")))
 	auditCmd.Flags().StringVar(&o.ExceptionsFile, "exceptions-file", "", "exceptions for removal. default: none")
 	auditCmd.Flags().BoolVar(&o.CheckOwners, "check-owners", false, "parse owners files. default: false")
+	auditCmd.Flags().StringVar(&o.OwnersPath, "owners-path", "", "directory or tar archive of OWNERS and OWNERS_ALIASES files to check owners against instead of searching cs.k8s.io. default: none")
 	auditCmd.Flags().BoolVar(&o.CheckTeams, "check-teams", false, "check which teams the user belongs to. default: false")
+	auditCmd.Flags().BoolVar(&o.Apply, "apply", false, "remove the inactive members from all orgs and teams in a single commit. pass --confirm to persist changes. default: false")
+	auditCmd.Flags().StringSliceVar(&o.DevStatsURLs, "devstats-url", defaultDevStatsURLs, "devstats query APIs to fetch contributions from. contributions are summed across all of them")
+	auditCmd.Flags().StringVar(&o.ContributionsFile, "contributions-file", "", "read contributions from a JSON or CSV snapshot instead of querying devstats. default: none")
+	auditCmd.Flags().BoolVar(&o.IncludeReadOnly, "include-read-only", false, "audit read-only orgs when --org is not specified. default: false")
+
+	validateCmd := &cobra.Command{
+		Use:   "validate",
+		Short: "Validate org configs against policy rules",
+		Long:  validateHelpText,
+		Args:  cobra.NoArgs,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			return validateOrgs(o.RepoRoot, o.Orgs, false)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+			return ValidateOrgs(o, os.Stdout)
+		},
+	}
+
+	// korg validate flags
+	validateCmd.Flags().StringVar(&o.ValidationConfig, "config", "", "validation config file. default: config/validation.yaml under --root")
+	validateCmd.Flags().BoolVar(&o.GitHubAnnotations, "github-annotations", diagnostics.GitHubActions(), "also print violations as GitHub Actions annotations. default: true when running in GitHub Actions")
+	validateCmd.Flags().BoolVar(&o.ListRules, "list-rules", false, "list the available rules and exit")
+
+	diffCmd := &cobra.Command{
+		Use:   "diff <base-ref> [<head-ref>]",
+		Short: "Summarize membership changes between two git revisions",
+		Long:  diffHelpText,
+		Args:  cobra.RangeArgs(1, 2),
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			for _, f := range diffFormats {
+				if f == o.DiffFormat {
+					return nil
+				}
+			}
+			return fmt.Errorf("invalid format %q, must be one of: %s", o.DiffFormat, strings.Join(diffFormats, ", "))
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+			head := ""
+			if len(args) > 1 {
+				head = args[1]
+			}
+			return DiffOrgs(o, args[0], head, os.Stdout)
+		},
+	}
+
+	// korg diff flags
+	diffCmd.Flags().StringVar(&o.DiffFormat, "format", diffFormatText, fmt.Sprintf("format of the summary. one of: %s", strings.Join(diffFormats, ", ")))
+
+	planCmd := &cobra.Command{
+		Use:   "plan",
+		Short: "Preview the changes peribolos would make to GitHub",
+		Long:  planHelpText,
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+			return PlanOrgs(o, os.Stdout)
+		},
+	}
+
+	// korg plan flags
+	planCmd.Flags().StringVar(&o.State, "state", "", fmt.Sprintf("JSON snapshot of the state of the orgs on GitHub, as written by korg snapshot. default: %s under --root", defaultStateFile))
+	planCmd.Flags().StringVar(&o.PlanConfig, "config-path", "", fmt.Sprintf("merged config to compare the state against. default: %s under --root", defaultMergedConfig))
+	planCmd.Flags().StringSliceVar(&o.RequiredAdmins, "required-admins", []string{}, "admins that peribolos never removes or demotes, as passed by admin/update.sh")
+
+	snapshotCmd := &cobra.Command{
+		Use:   "snapshot",
+		Short: "Export the current state of GitHub orgs",
+		Long:  snapshotHelpText,
+		Args:  cobra.NoArgs,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if len(o.Orgs) == 0 {
+				return fmt.Errorf("please specify atleast one org to snapshot")
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+			return SnapshotOrgs(o, os.Stdout)
+		},
+	}
+
+	// korg snapshot flags
+	snapshotCmd.Flags().StringVar(&o.State, "state", "", fmt.Sprintf("file to write the JSON snapshot to. default: %s under --root", defaultStateFile))
+	snapshotCmd.Flags().StringVar(&o.DumpDir, "dump-dir", "", fmt.Sprintf("directory to write org.yaml and teams.yaml of each org to. default: %s under --root", defaultDumpDir))
+	snapshotCmd.Flags().StringVar(&o.GitHubEndpoint, "github-endpoint", ghstate.DefaultEndpoint, "GitHub REST API endpoint")
+	snapshotCmd.Flags().StringVar(&o.GitHubTokenPath, "github-token-path", "", "file containing a GitHub token. default: $GITHUB_TOKEN")
+
+	driftCmd := &cobra.Command{
+		Use:   "drift",
+		Short: "Report changes made to GitHub orgs outside of their config",
+		Long:  driftHelpText,
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+			return DriftOrgs(o, os.Stdout)
+		},
+	}
+
+	// korg drift flags
+	driftCmd.Flags().StringVar(&o.State, "state", "", fmt.Sprintf("JSON snapshot of the state of the orgs on GitHub, as written by korg snapshot. default: %s under --root", defaultStateFile))
+	driftCmd.Flags().BoolVar(&o.FromGitHub, "from-github", false, "fetch the state of the orgs from the GitHub API instead of reading --state")
+	driftCmd.Flags().StringVar(&o.PlanConfig, "config-path", "", fmt.Sprintf("merged config to compare the state against. default: %s under --root", defaultMergedConfig))
+	driftCmd.Flags().StringVar(&o.GitHubEndpoint, "github-endpoint", ghstate.DefaultEndpoint, "GitHub REST API endpoint")
+	driftCmd.Flags().StringVar(&o.GitHubTokenPath, "github-token-path", "", "file containing a GitHub token. default: $GITHUB_TOKEN")
+
+	whoisCmd := &cobra.Command{
+		Use:   "whois <github username>",
+		Short: "Show everything a user has across orgs, teams and OWNERS files",
+		Long:  whoisHelpText,
+		Args:  cobra.ExactArgs(1),
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if err := validateOrgs(o.RepoRoot, o.Orgs, false); err != nil {
+				return err
+			}
+			for _, f := range reportFormats {
+				if f == o.WhoisFormat {
+					return nil
+				}
+			}
+			return fmt.Errorf("invalid format %q, must be one of: %s", o.WhoisFormat, strings.Join(reportFormats, ", "))
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+			return Whois(o, args[0], os.Stdout)
+		},
+	}
+
+	// korg whois flags
+	whoisCmd.Flags().StringVar(&o.WhoisFormat, "format", reportFormatText, fmt.Sprintf("format of the report. one of: %s", strings.Join(reportFormats, ", ")))
+
+	accessCmd := &cobra.Command{
+		Use:   "access <org>/<repo>",
+		Short: "List the teams and users with access to a repo",
+		Long:  accessHelpText,
+		Args:  cobra.ExactArgs(1),
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			for _, f := range reportFormats {
+				if f == o.AccessFormat {
+					return nil
+				}
+			}
+			return fmt.Errorf("invalid format %q, must be one of: %s", o.AccessFormat, strings.Join(reportFormats, ", "))
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+			return RepoAccess(o, args[0], os.Stdout)
+		},
+	}
+
+	// korg access flags
+	accessCmd.Flags().StringVar(&o.AccessFormat, "format", reportFormatText, fmt.Sprintf("format of the report. one of: %s", strings.Join(reportFormats, ", ")))
+
+	renameUserCmd := &cobra.Command{
+		Use:   "rename-user <old github username> <new github username>",
+		Short: "Rename a user across org configs and OWNERS files",
+		Long:  renameUserHelpText,
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+			return RenameUser(o, args[0], args[1])
+		},
+	}
+
+	teamCmd := &cobra.Command{
+		Use:   "team",
+		Short: "Create, rename, move and delete teams",
+		Long:  teamHelpText,
+	}
+	teamCreateCmd := &cobra.Command{
+		Use:   "create <org>/<dir>/<team>",
+		Short: "Create a team",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+			return CreateTeam(o, args[0])
+		},
+	}
+	teamRenameCmd := &cobra.Command{
+		Use:   "rename <org>/<dir>/<team> <new name>",
+		Short: "Rename a team",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+			return RenameTeam(o, args[0], args[1])
+		},
+	}
+	teamMoveCmd := &cobra.Command{
+		Use:   "move <org>/<dir>/<team>",
+		Short: "Move a team to another directory or under a parent team",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+			return MoveTeam(o, args[0])
+		},
+	}
+	teamDeleteCmd := &cobra.Command{
+		Use:   "delete <org>/<dir>/<team>",
+		Short: "Delete a team",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+			return DeleteTeam(o, args[0])
+		},
+	}
+
+	// korg team flags
+	teamCreateCmd.Flags().StringVar(&o.TeamOptions.Description, "description", "", "description of the team")
+	teamCreateCmd.Flags().StringSliceVar(&o.TeamOptions.Maintainers, "maintainer", []string{}, "maintainers of the team. must be org admins")
+	teamCreateCmd.Flags().StringSliceVar(&o.TeamOptions.Members, "member", []string{}, "members of the team. must be org members")
+	teamCreateCmd.Flags().StringVar(&o.TeamOptions.Parent, "parent", "", "create the team as a child of this team, defined in the same file")
+	teamCreateCmd.Flags().StringSliceVar(&o.TeamOptions.Owners, "owner", []string{}, "approvers and reviewers of the OWNERS file of a new directory")
+	teamMoveCmd.Flags().StringVar(&o.TeamOptions.Dir, "dir", "", `directory relative to config/<org>/ to move the team to the top level of. "." is org.yaml`)
+	teamMoveCmd.Flags().StringVar(&o.TeamOptions.Parent, "parent", "", "team to move the team under")
+	teamMoveCmd.Flags().StringSliceVar(&o.TeamOptions.Owners, "owner", []string{}, "approvers and reviewers of the OWNERS file of a new directory")
+	teamCmd.AddCommand(teamCreateCmd, teamRenameCmd, teamMoveCmd, teamDeleteCmd)
+
+	repoCmd := &cobra.Command{
+		Use:   "repo",
+		Short: "Grant and revoke the access of teams to repos",
+		Long:  repoHelpText,
+	}
+	repoGrantCmd := &cobra.Command{
+		Use:   "grant <org>/<dir>/<team> <repo>",
+		Short: "Grant a team a permission on a repo",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+			return GrantRepo(o, args[0], args[1])
+		},
+	}
+	repoRevokeCmd := &cobra.Command{
+		Use:   "revoke <org>/<dir>/<team> <repo>",
+		Short: "Revoke the access of a team to a repo",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+			return RevokeRepo(o, args[0], args[1])
+		},
+	}
+
+	// korg repo flags
+	repoGrantCmd.Flags().StringVar(&o.Permission, "permission", "", "permission to grant. one of: read, triage, write, maintain, admin")
+	repoCmd.AddCommand(repoGrantCmd, repoRevokeCmd)
 
 	// commands
 	rootCmd.AddCommand(addCmd)
 	rootCmd.AddCommand(removeCmd)
 	rootCmd.AddCommand(auditCmd)
+	rootCmd.AddCommand(validateCmd)
+	rootCmd.AddCommand(diffCmd)
+	rootCmd.AddCommand(planCmd)
+	rootCmd.AddCommand(snapshotCmd)
+	rootCmd.AddCommand(driftCmd)
+	rootCmd.AddCommand(whoisCmd)
+	rootCmd.AddCommand(accessCmd)
+	rootCmd.AddCommand(renameUserCmd)
+	rootCmd.AddCommand(teamCmd)
+	rootCmd.AddCommand(repoCmd)
 
 	if err := rootCmd.Execute(); err != nil {
 		os.Exit(1)
@@ -0,0 +1,132 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+var orgMetadataPathFormat = "config/%s/metadata.yaml"
+
+// OrgMetadata is read from the optional config/<org>/metadata.yaml file and
+// describes how korg treats an org.
+type OrgMetadata struct {
+	// ReadOnly orgs, e.g. archived ones, are refused by commands adding
+	// members and are skipped by audit unless explicitly requested.
+	ReadOnly bool `json:"readOnly,omitempty"`
+	// Reason explains why the org is read-only.
+	Reason string `json:"reason,omitempty"`
+}
+
+// orgRegistry maps org names to their metadata for every org with a
+// config/<org>/org.yaml.
+type orgRegistry map[string]OrgMetadata
+
+// loadOrgRegistry discovers orgs by scanning the config directory under
+// repoRoot for org.yaml files.
+func loadOrgRegistry(repoRoot string) (orgRegistry, error) {
+	configDir := filepath.Join(repoRoot, "config")
+	entries, err := os.ReadDir(configDir)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list orgs in %s: %s", configDir, err)
+	}
+
+	registry := orgRegistry{}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		name := entry.Name()
+		if _, err := os.Stat(filepath.Join(repoRoot, fmt.Sprintf(orgConfigPathFormat, name))); err != nil {
+			continue
+		}
+
+		var metadata OrgMetadata
+		path := filepath.Join(repoRoot, fmt.Sprintf(orgMetadataPathFormat, name))
+		contents, err := os.ReadFile(path)
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+		case err != nil:
+			return nil, fmt.Errorf("unable to read file at %s: %s", path, err)
+		default:
+			if err := yaml.Unmarshal(contents, &metadata, yaml.DisallowUnknownFields); err != nil {
+				return nil, fmt.Errorf("unable to unmarshal metadata from %s: %s", path, err)
+			}
+		}
+		registry[name] = metadata
+	}
+	return registry, nil
+}
+
+// Names returns the sorted names of all orgs, leaving out read-only orgs
+// unless includeReadOnly is set.
+func (r orgRegistry) Names(includeReadOnly bool) []string {
+	names := []string{}
+	for name, metadata := range r {
+		if metadata.ReadOnly && !includeReadOnly {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// validateOrgs returns an error if any of orgs is not configured under
+// repoRoot or, when writable is set, is read-only.
+func validateOrgs(repoRoot string, orgs []string, writable bool) error {
+	registry, err := loadOrgRegistry(repoRoot)
+	if err != nil {
+		return err
+	}
+	return registry.Validate(orgs, writable)
+}
+
+// Validate returns an error if any of orgs is unknown or, when writable is
+// set, is read-only.
+func (r orgRegistry) Validate(orgs []string, writable bool) error {
+	invalid := []string{}
+	readOnly := []string{}
+	for _, org := range orgs {
+		metadata, ok := r[org]
+		switch {
+		case !ok:
+			invalid = append(invalid, org)
+		case writable && metadata.ReadOnly:
+			if metadata.Reason != "" {
+				org = fmt.Sprintf("%s (%s)", org, metadata.Reason)
+			}
+			readOnly = append(readOnly, org)
+		}
+	}
+
+	if len(invalid) > 0 {
+		return fmt.Errorf("specified invalid orgs: %s", strings.Join(invalid, ", "))
+	}
+	if len(readOnly) > 0 {
+		return fmt.Errorf("specified read-only orgs: %s", strings.Join(readOnly, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func writeFile(t *testing.T, root, path, contents string) {
+	t.Helper()
+	path = filepath.Join(root, path)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadOrgRegistry(t *testing.T) {
+	root := setupRepoRoot(t)
+	writeFile(t, root, "config/kubernetes-retired/org.yaml", "name: Kubernetes Retired\n")
+	writeFile(t, root, "config/kubernetes-retired/metadata.yaml", "readOnly: true\nreason: archived\n")
+	writeFile(t, root, "config/not-an-org/OWNERS", "approvers: []\n")
+
+	registry, err := loadOrgRegistry(root)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got, expected := registry.Names(false), []string{"kubernetes"}; !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected writable orgs %v, got %v", expected, got)
+	}
+	if got, expected := registry.Names(true), []string{"kubernetes", "kubernetes-retired"}; !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected all orgs %v, got %v", expected, got)
+	}
+
+	cases := []struct {
+		orgs        []string
+		writable    bool
+		expectError bool
+	}{
+		{orgs: []string{"kubernetes"}, writable: true},
+		{orgs: []string{"kubernetes-retired"}, writable: false},
+		{orgs: []string{"kubernetes-retired"}, writable: true, expectError: true},
+		{orgs: []string{"not-an-org"}, writable: false, expectError: true},
+	}
+	for _, c := range cases {
+		err := registry.Validate(c.orgs, c.writable)
+		if c.expectError && err == nil {
+			t.Errorf("expected error validating %v (writable: %v)", c.orgs, c.writable)
+		}
+		if !c.expectError && err != nil {
+			t.Errorf("unexpected error validating %v (writable: %v): %v", c.orgs, c.writable, err)
+		}
+	}
+
+	o := Options{RepoRoot: root, Orgs: []string{"kubernetes-retired"}}
+	if err := AddMemberToOrgs("alice", o); err == nil {
+		t.Errorf("expected error adding to a read-only org")
+	}
+}
+
+func TestLoadOrgRegistryInvalidMetadata(t *testing.T) {
+	root := setupRepoRoot(t)
+	writeFile(t, root, "config/kubernetes/metadata.yaml", "archived: true\n")
+
+	if _, err := loadOrgRegistry(root); err == nil {
+		t.Errorf("expected error for unknown metadata fields")
+	}
+}
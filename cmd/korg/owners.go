@@ -0,0 +1,342 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	ownersFileName        = "OWNERS"
+	ownersAliasesFileName = "OWNERS_ALIASES"
+
+	ownerRoleApprover = "approver"
+	ownerRoleReviewer = "reviewer"
+)
+
+type ownersConfig struct {
+	Reviewers []string `json:"reviewers,omitempty"`
+	Approvers []string `json:"approvers,omitempty"`
+}
+
+type ownersFile struct {
+	ownersConfig
+	// Filters maps file name regexes to the owners of matching files.
+	Filters map[string]ownersConfig `json:"filters,omitempty"`
+}
+
+type ownersAliasesFile struct {
+	Aliases map[string][]string `json:"aliases,omitempty"`
+}
+
+// OwnerEntry is a role held by a user in an OWNERS file.
+type OwnerEntry struct {
+	// File is the path of the OWNERS file relative to the scanned directory
+	// or archive.
+	File string `json:"file"`
+	// Role is either approver or reviewer.
+	Role string `json:"role"`
+	// Alias is set if the user holds the role through an alias defined in
+	// OWNERS_ALIASES.
+	Alias string `json:"alias,omitempty"`
+}
+
+func (e OwnerEntry) String() string {
+	if e.Alias != "" {
+		return fmt.Sprintf("%s (%s via %s)", e.File, e.Role, e.Alias)
+	}
+	return fmt.Sprintf("%s (%s)", e.File, e.Role)
+}
+
+// OwnerResolver determines whether users are listed in OWNERS files.
+type OwnerResolver interface {
+	// Owners reports whether username is an owner and, if known, the OWNERS
+	// file entries listing them.
+	Owners(username string) (bool, []OwnerEntry, error)
+}
+
+// newOwnerResolver scans the OWNERS files at o.OwnersPath if it is set and
+// falls back to searching cs.k8s.io otherwise.
+func newOwnerResolver(o AuditOptions) (OwnerResolver, error) {
+	if o.OwnersPath == "" {
+		return csSearchResolver{}, nil
+	}
+	fmt.Fprintf(os.Stderr, "indexing OWNERS files in %s\n", o.OwnersPath)
+	return loadOwnersIndex(o.OwnersPath)
+}
+
+// csSearchResolver searches OWNERS files across all repos indexed by
+// cs.k8s.io. It only knows whether a user is mentioned, not where.
+type csSearchResolver struct{}
+
+func (csSearchResolver) Owners(username string) (bool, []OwnerEntry, error) {
+	isOwner, err := IsOwner(username)
+	return isOwner, nil, err
+}
+
+// ownersIndex maps lowercased usernames to the OWNERS file entries listing
+// them, with aliases expanded.
+type ownersIndex map[string][]OwnerEntry
+
+func (idx ownersIndex) Owners(username string) (bool, []OwnerEntry, error) {
+	entries := idx[strings.ToLower(username)]
+	return len(entries) > 0, entries, nil
+}
+
+// loadOwnersIndex indexes the OWNERS and OWNERS_ALIASES files in a directory,
+// e.g. a checkout of one or more repos, or in a tar archive (optionally gzip
+// compressed) of such files. Aliases are resolved using the OWNERS_ALIASES
+// file in the nearest parent directory of each OWNERS file.
+func loadOwnersIndex(root string) (ownersIndex, error) {
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, err
+	}
+
+	files := map[string][]byte{}
+	if info.IsDir() {
+		err = readOwnersDir(root, files)
+	} else {
+		err = readOwnersArchive(root, files)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return buildOwnersIndex(files)
+}
+
+func isOwnersFile(name string) bool {
+	return name == ownersFileName || name == ownersAliasesFileName
+}
+
+// skipOwnersDir reports whether OWNERS files in the directory named name
+// should be ignored.
+func skipOwnersDir(name string) bool {
+	return name == ".git" || name == "vendor"
+}
+
+func readOwnersDir(root string, files map[string][]byte) error {
+	return filepath.Walk(root, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if p != root && skipOwnersDir(info.Name()) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !isOwnersFile(info.Name()) {
+			return nil
+		}
+
+		buf, err := os.ReadFile(p)
+		if err != nil {
+			return fmt.Errorf("read: %v", err)
+		}
+		relativePath, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(relativePath)] = buf
+		return nil
+	})
+}
+
+func readOwnersArchive(archive string, files map[string][]byte) error {
+	f, err := os.Open(archive)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if strings.HasSuffix(archive, ".gz") || strings.HasSuffix(archive, ".tgz") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return fmt.Errorf("unable to read %s: %v", archive, err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("unable to read %s: %v", archive, err)
+		}
+		name := path.Clean(strings.TrimPrefix(hdr.Name, "./"))
+		if hdr.Typeflag != tar.TypeReg || !isOwnersFile(path.Base(name)) {
+			continue
+		}
+		skip := false
+		for _, dir := range strings.Split(path.Dir(name), "/") {
+			skip = skip || skipOwnersDir(dir)
+		}
+		if skip {
+			continue
+		}
+		buf, err := io.ReadAll(tr)
+		if err != nil {
+			return fmt.Errorf("unable to read %s from %s: %v", name, archive, err)
+		}
+		files[name] = buf
+	}
+}
+
+// buildOwnersIndex indexes files, keyed by slash separated relative path.
+func buildOwnersIndex(files map[string][]byte) (ownersIndex, error) {
+	aliases := map[string]map[string][]string{}
+	var ownersPaths []string
+	for p, buf := range files {
+		if path.Base(p) == ownersFileName {
+			ownersPaths = append(ownersPaths, p)
+			continue
+		}
+		var a ownersAliasesFile
+		if err := yaml.Unmarshal(buf, &a); err != nil {
+			return nil, fmt.Errorf("unable to unmarshal %s: %v", p, err)
+		}
+		normalized := map[string][]string{}
+		for name, users := range a.Aliases {
+			normalized[strings.ToLower(name)] = users
+		}
+		aliases[path.Dir(p)] = normalized
+	}
+	sort.Strings(ownersPaths)
+
+	idx := ownersIndex{}
+	for _, p := range ownersPaths {
+		var owners ownersFile
+		if err := yaml.Unmarshal(files[p], &owners); err != nil {
+			return nil, fmt.Errorf("unable to unmarshal %s: %v", p, err)
+		}
+
+		fileAliases := nearestAliases(aliases, path.Dir(p))
+		// a user may be listed more than once, e.g. directly and in filters
+		seen := map[string]bool{}
+		add := func(names []string, role string) {
+			for _, name := range names {
+				users, alias := []string{name}, ""
+				if expanded, ok := fileAliases[strings.ToLower(name)]; ok {
+					users, alias = expanded, name
+				}
+				for _, u := range users {
+					key := strings.ToLower(u)
+					if seen[key+"/"+role+"/"+alias] {
+						continue
+					}
+					seen[key+"/"+role+"/"+alias] = true
+					idx[key] = append(idx[key], OwnerEntry{File: p, Role: role, Alias: alias})
+				}
+			}
+		}
+
+		configs := []ownersConfig{owners.ownersConfig}
+		filters := make([]string, 0, len(owners.Filters))
+		for f := range owners.Filters {
+			filters = append(filters, f)
+		}
+		sort.Strings(filters)
+		for _, f := range filters {
+			configs = append(configs, owners.Filters[f])
+		}
+		for _, c := range configs {
+			add(c.Approvers, ownerRoleApprover)
+			add(c.Reviewers, ownerRoleReviewer)
+		}
+	}
+	return idx, nil
+}
+
+func nearestAliases(aliases map[string]map[string][]string, dir string) map[string][]string {
+	for {
+		if a, ok := aliases[dir]; ok {
+			return a
+		}
+		if dir == "." || dir == "/" {
+			return nil
+		}
+		dir = path.Dir(dir)
+	}
+}
+
+// findConfigApprovers returns the OWNERS files under config/ that list each
+// user as an approver, directly or through an alias, keyed by lowercased
+// username. Paths are relative to the repo root.
+func findConfigApprovers(repoRoot string) (map[string][]string, error) {
+	idx, err := loadConfigOwners(repoRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	approvers := map[string][]string{}
+	for user, entries := range idx {
+		for _, e := range entries {
+			if e.Role == ownerRoleApprover && !slices.Contains(approvers[user], e.File) {
+				approvers[user] = append(approvers[user], e.File)
+			}
+		}
+	}
+	return approvers, nil
+}
+
+// loadConfigOwners indexes the OWNERS files under config/, with the aliases
+// of the OWNERS_ALIASES file at the repo root. Paths are relative to the repo
+// root.
+func loadConfigOwners(repoRoot string) (ownersIndex, error) {
+	files, err := readConfigOwners(repoRoot)
+	if err != nil {
+		return nil, err
+	}
+	return buildOwnersIndex(files)
+}
+
+// readConfigOwners reads the OWNERS and OWNERS_ALIASES files under config/
+// and the OWNERS_ALIASES file at the repo root, keyed by their slash separated
+// path relative to the repo root.
+func readConfigOwners(repoRoot string) (map[string][]byte, error) {
+	files := map[string][]byte{}
+	if err := readOwnersDir(filepath.Join(repoRoot, "config"), files); err != nil {
+		return nil, err
+	}
+	prefixed := map[string][]byte{}
+	for p, buf := range files {
+		prefixed[path.Join("config", p)] = buf
+	}
+	if buf, err := os.ReadFile(filepath.Join(repoRoot, ownersAliasesFileName)); err == nil {
+		prefixed[ownersAliasesFileName] = buf
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+	return prefixed, nil
+}
@@ -0,0 +1,149 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+var testOwnersFiles = map[string]string{
+	"kubernetes/org/OWNERS_ALIASES": `aliases:
+  sig-contribex-leads:
+  - Alice
+  - bob
+`,
+	"kubernetes/org/OWNERS": `approvers:
+- sig-contribex-leads
+reviewers:
+- carol
+- alice
+`,
+	"kubernetes/org/config/OWNERS": `filters:
+  ".*":
+    approvers:
+    - carol
+  "\\.yaml$":
+    reviewers:
+    - SIG-CONTRIBEX-LEADS
+`,
+	// aliases of kubernetes/org don't apply to other repos
+	"kubernetes/kubernetes/OWNERS": `approvers:
+- sig-contribex-leads
+`,
+	"kubernetes/kubernetes/vendor/OWNERS": `approvers:
+- carol
+`,
+}
+
+var expectedOwnersIndex = ownersIndex{
+	"alice": {
+		{File: "kubernetes/org/OWNERS", Role: "approver", Alias: "sig-contribex-leads"},
+		{File: "kubernetes/org/OWNERS", Role: "reviewer"},
+		{File: "kubernetes/org/config/OWNERS", Role: "reviewer", Alias: "SIG-CONTRIBEX-LEADS"},
+	},
+	"bob": {
+		{File: "kubernetes/org/OWNERS", Role: "approver", Alias: "sig-contribex-leads"},
+		{File: "kubernetes/org/config/OWNERS", Role: "reviewer", Alias: "SIG-CONTRIBEX-LEADS"},
+	},
+	"carol": {
+		{File: "kubernetes/org/OWNERS", Role: "reviewer"},
+		{File: "kubernetes/org/config/OWNERS", Role: "approver"},
+	},
+	"sig-contribex-leads": {
+		{File: "kubernetes/kubernetes/OWNERS", Role: "approver"},
+	},
+}
+
+func TestLoadOwnersIndexFromDir(t *testing.T) {
+	root := t.TempDir()
+	for path, contents := range testOwnersFiles {
+		writeFile(t, root, path, contents)
+	}
+
+	idx, err := loadOwnersIndex(root)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(idx, expectedOwnersIndex) {
+		t.Errorf("expected %v, got %v", expectedOwnersIndex, idx)
+	}
+
+	isOwner, entries, err := idx.Owners("CAROL")
+	if err != nil || !isOwner || len(entries) != 2 {
+		t.Errorf("expected CAROL to own 2 files, got %v, %v, %v", isOwner, entries, err)
+	}
+	if isOwner, _, _ := idx.Owners("dave"); isOwner {
+		t.Errorf("expected dave not to be an owner")
+	}
+}
+
+func TestLoadOwnersIndexFromArchive(t *testing.T) {
+	archive := filepath.Join(t.TempDir(), "owners.tar.gz")
+	f, err := os.Create(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	for path, contents := range testOwnersFiles {
+		hdr := &tar.Header{Name: "./" + path, Mode: 0644, Size: int64(len(contents)), Typeflag: tar.TypeReg}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(contents)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, c := range []interface{ Close() error }{tw, gz, f} {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	idx, err := loadOwnersIndex(archive)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(idx, expectedOwnersIndex) {
+		t.Errorf("expected %v, got %v", expectedOwnersIndex, idx)
+	}
+}
+
+func TestFindConfigApprovers(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "OWNERS_ALIASES", "aliases:\n  sig-contribex-leads:\n  - alice\n")
+	writeFile(t, root, "OWNERS", "approvers:\n- dave\n")
+	writeFile(t, root, "config/kubernetes/OWNERS", "approvers:\n- sig-contribex-leads\n- Bob\nreviewers:\n- carol\n")
+	writeFile(t, root, "config/kubernetes/sig-network/OWNERS", "approvers:\n- bob\n")
+
+	approvers, err := findConfigApprovers(root)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := map[string][]string{
+		"alice": {"config/kubernetes/OWNERS"},
+		"bob":   {"config/kubernetes/OWNERS", "config/kubernetes/sig-network/OWNERS"},
+	}
+	if !reflect.DeepEqual(approvers, expected) {
+		t.Errorf("expected %v, got %v", expected, approvers)
+	}
+}
@@ -0,0 +1,418 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/prow/pkg/config/org"
+	"sigs.k8s.io/prow/pkg/github"
+	"sigs.k8s.io/yaml"
+
+	"k8s.io/org/pkg/ghstate"
+	"k8s.io/org/pkg/orgconfig"
+)
+
+// defaultMergedConfig is where "make config" writes the merged config,
+// relative to the root of the repo.
+const defaultMergedConfig = "_output/gen-config.yaml"
+
+// maximumRemovalDelta is the default --maximum-removal-delta of peribolos, the
+// largest fraction of org members it removes in a single run.
+const maximumRemovalDelta = 0.25
+
+type planKind string
+
+const (
+	planInvite           planKind = "invite"
+	planOrgRole          planKind = "org-role"
+	planRemove           planKind = "remove"
+	planCreateTeam       planKind = "create-team"
+	planRenameTeam       planKind = "rename-team"
+	planDeleteTeam       planKind = "delete-team"
+	planUpdateTeam       planKind = "update-team"
+	planAddTeamMember    planKind = "add-team-member"
+	planTeamRole         planKind = "team-role"
+	planRemoveTeamMember planKind = "remove-team-member"
+	planGrantRepo        planKind = "grant-repo"
+	planUpdateRepo       planKind = "update-repo"
+	planRevokeRepo       planKind = "revoke-repo"
+)
+
+// PlanAction is a single change peribolos makes to GitHub.
+type PlanAction struct {
+	Kind planKind
+	Team string
+	// Subject is the login, repo or team setting the change applies to.
+	Subject string
+	// Old and New are the role, permission, setting or team name before and
+	// after the change, if any.
+	Old string
+	New string
+}
+
+// OrgPlan lists the changes peribolos makes to an org, in the order they are
+// printed.
+type OrgPlan struct {
+	Org      string
+	Actions  []PlanAction
+	Warnings []string
+}
+
+// PlanOrgs compares the merged config with a snapshot of the state of the
+// orgs on GitHub and writes the changes peribolos would make to out.
+func PlanOrgs(o Options, out io.Writer) error {
+	configPath := o.PlanConfig
+	if configPath == "" {
+		configPath = filepath.Join(o.RepoRoot, defaultMergedConfig)
+	}
+	cfg, err := loadMergedConfig(configPath)
+	if err != nil {
+		return err
+	}
+	statePath := stateFile(o)
+	state, err := ghstate.Load(statePath)
+	if err != nil {
+		return err
+	}
+
+	orgs := o.Orgs
+	if len(orgs) == 0 {
+		for name := range cfg.Orgs {
+			orgs = append(orgs, name)
+		}
+		sort.Strings(orgs)
+	}
+
+	var plans []OrgPlan
+	for _, name := range orgs {
+		orgCfg, ok := cfg.Orgs[name]
+		if !ok {
+			return fmt.Errorf("org %s is not in %s", name, configPath)
+		}
+		orgState, ok := state.Orgs[name]
+		if !ok {
+			return fmt.Errorf("org %s is not in the state snapshot %s", name, statePath)
+		}
+		if p := planOrg(name, orgCfg, orgState, o.RequiredAdmins); len(p.Actions) > 0 || len(p.Warnings) > 0 {
+			plans = append(plans, p)
+		}
+	}
+	writePlan(out, plans)
+	return nil
+}
+
+func loadMergedConfig(path string) (*org.FullConfig, error) {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read merged config: %v", err)
+	}
+	var cfg org.FullConfig
+	if err := yaml.Unmarshal(buf, &cfg); err != nil {
+		return nil, fmt.Errorf("unmarshal merged config %s: %v", path, err)
+	}
+	return &cfg, nil
+}
+
+// planOrg plans the changes to the org. Like peribolos, it never removes or
+// demotes the required admins, and warns if the config does not list them as
+// admins.
+func planOrg(name string, cfg org.Config, state ghstate.Org, requiredAdmins []string) OrgPlan {
+	p := OrgPlan{Org: name}
+	var missing []string
+	for _, login := range requiredAdmins {
+		if !orgconfig.ContainsLogin(cfg.Admins, login) {
+			missing = append(missing, login)
+		}
+	}
+	if len(missing) > 0 {
+		p.Warnings = append(p.Warnings, fmt.Sprintf("peribolos refuses to update the org, the config must list %s as admins", strings.Join(missing, ", ")))
+	}
+	removals := 0
+	for _, c := range planRoles("admin", cfg.Admins, cfg.Members, state.Admins, state.Members, state.Invitations) {
+		switch {
+		case c.Old == "admin" && c.New != "admin" && orgconfig.ContainsLogin(requiredAdmins, c.Login):
+			continue
+		case c.Old == "":
+			p.Actions = append(p.Actions, PlanAction{Kind: planInvite, Subject: c.Login, New: c.New})
+		case c.New == "":
+			p.Actions = append(p.Actions, PlanAction{Kind: planRemove, Subject: c.Login, Old: c.Old})
+			removals++
+		default:
+			p.Actions = append(p.Actions, PlanAction{Kind: planOrgRole, Subject: c.Login, Old: c.Old, New: c.New})
+		}
+	}
+	if current := len(state.Admins) + len(state.Members); current > 0 && float64(removals)/float64(current) > maximumRemovalDelta {
+		p.Warnings = append(p.Warnings, fmt.Sprintf("peribolos refuses to remove %d of %d members, more than --maximum-removal-delta=%.2f", removals, current, maximumRemovalDelta))
+	}
+	p.Actions = append(p.Actions, planTeams(cfg.Teams, state)...)
+	return p
+}
+
+// plannedTeam is a team of the merged config with the name of its parent.
+type plannedTeam struct {
+	org.Team
+	Name   string
+	Parent string
+}
+
+func flattenTeams(teams map[string]org.Team, parent string) []plannedTeam {
+	var flat []plannedTeam
+	for name, t := range teams {
+		flat = append(flat, plannedTeam{Team: t, Name: name, Parent: parent})
+		flat = append(flat, flattenTeams(t.Children, name)...)
+	}
+	return flat
+}
+
+// planTeams matches configured teams with teams on GitHub by name or by
+// their previous names, like peribolos, and deletes the teams that are not
+// configured.
+func planTeams(teams map[string]org.Team, state ghstate.Org) []PlanAction {
+	configured := flattenTeams(teams, "")
+	sort.Slice(configured, func(i, j int) bool {
+		return configured[i].Name < configured[j].Name
+	})
+	inConfig := map[string]bool{}
+	for _, t := range configured {
+		inConfig[github.NormLogin(t.Name)] = true
+	}
+
+	// current maps the names of the matched teams on GitHub to their names in
+	// the config
+	current := map[string]string{}
+	existing := map[string]ghstate.Team{}
+	var renames []PlanAction
+	for _, t := range configured {
+		if name, s, ok := state.Team(t.Name); ok {
+			current[github.NormLogin(name)] = t.Name
+			existing[t.Name] = s
+			continue
+		}
+		for _, previous := range t.Previously {
+			if inConfig[github.NormLogin(previous)] {
+				continue
+			}
+			if name, s, ok := state.Team(previous); ok {
+				current[github.NormLogin(name)] = t.Name
+				existing[t.Name] = s
+				renames = append(renames, PlanAction{Kind: planRenameTeam, Team: t.Name, Old: name})
+				break
+			}
+		}
+	}
+
+	var actions []PlanAction
+	var deleted []string
+	for name := range state.Teams {
+		if _, ok := current[github.NormLogin(name)]; !ok {
+			deleted = append(deleted, name)
+		}
+	}
+	sort.Strings(deleted)
+	for _, name := range deleted {
+		actions = append(actions, PlanAction{Kind: planDeleteTeam, Team: name})
+	}
+	actions = append(actions, renames...)
+
+	for _, t := range configured {
+		s, ok := existing[t.Name]
+		if !ok {
+			actions = append(actions, PlanAction{Kind: planCreateTeam, Team: t.Name, New: t.Parent})
+		} else {
+			actions = append(actions, planTeamSettings(t, s, current)...)
+		}
+		for _, c := range planRoles("maintainer", t.Maintainers, t.Members, s.Maintainers, s.Members, nil) {
+			switch {
+			case c.Old == "":
+				actions = append(actions, PlanAction{Kind: planAddTeamMember, Team: t.Name, Subject: c.Login, New: c.New})
+			case c.New == "":
+				actions = append(actions, PlanAction{Kind: planRemoveTeamMember, Team: t.Name, Subject: c.Login, Old: c.Old})
+			default:
+				actions = append(actions, PlanAction{Kind: planTeamRole, Team: t.Name, Subject: c.Login, Old: c.Old, New: c.New})
+			}
+		}
+		actions = append(actions, planTeamRepos(t.Name, t.Repos, s.Repos)...)
+	}
+	return actions
+}
+
+func planTeamSettings(t plannedTeam, s ghstate.Team, current map[string]string) []PlanAction {
+	var actions []PlanAction
+	update := func(setting, old, new string) {
+		actions = append(actions, PlanAction{Kind: planUpdateTeam, Team: t.Name, Subject: setting, Old: old, New: new})
+	}
+	if t.Description != nil && *t.Description != s.Description {
+		update("description", s.Description, *t.Description)
+	}
+	if t.Privacy != nil && *t.Privacy != s.Privacy {
+		update("privacy", string(s.Privacy), string(*t.Privacy))
+	}
+	parent := s.Parent
+	if renamed, ok := current[github.NormLogin(parent)]; ok {
+		parent = renamed
+	}
+	if github.NormLogin(parent) != github.NormLogin(t.Parent) {
+		update("parent", s.Parent, t.Parent)
+	}
+	return actions
+}
+
+func planTeamRepos(team string, want, have map[string]github.RepoPermissionLevel) []PlanAction {
+	haveByName := map[string]github.RepoPermissionLevel{}
+	for repo, permission := range have {
+		haveByName[strings.ToLower(repo)] = permission
+	}
+	wantByName := map[string]bool{}
+	var actions []PlanAction
+	for repo, permission := range want {
+		wantByName[strings.ToLower(repo)] = true
+		old, ok := haveByName[strings.ToLower(repo)]
+		switch {
+		case !ok:
+			actions = append(actions, PlanAction{Kind: planGrantRepo, Team: team, Subject: repo, New: string(permission)})
+		case old != permission:
+			actions = append(actions, PlanAction{Kind: planUpdateRepo, Team: team, Subject: repo, Old: string(old), New: string(permission)})
+		}
+	}
+	for repo, permission := range have {
+		if !wantByName[strings.ToLower(repo)] {
+			actions = append(actions, PlanAction{Kind: planRevokeRepo, Team: team, Subject: repo, Old: string(permission)})
+		}
+	}
+	sort.Slice(actions, func(i, j int) bool {
+		return strings.ToLower(actions[i].Subject) < strings.ToLower(actions[j].Subject)
+	})
+	return actions
+}
+
+// roleChange is a login that is added (Old is empty), removed (New is empty)
+// or whose role changes.
+type roleChange struct {
+	Login string
+	Old   string
+	New   string
+}
+
+// planRoles compares the wanted and current logins with the super role
+// (admin or maintainer) and the member role, case-insensitively. Logins with
+// a pending invitation are not invited again.
+func planRoles(super string, wantSuper, wantMembers, haveSuper, haveMembers, invited []string) []roleChange {
+	roles := func(superLogins, members []string) map[string]string {
+		m := map[string]string{}
+		for _, login := range members {
+			m[github.NormLogin(login)] = "member"
+		}
+		for _, login := range superLogins {
+			m[github.NormLogin(login)] = super
+		}
+		return m
+	}
+	want, have := roles(wantSuper, wantMembers), roles(haveSuper, haveMembers)
+	pending := map[string]bool{}
+	for _, login := range invited {
+		pending[github.NormLogin(login)] = true
+	}
+
+	var changes []roleChange
+	done := map[string]bool{}
+	for _, logins := range [][]string{wantSuper, wantMembers} {
+		for _, login := range logins {
+			key := github.NormLogin(login)
+			if done[key] {
+				continue
+			}
+			done[key] = true
+			switch old, ok := have[key]; {
+			case !ok && !pending[key]:
+				changes = append(changes, roleChange{Login: login, New: want[key]})
+			case ok && old != want[key]:
+				changes = append(changes, roleChange{Login: login, Old: old, New: want[key]})
+			}
+		}
+	}
+	for _, logins := range [][]string{haveSuper, haveMembers} {
+		for _, login := range logins {
+			key := github.NormLogin(login)
+			if done[key] {
+				continue
+			}
+			done[key] = true
+			changes = append(changes, roleChange{Login: login, Old: have[key]})
+		}
+	}
+	sort.SliceStable(changes, func(i, j int) bool {
+		return strings.ToLower(changes[i].Login) < strings.ToLower(changes[j].Login)
+	})
+	return changes
+}
+
+func (a PlanAction) String() string {
+	switch a.Kind {
+	case planInvite:
+		return fmt.Sprintf("invite %s to the org as %s", a.Subject, a.New)
+	case planOrgRole:
+		return fmt.Sprintf("change the org role of %s from %s to %s", a.Subject, a.Old, a.New)
+	case planRemove:
+		return fmt.Sprintf("remove %s (%s) from the org", a.Subject, a.Old)
+	case planCreateTeam:
+		if a.New != "" {
+			return fmt.Sprintf("create team %s under %s", a.Team, a.New)
+		}
+		return fmt.Sprintf("create team %s", a.Team)
+	case planRenameTeam:
+		return fmt.Sprintf("rename team %s to %s", a.Old, a.Team)
+	case planDeleteTeam:
+		return fmt.Sprintf("delete team %s", a.Team)
+	case planUpdateTeam:
+		return fmt.Sprintf("update team %s %s", a.Team, SettingChange{Name: a.Subject, Old: a.Old, New: a.New})
+	case planAddTeamMember:
+		return fmt.Sprintf("add %s to team %s as %s", a.Subject, a.Team, a.New)
+	case planTeamRole:
+		return fmt.Sprintf("change the role of %s in team %s from %s to %s", a.Subject, a.Team, a.Old, a.New)
+	case planRemoveTeamMember:
+		return fmt.Sprintf("remove %s (%s) from team %s", a.Subject, a.Old, a.Team)
+	case planGrantRepo:
+		return fmt.Sprintf("grant team %s %s on repo %s", a.Team, a.New, a.Subject)
+	case planUpdateRepo:
+		return fmt.Sprintf("change the permission of team %s on repo %s from %s to %s", a.Team, a.Subject, a.Old, a.New)
+	case planRevokeRepo:
+		return fmt.Sprintf("revoke the %s permission of team %s on repo %s", a.Old, a.Team, a.Subject)
+	}
+	return string(a.Kind)
+}
+
+func writePlan(w io.Writer, plans []OrgPlan) {
+	if len(plans) == 0 {
+		fmt.Fprintln(w, "no changes")
+		return
+	}
+	for _, p := range plans {
+		fmt.Fprintf(w, "%s (%d changes):\n", p.Org, len(p.Actions))
+		for _, a := range p.Actions {
+			fmt.Fprintf(w, "  %s\n", a)
+		}
+		for _, warning := range p.Warnings {
+			fmt.Fprintf(w, "  warning: %s\n", warning)
+		}
+	}
+}
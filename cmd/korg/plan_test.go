@@ -0,0 +1,133 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPlanOrgs(t *testing.T) {
+	dir := t.TempDir()
+	config := filepath.Join(dir, "gen-config.yaml")
+	if err := os.WriteFile(config, []byte(`orgs:
+  kubernetes:
+    admins: [cblecker]
+    members: [Alice, bob, dave]
+    teams:
+      sig-foo:
+        description: SIG Foo
+        privacy: secret
+        maintainers: [cblecker]
+        members: [alice, bob]
+        repos:
+          foo: admin
+          bar: read
+        teams:
+          sig-foo-leads:
+            previously: [foo-leads]
+            members: [bob]
+      sig-new:
+        members: [dave]
+        repos:
+          new: write
+`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	state := filepath.Join(dir, "state.json")
+	if err := os.WriteFile(state, []byte(`{"orgs": {"kubernetes": {
+  "admins": ["cblecker", "bob"],
+  "members": ["alice", "erin"],
+  "invitations": ["dave"],
+  "teams": {
+    "sig-foo": {
+      "description": "SIG Foo",
+      "privacy": "closed",
+      "maintainers": ["cblecker", "alice"],
+      "members": ["erin"],
+      "repos": {"foo": "write", "baz": "read", "Bar": "read"}
+    },
+    "foo-leads": {"members": ["bob"]},
+    "sig-unused": {}
+  }
+}}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `kubernetes (14 changes):
+  change the org role of bob from admin to member
+  remove erin (member) from the org
+  delete team sig-unused
+  rename team foo-leads to sig-foo-leads
+  update team sig-foo privacy: closed -> secret
+  change the role of alice in team sig-foo from maintainer to member
+  add bob to team sig-foo as member
+  remove erin (member) from team sig-foo
+  revoke the read permission of team sig-foo on repo baz
+  change the permission of team sig-foo on repo foo from write to admin
+  update team sig-foo-leads parent: (unset) -> sig-foo
+  create team sig-new
+  add dave to team sig-new as member
+  grant team sig-new write on repo new
+`
+	var out bytes.Buffer
+	if err := PlanOrgs(Options{State: state, PlanConfig: config}, &out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out.String() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, out.String())
+	}
+
+	out.Reset()
+	if err := PlanOrgs(Options{State: state, PlanConfig: config, Orgs: []string{"kubernetes-sigs"}}, &out); err == nil {
+		t.Errorf("expected an error for an org missing from the config")
+	}
+}
+
+func TestPlanOrgsRequiredAdmins(t *testing.T) {
+	dir := t.TempDir()
+	config := filepath.Join(dir, "gen-config.yaml")
+	if err := os.WriteFile(config, []byte(`orgs:
+  kubernetes:
+    admins: [cblecker]
+    members: [alice]
+`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	state := filepath.Join(dir, "state.json")
+	if err := os.WriteFile(state, []byte(`{"orgs": {"kubernetes": {
+  "admins": ["cblecker", "k8s-ci-robot", "nikhita", "bob"],
+  "members": ["alice"]
+}}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `kubernetes (1 changes):
+  remove bob (admin) from the org
+  warning: peribolos refuses to update the org, the config must list K8s-CI-Robot, nikhita as admins
+`
+	var out bytes.Buffer
+	o := Options{State: state, PlanConfig: config, RequiredAdmins: []string{"cblecker", "K8s-CI-Robot", "nikhita"}}
+	if err := PlanOrgs(o, &out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out.String() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, out.String())
+	}
+}
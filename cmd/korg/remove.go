@@ -18,62 +18,105 @@ package main
 
 import (
 	"fmt"
-	"path/filepath"
 	"strings"
+
+	"k8s.io/org/pkg/orgconfig"
 )
 
 func RemoveMemberFromOrgs(o Options, username string) error {
-	if invalidOrgs := findInvalidOrgs(o.Orgs); len(invalidOrgs) > 0 {
-		return fmt.Errorf("specified invalid orgs: %s", strings.Join(invalidOrgs, ", "))
+	if err := validateOrgs(o.RepoRoot, o.Orgs, false); err != nil {
+		return err
+	}
+
+	teams, err := parseTeamRefs(o.Teams)
+	if err != nil {
+		return err
+	}
+	if len(teams) > 0 {
+		return removeMemberFromTeamsOnly(o, username, teams)
 	}
 
 	if !o.Confirm {
 		fmt.Println("!!! running in dry-run mode. pass --confirm to persist changes.")
 	}
 
-	configsModified := []string{}
+	// every org is checked before any config file is written
+	repo := &orgconfig.Repo{Root: o.RepoRoot, AllowOverride: o.AllowOverride}
+	var changes orgconfig.Changes
 	for _, org := range o.Orgs {
 		fmt.Printf("removing %s from %s org\n", username, org)
 
-		relativeConfigPath := fmt.Sprintf(orgConfigPathFormat, org)
-		configPath := filepath.Join(o.RepoRoot, relativeConfigPath)
-
-		config, err := readConfig(configPath)
+		orgCfg, err := repo.LoadOrg(org)
 		if err != nil {
 			return fmt.Errorf("reading config: %s", err)
 		}
 
-		if stringInSliceCaseAgnostic(config.Admins, username) {
+		if orgCfg.IsAdmin(username) {
 			return fmt.Errorf("user %s is an admin for org %s", username, org)
 		}
 
-		if !stringInSliceCaseAgnostic(config.Members, username) {
+		e, removed := orgCfg.RemoveMember(username)
+		if !removed {
 			return fmt.Errorf("user %s doesn't exist in org %s", username, org)
 		}
+		changes.Add(e)
+
+		// teams may only list org members, so the user leaves their teams too
+		if err := removeFromAllTeams(&changes, orgCfg, username); err != nil {
+			return err
+		}
+	}
+	configsModified := changes.Files()
+	fmt.Printf("config files modified: %s\n", strings.Join(configsModified, ", "))
 
-		// remove user from the org
-		for i, member := range config.Members {
-			if strings.EqualFold(username, member) {
-				config.Members = append(config.Members[:i], config.Members[i+1:]...)
-				break
-			}
+	if o.Confirm {
+		if err := writeChanges(o, &changes); err != nil {
+			return err
 		}
 
-		if o.Confirm {
-			fmt.Printf("saving config for %s org\n", org)
-			if err := saveConfig(configPath, config); err != nil {
-				return fmt.Errorf("saving config: %s", err)
-			}
+		fmt.Println("committing changes")
+
+		message := fmt.Sprintf("remove %s from %s", username, strings.Join(o.Orgs, ", "))
+		if err := commitChanges(o.RepoRoot, configsModified, message); err != nil {
+			return fmt.Errorf("committing changes: %s", err)
 		}
+	}
+	return nil
+}
+
+// removeMemberFromTeamsOnly removes username from the given teams while
+// keeping their org membership.
+func removeMemberFromTeamsOnly(o Options, username string, teams []teamRef) error {
+	if len(o.Orgs) != 1 {
+		return fmt.Errorf("teams can only be specified for a single org")
+	}
+
+	if !o.Confirm {
+		fmt.Println("!!! running in dry-run mode. pass --confirm to persist changes.")
+	}
+
+	org := o.Orgs[0]
+	repo := &orgconfig.Repo{Root: o.RepoRoot, AllowOverride: o.AllowOverride}
+	orgCfg, err := repo.LoadOrg(org)
+	if err != nil {
+		return fmt.Errorf("reading config: %s", err)
+	}
 
-		configsModified = append(configsModified, relativeConfigPath)
-		fmt.Printf("config files modified: %s\n", strings.Join(configsModified, ", "))
+	var changes orgconfig.Changes
+	if err := RemoveMemberFromTeams(&changes, orgCfg, username, teams); err != nil {
+		return err
 	}
+	configsModified := changes.Files()
+	fmt.Printf("config files modified: %s\n", strings.Join(configsModified, ", "))
 
 	if o.Confirm {
+		if err := writeChanges(o, &changes); err != nil {
+			return err
+		}
+
 		fmt.Println("committing changes")
 
-		message := fmt.Sprintf("remove %s from %s", username, strings.Join(o.Orgs, ", "))
+		message := fmt.Sprintf("remove %s from %s teams %s", username, org, strings.Join(o.Teams, ", "))
 		if err := commitChanges(o.RepoRoot, configsModified, message); err != nil {
 			return fmt.Errorf("committing changes: %s", err)
 		}
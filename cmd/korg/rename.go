@@ -0,0 +1,155 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+
+	"k8s.io/org/pkg/orgconfig"
+)
+
+// ownersLoginKeys are the lists of OWNERS files holding GitHub logins, either
+// at the top level or under filters.
+var ownersLoginKeys = []string{"approvers", "reviewers", "emeritus_approvers", "emeritus_reviewers"}
+
+// RenameUser replaces every occurrence of oldName, compared
+// case-insensitively, in the org and team configs, in the OWNERS files under
+// config/ and in OWNERS_ALIASES with newName in a single commit.
+func RenameUser(o Options, oldName, newName string) error {
+	edits, err := findLoginLists(o, oldName)
+	if err != nil {
+		return err
+	}
+	if len(edits) == 0 {
+		return fmt.Errorf("user %s not found", oldName)
+	}
+	// a change of case only renames the user in place
+	if !strings.EqualFold(oldName, newName) {
+		existing, err := findLoginLists(o, newName)
+		if err != nil {
+			return err
+		}
+		if len(existing) > 0 {
+			var files []string
+			for _, e := range existing {
+				if !slices.Contains(files, e.File) {
+					files = append(files, e.File)
+				}
+			}
+			return fmt.Errorf("user %s already exists in %s", newName, strings.Join(files, ", "))
+		}
+	}
+
+	if !o.Confirm {
+		fmt.Println("!!! running in dry-run mode. pass --confirm to persist changes.")
+	}
+
+	var changes orgconfig.Changes
+	for _, e := range edits {
+		fmt.Printf("renaming %s to %s in %s (%s)\n", oldName, newName, e.File, strings.Join(e.Path, "."))
+		e.Rename = newName
+		changes.Add(e)
+	}
+	configsModified := changes.Files()
+	fmt.Printf("config files modified: %s\n", strings.Join(configsModified, ", "))
+
+	if o.Confirm {
+		repo := &orgconfig.Repo{Root: o.RepoRoot}
+		if err := repo.Write(&changes); err != nil {
+			return fmt.Errorf("saving config: %s", err)
+		}
+
+		fmt.Println("committing changes")
+		message := fmt.Sprintf("rename %s to %s", oldName, newName)
+		if err := commitChanges(o.RepoRoot, configsModified, message); err != nil {
+			return fmt.Errorf("committing changes: %s", err)
+		}
+	}
+	return nil
+}
+
+// findLoginLists returns an edit locating each list of the orgs, their teams
+// and the OWNERS files that contains login.
+func findLoginLists(o Options, login string) ([]orgconfig.Edit, error) {
+	repo := &orgconfig.Repo{Root: o.RepoRoot, AllowOverride: o.AllowOverride}
+	orgs, err := repo.OrgNames()
+	if err != nil {
+		return nil, err
+	}
+
+	var edits []orgconfig.Edit
+	for _, name := range orgs {
+		orgCfg, err := repo.LoadOrg(name)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range orgCfg.Memberships(login) {
+			edits = append(edits, orgconfig.Edit{File: m.File, Path: m.Path, Value: login})
+		}
+	}
+
+	files, err := readConfigOwners(o.RepoRoot)
+	if err != nil {
+		return nil, fmt.Errorf("reading OWNERS files: %s", err)
+	}
+
+	var paths []string
+	for p := range files {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	for _, p := range paths {
+		doc, err := orgconfig.ParseDocument(files[p])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", p, err)
+		}
+		for _, listPath := range doc.ListsContaining(login) {
+			if isOwnersLoginList(path.Base(p), listPath) {
+				edits = append(edits, orgconfig.Edit{File: p, Path: listPath, Value: login})
+			}
+		}
+	}
+	return edits, nil
+}
+
+// isOwnersLoginList reports whether the list at listPath of the OWNERS or
+// OWNERS_ALIASES file named name holds GitHub logins, as opposed to e.g.
+// labels.
+func isOwnersLoginList(name string, listPath []string) bool {
+	if name == ownersAliasesFileName {
+		return len(listPath) == 2 && listPath[0] == "aliases"
+	}
+	switch len(listPath) {
+	case 1:
+	case 3:
+		if listPath[0] != "filters" {
+			return false
+		}
+	default:
+		return false
+	}
+	for _, key := range ownersLoginKeys {
+		if listPath[len(listPath)-1] == key {
+			return true
+		}
+	}
+	return false
+}
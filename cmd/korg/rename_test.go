@@ -0,0 +1,146 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+)
+
+func setupRenameRepoRoot(t *testing.T) string {
+	t.Helper()
+	root := setupRepoRoot(t)
+	writeFile(t, root, "config/kubernetes/OWNERS", `approvers:
+- cblecker
+- thockin
+emeritus_approvers:
+- aojea # retired
+labels:
+- aojea
+filters:
+  ".*":
+    reviewers:
+    - Aojea
+`)
+	writeFile(t, root, "OWNERS_ALIASES", `aliases:
+  sig-network-leads:
+    - aojea
+    - thockin
+`)
+	return root
+}
+
+func TestRenameUser(t *testing.T) {
+	root := setupRenameRepoRoot(t)
+	o := Options{Confirm: true, RepoRoot: root}
+	if err := RenameUser(o, "AOJEA", "zaojea"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := map[string]string{
+		"config/kubernetes/org.yaml": `members:
+- 08volt
+- "249043822"
+- Bob
+- zaojea
+- zed
+name: Kubernetes
+`,
+		"config/kubernetes/sig-network/teams.yaml": `    members:
+    # Tech lead
+    - thockin
+    - zaojea # Testing / Network
+    privacy: closed
+`,
+		"config/kubernetes/OWNERS": `approvers:
+- cblecker
+- thockin
+emeritus_approvers:
+- zaojea # retired
+labels:
+- aojea
+filters:
+  ".*":
+    reviewers:
+    - zaojea
+`,
+		"OWNERS_ALIASES": `    - thockin
+    - zaojea
+`,
+	}
+	for file, want := range expected {
+		if got := string(mustReadFile(t, filepath.Join(root, file))); !strings.Contains(got, want) {
+			t.Errorf("expected %s to contain:\n%s\ngot:\n%s", file, want, got)
+		}
+	}
+
+	r, err := git.PlainOpen(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	log, err := r.Log(&git.LogOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var messages []string
+	for {
+		c, err := log.Next()
+		if err != nil {
+			break
+		}
+		messages = append(messages, c.Message)
+	}
+	if want := []string{"rename AOJEA to zaojea"}; !reflect.DeepEqual(messages, want) {
+		t.Errorf("expected commits %q, got %q", want, messages)
+	}
+}
+
+func TestRenameUserRefusesExistingLogin(t *testing.T) {
+	for _, newName := range []string{"Thockin", "cblecker"} {
+		root := setupRenameRepoRoot(t)
+		o := Options{Confirm: true, RepoRoot: root}
+		before := mustReadFile(t, filepath.Join(root, "config/kubernetes/org.yaml"))
+		err := RenameUser(o, "aojea", newName)
+		if err == nil || !strings.Contains(err.Error(), "already exists") {
+			t.Errorf("renaming to %s: expected an error about the existing login, got %v", newName, err)
+		}
+		if got := mustReadFile(t, filepath.Join(root, "config/kubernetes/org.yaml")); string(got) != string(before) {
+			t.Errorf("renaming to %s: expected org.yaml to be left alone, got:\n%s", newName, got)
+		}
+	}
+}
+
+func TestRenameUserNotFound(t *testing.T) {
+	root := setupRenameRepoRoot(t)
+	if err := RenameUser(Options{Confirm: true, RepoRoot: root}, "nobody", "somebody"); err == nil {
+		t.Errorf("expected an error renaming a missing user")
+	}
+}
+
+func TestRenameUserChangesCase(t *testing.T) {
+	root := setupRenameRepoRoot(t)
+	if err := RenameUser(Options{Confirm: true, RepoRoot: root}, "thockin", "THockin"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := string(mustReadFile(t, filepath.Join(root, "OWNERS_ALIASES"))); !strings.Contains(got, "- THockin") {
+		t.Errorf("expected the login to be renamed in place, got:\n%s", got)
+	}
+}
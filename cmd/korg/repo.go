@@ -0,0 +1,150 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"sigs.k8s.io/prow/pkg/github"
+
+	"k8s.io/org/pkg/orgconfig"
+	"k8s.io/org/pkg/restrictions"
+)
+
+// GrantRepo sets the permission of the team referenced by ref on repo to
+// o.Permission. The repo must be allowed by the restriction matching the file
+// defining the team if it is a top-level team.
+func GrantRepo(o Options, ref, repoName string) error {
+	orgName, tr, err := parseOrgTeamRef(ref)
+	if err != nil {
+		return err
+	}
+	if o.Permission == "" {
+		return fmt.Errorf("--permission is required")
+	}
+	var permission github.RepoPermissionLevel
+	if err := permission.UnmarshalText([]byte(o.Permission)); err != nil || permission == github.None {
+		return fmt.Errorf("invalid permission %q, must be one of: read, triage, write, maintain, admin", o.Permission)
+	}
+	if repoName == "" || strings.Contains(repoName, "/") {
+		return fmt.Errorf("invalid repo %q, must be the name of a repo of org %s", repoName, orgName)
+	}
+	_, t, err := loadTeam(o, orgName, tr)
+	if err != nil {
+		return err
+	}
+
+	// keep the spelling of a repo the team already has access to
+	for name, current := range t.Repos {
+		if !strings.EqualFold(name, repoName) {
+			continue
+		}
+		if current == permission {
+			return fmt.Errorf("team %s already has %s permission on %s", t.FullName(), permission, name)
+		}
+		repoName = name
+	}
+
+	// like cmd/restrictions, only the repos of top-level teams are restricted
+	file := filepath.ToSlash(t.File)
+	if len(t.Parents) == 0 {
+		if err := checkRepoRestriction(o, file, repoName); err != nil {
+			return err
+		}
+	}
+
+	if !o.Confirm {
+		fmt.Println("!!! running in dry-run mode. pass --confirm to persist changes.")
+	}
+	fmt.Printf("granting team %s %s permission on %s in %s\n", t.FullName(), permission, repoName, file)
+
+	c := &stagedFiles{root: o.RepoRoot}
+	err = c.edit(file, func(doc *orgconfig.Document) error {
+		_, err := doc.SetValue(string(permission), append(append([]string{}, t.Path...), "repos", repoName)...)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if err := c.validate(o, orgName); err != nil {
+		return err
+	}
+	return c.apply(o, fmt.Sprintf("grant %s/%s %s on %s", orgName, t.FullName(), permission, repoName))
+}
+
+// RevokeRepo removes repo from the repos of the team referenced by ref.
+// Access the team inherits from its parent teams is not changed.
+func RevokeRepo(o Options, ref, repoName string) error {
+	orgName, tr, err := parseOrgTeamRef(ref)
+	if err != nil {
+		return err
+	}
+	_, t, err := loadTeam(o, orgName, tr)
+	if err != nil {
+		return err
+	}
+	var found bool
+	for name := range t.Repos {
+		if strings.EqualFold(name, repoName) {
+			repoName, found = name, true
+		}
+	}
+	if !found {
+		return fmt.Errorf("team %s does not list repo %s", t.FullName(), repoName)
+	}
+
+	file := filepath.ToSlash(t.File)
+	if !o.Confirm {
+		fmt.Println("!!! running in dry-run mode. pass --confirm to persist changes.")
+	}
+	fmt.Printf("revoking the %s permission of team %s on %s in %s\n", t.Repos[repoName], t.FullName(), repoName, file)
+
+	c := &stagedFiles{root: o.RepoRoot}
+	err = c.edit(file, func(doc *orgconfig.Document) error {
+		_, _, err := doc.RemoveKey(append(append([]string{}, t.Path...), "repos", repoName)...)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if err := c.validate(o, orgName); err != nil {
+		return err
+	}
+	return c.apply(o, fmt.Sprintf("revoke %s/%s access to %s", orgName, t.FullName(), repoName))
+}
+
+// checkRepoRestriction returns an error if the restrictions config doesn't
+// allow the teams defined in file, relative to the repo root, to be granted
+// access to repoName.
+func checkRepoRestriction(o Options, file, repoName string) error {
+	restrictionsPath := filepath.Join(o.RepoRoot, restrictionsConfigPath)
+	if _, err := os.Stat(restrictionsPath); os.IsNotExist(err) {
+		return nil
+	}
+	cfg, err := restrictions.Load(restrictionsPath)
+	if err != nil {
+		return err
+	}
+	r := restrictions.For(cfg.Restrictions, strings.TrimPrefix(file, "config/"))
+	if !r.Allows(repoName) {
+		return fmt.Errorf("repo %q is not allowed for teams defined in %s by the restriction on %q in %s", repoName, file, r.Path, restrictionsConfigPath)
+	}
+	return nil
+}
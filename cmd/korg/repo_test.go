@@ -0,0 +1,126 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestGrantRepo(t *testing.T) {
+	root := setupRepoRoot(t)
+	writeFile(t, root, "config/restrictions.yaml", `restrictions:
+  - path: "kubernetes/sig-network/teams.yaml"
+    allowedRepos:
+    - "^ingress"
+    - "^community$"
+`)
+	o := Options{Confirm: true, RepoRoot: root}
+
+	grants := []struct {
+		team, repo, permission string
+	}{
+		{team: "kubernetes/sig-network/sig-network-leads", repo: "community", permission: "read"},
+		{team: "kubernetes/sig-network/sig-network-leads", repo: "Ingress-GCE", permission: "write"},
+		{team: "kubernetes/sig-network/sig-network-reviewers", repo: "ingress-nginx", permission: "triage"},
+	}
+	for _, g := range grants {
+		o.Permission = g.permission
+		if err := GrantRepo(o, g.team, g.repo); err != nil {
+			t.Fatalf("unexpected error granting %s on %s: %v", g.permission, g.repo, err)
+		}
+	}
+
+	refused := []struct {
+		name, repo, permission, err string
+	}{
+		{name: "invalid permission", repo: "ingress-gce", permission: "pull", err: `invalid permission "pull"`},
+		{name: "none permission", repo: "ingress-gce", permission: "none", err: `invalid permission "none"`},
+		{name: "same permission", repo: "ingress-gce", permission: "write", err: "already has write permission"},
+		{name: "restricted repo", repo: "website", permission: "read", err: `repo "website" is not allowed`},
+		{name: "repo of another org", repo: "kubernetes-sigs/ingress-foo", permission: "read", err: "invalid repo"},
+	}
+	for _, c := range refused {
+		o.Permission = c.permission
+		if err := GrantRepo(o, "kubernetes/sig-network/sig-network-leads", c.repo); err == nil || !strings.Contains(err.Error(), c.err) {
+			t.Errorf("%s: expected an error containing %q, got %v", c.name, c.err, err)
+		}
+	}
+
+	expected := `teams:
+  # Network owners
+  sig-network-leads:
+    description: SIG Network leads
+    maintainers:
+    - cblecker
+    members:
+    - aojea # Testing / Network
+    # Tech lead
+    - thockin
+    privacy: closed
+    repos:
+      community: read
+      ingress-gce: write
+  sig-network-reviewers:
+    description: ""
+    members: []
+    privacy: closed
+    repos:
+      ingress-nginx: triage
+  sig-network-solo:
+    description: |
+      Multi-line
+      description
+    members:
+      - shaneutt
+    privacy: closed
+`
+	if got := string(mustReadFile(t, filepath.Join(root, "config/kubernetes/sig-network/teams.yaml"))); got != expected {
+		t.Errorf("expected teams.yaml:\n%s\ngot:\n%s", expected, got)
+	}
+	expectedMessages := []string{
+		"grant kubernetes/sig-network-leads read on community",
+		"grant kubernetes/sig-network-leads write on ingress-gce",
+		"grant kubernetes/sig-network-reviewers triage on ingress-nginx",
+	}
+	if got := commitMessages(t, root); !reflect.DeepEqual(got, expectedMessages) {
+		t.Errorf("expected commits %q, got %q", expectedMessages, got)
+	}
+}
+
+func TestRevokeRepo(t *testing.T) {
+	root := setupRepoRoot(t)
+	o := Options{Confirm: true, RepoRoot: root}
+
+	if err := RevokeRepo(o, "kubernetes/sig-network/sig-network-reviewers", "ingress-gce"); err == nil || !strings.Contains(err.Error(), "does not list repo") {
+		t.Errorf("expected an error revoking a repo the team doesn't list, got %v", err)
+	}
+	if err := RevokeRepo(o, "kubernetes/sig-network/sig-network-leads", "INGRESS-GCE"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got := string(mustReadFile(t, filepath.Join(root, "config/kubernetes/sig-network/teams.yaml")))
+	if strings.Contains(got, "repos:") || strings.Contains(got, "ingress-gce") {
+		t.Errorf("expected ingress-gce and the empty repos mapping to be removed, got:\n%s", got)
+	}
+	expectedMessages := []string{"revoke kubernetes/sig-network-leads access to ingress-gce"}
+	if got := commitMessages(t, root); !reflect.DeepEqual(got, expectedMessages) {
+		t.Errorf("expected commits %q, got %q", expectedMessages, got)
+	}
+}
@@ -0,0 +1,125 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/prow/pkg/config/org"
+	"sigs.k8s.io/yaml"
+
+	"k8s.io/org/pkg/ghstate"
+)
+
+const (
+	// defaultStateFile is where "korg snapshot" writes the state snapshot and
+	// "korg plan" reads it from, relative to the root of the repo.
+	defaultStateFile = "_output/github-state.json"
+	// defaultDumpDir is where "korg snapshot" writes the state as configs,
+	// relative to the root of the repo.
+	defaultDumpDir = "_output/snapshot"
+)
+
+func stateFile(o Options) string {
+	if o.State != "" {
+		return o.State
+	}
+	return filepath.Join(o.RepoRoot, defaultStateFile)
+}
+
+// newGitHubClient reads the token from --github-token-path or
+// $GITHUB_TOKEN.
+func newGitHubClient(o Options) (*ghstate.Client, error) {
+	token := os.Getenv("GITHUB_TOKEN")
+	if o.GitHubTokenPath != "" {
+		buf, err := os.ReadFile(o.GitHubTokenPath)
+		if err != nil {
+			return nil, fmt.Errorf("reading GitHub token: %s", err)
+		}
+		token = strings.TrimSpace(string(buf))
+	}
+	return &ghstate.Client{Endpoint: o.GitHubEndpoint, Token: token}, nil
+}
+
+// SnapshotOrgs fetches the state of the orgs from GitHub and writes it as a
+// JSON snapshot and as config files under <dump-dir>/<org>/.
+func SnapshotOrgs(o Options, out io.Writer) error {
+	client, err := newGitHubClient(o)
+	if err != nil {
+		return err
+	}
+	state, err := client.Snapshot(o.Orgs)
+	if err != nil {
+		return err
+	}
+
+	path := stateFile(o)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	if err := state.Save(path); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "wrote %s\n", path)
+
+	dumpDir := o.DumpDir
+	if dumpDir == "" {
+		dumpDir = filepath.Join(o.RepoRoot, defaultDumpDir)
+	}
+	for _, name := range o.Orgs {
+		files, err := dumpOrg(filepath.Join(dumpDir, name), state.Orgs[name].Config())
+		if err != nil {
+			return fmt.Errorf("writing configs of %s: %s", name, err)
+		}
+		for _, f := range files {
+			fmt.Fprintf(out, "wrote %s\n", f)
+		}
+	}
+	return nil
+}
+
+// dumpOrg writes the org members to org.yaml and the teams to teams.yaml in
+// dir and returns the files written.
+func dumpOrg(dir string, cfg org.Config) ([]string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	teams := struct {
+		Teams map[string]org.Team `json:"teams"`
+	}{cfg.Teams}
+	cfg.Teams = nil
+
+	var files []string
+	for name, v := range map[string]interface{}{"org.yaml": cfg, "teams.yaml": teams} {
+		buf, err := yaml.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, buf, 0644); err != nil {
+			return nil, err
+		}
+		files = append(files, path)
+	}
+	sort.Strings(files)
+	return files, nil
+}
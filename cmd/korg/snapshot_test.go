@@ -0,0 +1,101 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSnapshotOrgs(t *testing.T) {
+	responses := map[string]string{
+		"/orgs/kubernetes/members?per_page=100&role=admin":                    `[{"login": "cblecker"}]`,
+		"/orgs/kubernetes/members?per_page=100&role=member":                   `[{"login": "alice"}]`,
+		"/orgs/kubernetes/invitations?per_page=100":                           `[{"login": "bob"}]`,
+		"/orgs/kubernetes/teams?per_page=100":                                 `[{"name": "sig-foo", "slug": "sig-foo", "privacy": "closed"}]`,
+		"/orgs/kubernetes/teams/sig-foo/members?per_page=100&role=maintainer": `[{"login": "cblecker"}]`,
+		"/orgs/kubernetes/teams/sig-foo/members?per_page=100&role=member":     `[]`,
+		"/orgs/kubernetes/teams/sig-foo/repos?per_page=100":                   `[{"name": "foo", "role_name": "admin"}]`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := responses[r.URL.RequestURI()]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprint(w, body)
+	}))
+	defer server.Close()
+
+	root := t.TempDir()
+	o := Options{RepoRoot: root, Orgs: []string{"kubernetes"}, GitHubEndpoint: server.URL}
+	var out bytes.Buffer
+	if err := SnapshotOrgs(o, &out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expectedOut := fmt.Sprintf("wrote %s\nwrote %s\nwrote %s\n",
+		filepath.Join(root, defaultStateFile),
+		filepath.Join(root, defaultDumpDir, "kubernetes", "org.yaml"),
+		filepath.Join(root, defaultDumpDir, "kubernetes", "teams.yaml"))
+	if out.String() != expectedOut {
+		t.Errorf("expected output:\n%s\ngot:\n%s", expectedOut, out.String())
+	}
+	teams, err := os.ReadFile(filepath.Join(root, defaultDumpDir, "kubernetes", "teams.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedTeams := `teams:
+  sig-foo:
+    maintainers:
+    - cblecker
+    privacy: closed
+    repos:
+      foo: admin
+`
+	if string(teams) != expectedTeams {
+		t.Errorf("expected teams.yaml:\n%s\ngot:\n%s", expectedTeams, teams)
+	}
+
+	// the snapshot is the default state of korg plan
+	config := filepath.Join(root, defaultMergedConfig)
+	if err := os.WriteFile(config, []byte(`orgs:
+  kubernetes:
+    admins: [cblecker]
+    members: [alice, bob]
+    teams:
+      sig-foo:
+        maintainers: [cblecker]
+        privacy: closed
+        repos:
+          foo: admin
+`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	out.Reset()
+	if err := PlanOrgs(Options{RepoRoot: root}, &out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out.String() != "no changes\n" {
+		t.Errorf("expected no changes, got:\n%s", out.String())
+	}
+}
@@ -0,0 +1,143 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/prow/pkg/config/org"
+	"sigs.k8s.io/yaml"
+
+	"k8s.io/org/pkg/orgconfig"
+)
+
+// requiredSponsors is the number of sponsors a new org member needs, see
+// https://github.com/kubernetes/community/blob/master/community-membership.md
+const requiredSponsors = 2
+
+var affiliationsConfigPath = "config/affiliations.yaml"
+
+// AffiliationsConfig lists the logins of the people sharing an affiliation,
+// e.g. an employer, keyed by the name of the affiliation. The sponsors of a
+// new member must not share one.
+type AffiliationsConfig struct {
+	Affiliations map[string][]string `json:"affiliations"`
+}
+
+// loadAffiliations returns the affiliation of every login listed in the
+// affiliations config, keyed by lowercase login. A missing config lists no
+// affiliations.
+func loadAffiliations(repoRoot string) (map[string]string, error) {
+	buf, err := os.ReadFile(filepath.Join(repoRoot, affiliationsConfigPath))
+	if os.IsNotExist(err) {
+		return map[string]string{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading affiliations config: %s", err)
+	}
+	var cfg AffiliationsConfig
+	if err := yaml.Unmarshal(buf, &cfg, yaml.DisallowUnknownFields); err != nil {
+		return nil, fmt.Errorf("parsing %s: %s", affiliationsConfigPath, err)
+	}
+
+	names := make([]string, 0, len(cfg.Affiliations))
+	for name := range cfg.Affiliations {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	affiliations := map[string]string{}
+	for _, name := range names {
+		for _, login := range cfg.Affiliations[name] {
+			key := strings.ToLower(login)
+			if other, ok := affiliations[key]; ok {
+				return nil, fmt.Errorf("%s: %s is listed in both %s and %s", affiliationsConfigPath, login, other, name)
+			}
+			affiliations[key] = name
+		}
+	}
+	return affiliations, nil
+}
+
+// sponsorProblems returns the reasons sponsors don't qualify username for
+// membership of the org configured in config: every sponsor must be a member
+// or admin of the org other than username, no two sponsors may share an
+// affiliation, and there must be at least requiredSponsors of them.
+func sponsorProblems(username, orgName string, config *org.Config, sponsors []string, affiliations map[string]string) []string {
+	var problems, qualified []string
+	sponsorOf := map[string]string{}
+	for _, sponsor := range sponsors {
+		switch {
+		case strings.EqualFold(sponsor, username):
+			problems = append(problems, fmt.Sprintf("%s cannot sponsor themselves", sponsor))
+			continue
+		case orgconfig.ContainsLogin(qualified, sponsor):
+			continue
+		case !orgconfig.ContainsLogin(config.Members, sponsor) && !orgconfig.ContainsLogin(config.Admins, sponsor):
+			problems = append(problems, fmt.Sprintf("sponsor %s is not a member of %s org", sponsor, orgName))
+			continue
+		}
+		if affiliation, ok := affiliations[strings.ToLower(sponsor)]; ok {
+			if other, ok := sponsorOf[affiliation]; ok {
+				problems = append(problems, fmt.Sprintf("sponsors %s and %s are both affiliated with %s", other, sponsor, affiliation))
+				continue
+			}
+			sponsorOf[affiliation] = sponsor
+		}
+		qualified = append(qualified, sponsor)
+	}
+	if len(qualified) < requiredSponsors {
+		problems = append(problems, fmt.Sprintf("%s needs %d sponsors who are members of %s org, got %d", username, requiredSponsors, orgName, len(qualified)))
+	}
+	return problems
+}
+
+// verifySponsors checks the sponsors of username for the org configured in
+// config. It returns an error if they don't qualify, unless an override
+// reason is given, in which case it returns true.
+func verifySponsors(o Options, username, orgName string, config *org.Config, affiliations map[string]string) (bool, error) {
+	problems := sponsorProblems(username, orgName, config, o.Sponsors, affiliations)
+	if len(problems) == 0 {
+		return false, nil
+	}
+	if o.SponsorOverride == "" {
+		return false, fmt.Errorf("refusing to add %s to %s org:\n  %s\npass --sponsor-override with a reason to add them anyway", username, orgName, strings.Join(problems, "\n  "))
+	}
+	fmt.Printf("overriding the sponsor check for %s org: %s\n", orgName, strings.Join(problems, "; "))
+	return true, nil
+}
+
+// sponsorTrailers returns the git trailers recording the sponsors of a new
+// member, and the reason for overriding the sponsor check if it was.
+func sponsorTrailers(sponsors []string, overridden bool, reason string) string {
+	var trailers []string
+	for i, sponsor := range sponsors {
+		if !orgconfig.ContainsLogin(sponsors[:i], sponsor) {
+			trailers = append(trailers, "Sponsored-by: "+sponsor)
+		}
+	}
+	if overridden {
+		trailers = append(trailers, "Sponsor-check-override: "+reason)
+	}
+	if len(trailers) == 0 {
+		return ""
+	}
+	return "\n\n" + strings.Join(trailers, "\n")
+}
@@ -0,0 +1,136 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAddMemberToOrgsVerifiesSponsors(t *testing.T) {
+	cases := []struct {
+		name     string
+		user     string
+		teams    []string
+		sponsors []string
+		override string
+		err      string
+		message  string
+	}{
+		{
+			name:     "two sponsors",
+			user:     "alice",
+			sponsors: []string{"cblecker", "Bob"},
+			message:  "add alice to kubernetes\n\nSponsored-by: cblecker\nSponsored-by: Bob",
+		},
+		{
+			name:     "one sponsor",
+			user:     "alice",
+			sponsors: []string{"cblecker"},
+			err:      "alice needs 2 sponsors who are members of kubernetes org, got 1",
+		},
+		{
+			name:     "same sponsor twice",
+			user:     "alice",
+			sponsors: []string{"cblecker", "CBLECKER"},
+			err:      "got 1",
+		},
+		{
+			name:     "sponsoring themselves",
+			user:     "alice",
+			sponsors: []string{"cblecker", "alice"},
+			err:      "alice cannot sponsor themselves",
+		},
+		{
+			name:     "sponsor is not a member",
+			user:     "alice",
+			sponsors: []string{"cblecker", "thockin"},
+			err:      "sponsor thockin is not a member of kubernetes org",
+		},
+		{
+			name:     "sponsors with the same affiliation",
+			user:     "alice",
+			sponsors: []string{"aojea", "zed"},
+			err:      "sponsors aojea and zed are both affiliated with example-corp",
+		},
+		{
+			name:     "override",
+			user:     "alice",
+			sponsors: []string{"aojea", "zed"},
+			override: "approved by the steering committee",
+			message:  "add alice to kubernetes\n\nSponsored-by: aojea\nSponsored-by: zed\nSponsor-check-override: approved by the steering committee",
+		},
+		{
+			name:    "existing member added to a team",
+			user:    "Bob",
+			teams:   []string{"sig-network/sig-network-solo"},
+			message: "add Bob to kubernetes teams sig-network/sig-network-solo",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			root := setupRepoRoot(t)
+			writeFile(t, root, affiliationsConfigPath, `affiliations:
+  example-corp:
+  - aojea
+  - Zed
+  other-corp:
+  - cblecker
+`)
+			o := Options{
+				Confirm:         true,
+				RepoRoot:        root,
+				Orgs:            []string{"kubernetes"},
+				Teams:           c.teams,
+				Sponsors:        c.sponsors,
+				SponsorOverride: c.override,
+			}
+
+			err := AddMemberToOrgs(c.user, o)
+			if c.err != "" {
+				if err == nil || !strings.Contains(err.Error(), c.err) {
+					t.Fatalf("expected an error containing %q, got %v", c.err, err)
+				}
+				if got := commitMessages(t, root); len(got) > 0 {
+					t.Errorf("expected no commits, got %q", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := commitMessages(t, root); !reflect.DeepEqual(got, []string{c.message}) {
+				t.Errorf("expected commit %q, got %q", c.message, got)
+			}
+		})
+	}
+}
+
+func TestLoadAffiliationsRefusesLoginsInMultipleAffiliations(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, affiliationsConfigPath, `affiliations:
+  example-corp:
+  - alice
+  other-corp:
+  - Alice
+`)
+	if _, err := loadAffiliations(root); err == nil || !strings.Contains(err.Error(), "listed in both example-corp and other-corp") {
+		t.Errorf("expected an error for a login listed twice, got %v", err)
+	}
+}
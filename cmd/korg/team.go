@@ -0,0 +1,607 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/prow/pkg/config/org"
+	"sigs.k8s.io/prow/pkg/github"
+	"sigs.k8s.io/yaml"
+
+	"k8s.io/org/pkg/orgconfig"
+	"k8s.io/org/pkg/restrictions"
+	"k8s.io/org/pkg/validate"
+)
+
+var restrictionsConfigPath = "config/restrictions.yaml"
+
+// TeamOptions are the options of the korg team commands.
+type TeamOptions struct {
+	Description string
+	Maintainers []string
+	Members     []string
+	// Parent is the team to create or move the team under.
+	Parent string
+	// Dir is the directory, relative to config/<org>/, to move the team to.
+	// "." is the org.yaml file.
+	Dir string
+	// Owners are the approvers and reviewers of the OWNERS file created along
+	// with a new directory.
+	Owners []string
+}
+
+// parseOrgTeamRef parses <org>/<dir>/<team>, or <org>/<team> for teams
+// defined in org.yaml.
+func parseOrgTeamRef(ref string) (string, teamRef, error) {
+	orgName, rest, ok := strings.Cut(ref, "/")
+	if !ok || orgName == "" {
+		return "", teamRef{}, fmt.Errorf("invalid team %q, expected <org>/<dir>/<team> or <org>/<team>", ref)
+	}
+	t, err := parseTeamRef(rest)
+	if err != nil {
+		return "", teamRef{}, fmt.Errorf("invalid team %q, expected <org>/<dir>/<team> or <org>/<team>", ref)
+	}
+	return orgName, t, nil
+}
+
+// loadTeam returns the team referenced by ref, which must be defined in the
+// file of its directory.
+func loadTeam(o Options, orgName string, ref teamRef) (*orgconfig.Org, orgconfig.Team, error) {
+	if err := validateOrgs(o.RepoRoot, []string{orgName}, true); err != nil {
+		return nil, orgconfig.Team{}, err
+	}
+	repo := &orgconfig.Repo{Root: o.RepoRoot, AllowOverride: o.AllowOverride}
+	orgCfg, err := repo.LoadOrg(orgName)
+	if err != nil {
+		return nil, orgconfig.Team{}, err
+	}
+	t, err := resolveTeam(orgCfg, ref)
+	if err != nil {
+		return nil, orgconfig.Team{}, err
+	}
+	return orgCfg, t, nil
+}
+
+// CreateTeam adds a new team with privacy: closed to the teams.yaml file of
+// its directory, creating the file and an OWNERS file if the directory is new.
+func CreateTeam(o Options, ref string) error {
+	orgName, t, err := parseOrgTeamRef(ref)
+	if err != nil {
+		return err
+	}
+	if err := validateOrgs(o.RepoRoot, []string{orgName}, true); err != nil {
+		return err
+	}
+	repo := &orgconfig.Repo{Root: o.RepoRoot, AllowOverride: o.AllowOverride}
+	orgCfg, err := repo.LoadOrg(orgName)
+	if err != nil {
+		return err
+	}
+	if existing, ok := orgCfg.Team(t.Name); ok {
+		return fmt.Errorf("team %s already exists in %s", existing.FullName(), existing.File)
+	}
+	if strings.TrimSpace(o.TeamOptions.Description) == "" {
+		return fmt.Errorf("a description is required")
+	}
+
+	file := t.configPath(orgName)
+	teamsPath := []string{"teams"}
+	if o.TeamOptions.Parent != "" {
+		parent, ok := orgCfg.Team(o.TeamOptions.Parent)
+		if !ok {
+			return fmt.Errorf("parent team %s is not defined in org %s", o.TeamOptions.Parent, orgName)
+		}
+		if filepath.ToSlash(parent.File) != file {
+			return fmt.Errorf("parent team %s is defined in %s, not %s", parent.Name, parent.File, file)
+		}
+		teamsPath = append(append([]string{}, parent.Path...), "teams")
+	}
+
+	description, privacy := o.TeamOptions.Description, org.Closed
+	team := org.Team{TeamMetadata: org.TeamMetadata{Description: &description, Privacy: &privacy}}
+	for _, login := range o.TeamOptions.Maintainers {
+		if _, err := teamListKey(login, &orgCfg.Config, true); err != nil {
+			return err
+		}
+		team.Maintainers = appendLogin(team.Maintainers, login)
+	}
+	for _, login := range o.TeamOptions.Members {
+		key, err := teamListKey(login, &orgCfg.Config, false)
+		if err != nil {
+			return err
+		}
+		if key == "maintainers" {
+			team.Maintainers = appendLogin(team.Maintainers, login)
+		} else {
+			team.Members = appendLogin(team.Members, login)
+		}
+	}
+	block, err := teamBlock(t.Name, team)
+	if err != nil {
+		return err
+	}
+
+	if !o.Confirm {
+		fmt.Println("!!! running in dry-run mode. pass --confirm to persist changes.")
+	}
+	fmt.Printf("creating team %s in %s\n", t.Name, file)
+
+	c := &stagedFiles{root: o.RepoRoot}
+	if err := c.ensureOwners(o, file); err != nil {
+		return err
+	}
+	err = c.edit(file, func(doc *orgconfig.Document) error {
+		return doc.InsertKey(t.Name, block, teamsPath...)
+	})
+	if err != nil {
+		return err
+	}
+	if err := c.validate(o, orgName); err != nil {
+		return err
+	}
+	return c.apply(o, fmt.Sprintf("create team %s/%s", orgName, t))
+}
+
+// RenameTeam renames a team and records its old name in previously so that
+// peribolos renames the team on GitHub instead of recreating it.
+func RenameTeam(o Options, ref, newName string) error {
+	orgName, tr, err := parseOrgTeamRef(ref)
+	if err != nil {
+		return err
+	}
+	if newName == "" || strings.Contains(newName, "/") {
+		return fmt.Errorf("invalid team name %q", newName)
+	}
+	orgCfg, t, err := loadTeam(o, orgName, tr)
+	if err != nil {
+		return err
+	}
+	if existing, ok := orgCfg.Team(newName); ok && existing.FullName() != t.FullName() {
+		return fmt.Errorf("team %s already exists in %s", existing.FullName(), existing.File)
+	}
+
+	if !o.Confirm {
+		fmt.Println("!!! running in dry-run mode. pass --confirm to persist changes.")
+	}
+	fmt.Printf("renaming team %s to %s in %s\n", t.FullName(), newName, t.File)
+
+	c := &stagedFiles{root: o.RepoRoot}
+	err = c.edit(filepath.ToSlash(t.File), func(doc *orgconfig.Document) error {
+		if err := doc.RenameKey(newName, t.Path...); err != nil {
+			return err
+		}
+		if strings.EqualFold(t.Name, newName) {
+			return nil
+		}
+		renamed := append(append([]string{}, t.Path[:len(t.Path)-1]...), newName, "previously")
+		_, err := doc.AddToList(t.Name, renamed...)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	c.rename(t.FullName(), strings.Join(append(append([]string{}, t.Parents...), newName), "/"))
+	if err := c.validate(o, orgName); err != nil {
+		return err
+	}
+	return c.apply(o, fmt.Sprintf("rename team %s/%s to %s", orgName, t.FullName(), newName))
+}
+
+// MoveTeam moves a team, along with its child teams and comments, to the top
+// level of the teams.yaml file of another directory or under a parent team.
+func MoveTeam(o Options, ref string) error {
+	orgName, tr, err := parseOrgTeamRef(ref)
+	if err != nil {
+		return err
+	}
+	orgCfg, t, err := loadTeam(o, orgName, tr)
+	if err != nil {
+		return err
+	}
+
+	var file, dest string
+	var teamsPath []string
+	newFullName := t.Name
+	switch {
+	case o.TeamOptions.Parent != "" && o.TeamOptions.Dir != "":
+		return fmt.Errorf("only one of --parent and --dir can be specified")
+	case o.TeamOptions.Parent != "":
+		parent, ok := orgCfg.Team(o.TeamOptions.Parent)
+		if !ok {
+			return fmt.Errorf("parent team %s is not defined in org %s", o.TeamOptions.Parent, orgName)
+		}
+		if parent.FullName() == t.FullName() || strings.HasPrefix(parent.FullName(), t.FullName()+"/") {
+			return fmt.Errorf("team %s cannot be moved under itself", t.FullName())
+		}
+		file, teamsPath = filepath.ToSlash(parent.File), append(append([]string{}, parent.Path...), "teams")
+		dest = "team " + parent.FullName()
+		newFullName = parent.FullName() + "/" + t.Name
+	case o.TeamOptions.Dir != "":
+		dir := strings.Trim(o.TeamOptions.Dir, "/")
+		if dir == "." {
+			dir = ""
+		}
+		ref := teamRef{Dir: dir, Name: t.Name}
+		if _, err := parseTeamRef(ref.String()); err != nil {
+			return err
+		}
+		file, teamsPath = ref.configPath(orgName), []string{"teams"}
+		dest = file
+	default:
+		return fmt.Errorf("one of --parent and --dir is required")
+	}
+	if file == filepath.ToSlash(t.File) && strings.Join(teamsPath, ".") == strings.Join(t.Path[:len(t.Path)-1], ".") {
+		return fmt.Errorf("team %s is already in %s", t.FullName(), dest)
+	}
+
+	if !o.Confirm {
+		fmt.Println("!!! running in dry-run mode. pass --confirm to persist changes.")
+	}
+	fmt.Printf("moving team %s from %s to %s\n", t.FullName(), t.File, dest)
+
+	c := &stagedFiles{root: o.RepoRoot}
+	if err := c.ensureOwners(o, file); err != nil {
+		return err
+	}
+	var block []string
+	err = c.edit(filepath.ToSlash(t.File), func(doc *orgconfig.Document) error {
+		block, _, err = doc.RemoveKey(t.Path...)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	err = c.edit(file, func(doc *orgconfig.Document) error {
+		return doc.InsertKey(t.Name, block, teamsPath...)
+	})
+	if err != nil {
+		return err
+	}
+	c.removeIfEmpty(filepath.ToSlash(t.File))
+	c.rename(t.FullName(), newFullName)
+	if err := c.validate(o, orgName); err != nil {
+		return err
+	}
+	return c.apply(o, fmt.Sprintf("move team %s/%s to %s", orgName, t.FullName(), dest))
+}
+
+// DeleteTeam removes a team without child teams from its file. A teams.yaml
+// file left without teams is deleted.
+func DeleteTeam(o Options, ref string) error {
+	orgName, tr, err := parseOrgTeamRef(ref)
+	if err != nil {
+		return err
+	}
+	_, t, err := loadTeam(o, orgName, tr)
+	if err != nil {
+		return err
+	}
+	if len(t.Children) > 0 {
+		return fmt.Errorf("team %s has child teams %s, move or delete them first", t.FullName(), strings.Join(teamNames(t.Children), ", "))
+	}
+
+	if !o.Confirm {
+		fmt.Println("!!! running in dry-run mode. pass --confirm to persist changes.")
+	}
+	fmt.Printf("deleting team %s from %s\n", t.FullName(), t.File)
+
+	file := filepath.ToSlash(t.File)
+	c := &stagedFiles{root: o.RepoRoot}
+	err = c.edit(file, func(doc *orgconfig.Document) error {
+		_, _, err := doc.RemoveKey(t.Path...)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	c.removeIfEmpty(file)
+	if err := c.validate(o, orgName); err != nil {
+		return err
+	}
+	return c.apply(o, fmt.Sprintf("delete team %s/%s", orgName, t.FullName()))
+}
+
+func appendLogin(logins []string, login string) []string {
+	if orgconfig.ContainsLogin(logins, login) {
+		return logins
+	}
+	logins = append(logins, login)
+	sort.SliceStable(logins, func(i, j int) bool {
+		return strings.ToLower(logins[i]) < strings.ToLower(logins[j])
+	})
+	return logins
+}
+
+// teamBlock renders team as the lines of a "name:" entry of a teams mapping.
+func teamBlock(name string, team org.Team) ([]string, error) {
+	buf, err := yaml.Marshal(map[string]org.Team{name: team})
+	if err != nil {
+		return nil, fmt.Errorf("unable to marshal team %s: %s", name, err)
+	}
+	return strings.Split(strings.TrimSuffix(string(buf), "\n"), "\n"), nil
+}
+
+// stagedFiles holds the new contents of the files changed by a team command,
+// keyed by their slash separated path relative to the repo root, so that the
+// result can be validated before anything is written. Deleted files map to
+// nil.
+type stagedFiles struct {
+	root     string
+	files    []string
+	contents map[string][]byte
+	// renames maps the full names of the teams renamed or moved by the
+	// change to their new full names.
+	renames map[string]string
+}
+
+// rename records that the team named old, along with its child teams, is
+// named new after the change so that existing violations of the team are not
+// reported as new ones.
+func (c *stagedFiles) rename(old, new string) {
+	if c.renames == nil {
+		c.renames = map[string]string{}
+	}
+	c.renames[old] = new
+}
+
+// read returns the staged contents of file, or its contents on disk.
+func (c *stagedFiles) read(file string) ([]byte, bool, error) {
+	if buf, ok := c.contents[file]; ok {
+		return buf, buf != nil, nil
+	}
+	buf, err := os.ReadFile(filepath.Join(c.root, file))
+	if os.IsNotExist(err) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("unable to read file at %s: %s", file, err)
+	}
+	return buf, true, nil
+}
+
+func (c *stagedFiles) set(file string, buf []byte) {
+	if c.contents == nil {
+		c.contents = map[string][]byte{}
+	}
+	if _, ok := c.contents[file]; !ok {
+		c.files = append(c.files, file)
+	}
+	c.contents[file] = buf
+}
+
+// edit applies edit to file, which is created if it doesn't exist.
+func (c *stagedFiles) edit(file string, edit func(*orgconfig.Document) error) error {
+	buf, _, err := c.read(file)
+	if err != nil {
+		return err
+	}
+	doc, err := orgconfig.ParseDocument(buf)
+	if err != nil {
+		return fmt.Errorf("%s: %s", file, err)
+	}
+	if err := edit(doc); err != nil {
+		return fmt.Errorf("%s: %s", file, err)
+	}
+	c.set(file, doc.Bytes())
+	return nil
+}
+
+// removeIfEmpty deletes file if it is a teams.yaml file that is left empty.
+func (c *stagedFiles) removeIfEmpty(file string) {
+	buf, ok := c.contents[file]
+	if ok && path.Base(file) == "teams.yaml" && strings.TrimSpace(string(buf)) == "" {
+		fmt.Printf("deleting %s as it has no teams left\n", file)
+		c.contents[file] = nil
+	}
+}
+
+// ensureOwners adds an OWNERS file listing o.TeamOptions.Owners to the
+// directory of file if it is a new directory.
+func (c *stagedFiles) ensureOwners(o Options, file string) error {
+	if path.Base(file) != "teams.yaml" {
+		return nil
+	}
+	ownersFile := path.Join(path.Dir(file), ownersFileName)
+	if _, exists, err := c.read(ownersFile); err != nil || exists {
+		return err
+	}
+	if _, exists, err := c.read(file); err != nil || exists {
+		return err
+	}
+	if len(o.TeamOptions.Owners) == 0 {
+		return fmt.Errorf("%s is a new directory, pass --owner to list the approvers of its OWNERS file", path.Dir(file))
+	}
+	var b strings.Builder
+	b.WriteString("# See the OWNERS docs at https://go.k8s.io/owners\n\n")
+	for _, key := range []string{"reviewers", "approvers"} {
+		b.WriteString(key + ":\n")
+		for _, owner := range o.TeamOptions.Owners {
+			fmt.Fprintf(&b, "  - %s\n", owner)
+		}
+	}
+	fmt.Printf("creating %s\n", ownersFile)
+	c.set(ownersFile, []byte(b.String()))
+	return nil
+}
+
+// validate checks the org with the staged files against the rules of
+// pkg/validate and the restrictions config. Only violations the change
+// introduces are reported.
+func (c *stagedFiles) validate(o Options, orgName string) error {
+	rules := validate.DefaultRegistry()
+	policy, err := loadValidationConfig(o, rules)
+	if err != nil {
+		return err
+	}
+	before, err := validate.LoadOrgs(o.RepoRoot, []string{orgName})
+	if err != nil {
+		return err
+	}
+
+	staged, err := os.MkdirTemp("", "korg-team-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(staged)
+	orgDir := filepath.Join("config", orgName)
+	if err := copyDir(filepath.Join(o.RepoRoot, orgDir), filepath.Join(staged, orgDir)); err != nil {
+		return fmt.Errorf("staging changes: %s", err)
+	}
+	for _, file := range c.files {
+		p := filepath.Join(staged, file)
+		if c.contents[file] == nil {
+			if err := os.Remove(p); err != nil {
+				return fmt.Errorf("staging changes: %s", err)
+			}
+			continue
+		}
+		if err := orgconfig.WriteFile(p, c.contents[file]); err != nil {
+			return fmt.Errorf("staging changes: %s", err)
+		}
+	}
+	after, err := validate.LoadOrgs(staged, []string{orgName})
+	if err != nil {
+		return fmt.Errorf("the change breaks the config of org %s: %s", orgName, err)
+	}
+
+	known := map[string]bool{}
+	for _, v := range rules.Validate(before, policy) {
+		message := v.Message
+		for old, new := range c.renames {
+			message = strings.ReplaceAll(message, "team "+old+" ", "team "+new+" ")
+			message = strings.ReplaceAll(message, "team "+old+"/", "team "+new+"/")
+		}
+		known[v.Rule+"/"+message] = true
+	}
+	var problems []string
+	for _, v := range rules.Validate(after, policy) {
+		if v.Severity == validate.SeverityError && !known[v.Rule+"/"+v.Message] {
+			problems = append(problems, fmt.Sprintf("%s [%s]", v.Message, v.Rule))
+		}
+	}
+
+	restrictionsPath := filepath.Join(o.RepoRoot, restrictionsConfigPath)
+	if _, err := os.Stat(restrictionsPath); err == nil {
+		cfg, err := restrictions.Load(restrictionsPath)
+		if err != nil {
+			return err
+		}
+		for _, file := range c.files {
+			if c.contents[file] == nil || path.Base(file) == ownersFileName {
+				continue
+			}
+			teams, err := orgconfig.ReadFile(filepath.Join(staged, file), nil)
+			if err != nil {
+				return err
+			}
+			r := restrictions.For(cfg.Restrictions, strings.TrimPrefix(file, "config/"))
+			for _, name := range teamNames(teams.Teams) {
+				for _, repo := range repoNames(teams.Teams[name].Repos) {
+					if !r.Allows(repo) {
+						problems = append(problems, fmt.Sprintf("team %s cannot define repo %q in %s [restrictions]", name, repo, file))
+					}
+				}
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("refusing to change org %s:\n  %s", orgName, strings.Join(problems, "\n  "))
+	}
+	return nil
+}
+
+// apply writes the staged files and commits them if running with --confirm.
+func (c *stagedFiles) apply(o Options, message string) error {
+	fmt.Printf("config files modified: %s\n", strings.Join(c.files, ", "))
+	if !o.Confirm {
+		return nil
+	}
+
+	for _, file := range c.files {
+		p := filepath.Join(o.RepoRoot, file)
+		if c.contents[file] == nil {
+			if err := os.Remove(p); err != nil {
+				return fmt.Errorf("saving config: %s", err)
+			}
+			continue
+		}
+		if err := orgconfig.WriteFile(p, c.contents[file]); err != nil {
+			return fmt.Errorf("saving config: %s", err)
+		}
+	}
+
+	fmt.Println("committing changes")
+	if err := commitChanges(o.RepoRoot, c.files, message); err != nil {
+		return fmt.Errorf("committing changes: %s", err)
+	}
+	return nil
+}
+
+func teamNames(teams map[string]org.Team) []string {
+	names := make([]string, 0, len(teams))
+	for name := range teams {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func repoNames(repos map[string]github.RepoPermissionLevel) []string {
+	names := make([]string, 0, len(repos))
+	for name := range repos {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// copyDir copies the regular files of the directory src to dst, keeping their
+// modes.
+func copyDir(src, dst string) error {
+	return filepath.WalkDir(src, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, p)
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return os.MkdirAll(filepath.Join(dst, rel), 0755)
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		buf, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(filepath.Join(dst, rel), buf, info.Mode().Perm())
+	})
+}
@@ -0,0 +1,320 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+)
+
+func commitMessages(t *testing.T, root string) []string {
+	t.Helper()
+	r, err := git.PlainOpen(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	log, err := r.Log(&git.LogOptions{})
+	if err != nil {
+		return nil
+	}
+	var messages []string
+	for {
+		c, err := log.Next()
+		if err != nil {
+			break
+		}
+		messages = append([]string{c.Message}, messages...)
+	}
+	return messages
+}
+
+func TestCreateTeam(t *testing.T) {
+	root := setupRepoRoot(t)
+	o := Options{Confirm: true, RepoRoot: root, TeamOptions: TeamOptions{
+		Description: "Reviewers of foo",
+		Maintainers: []string{"k8s-ci-robot"},
+		Members:     []string{"zed", "cblecker", "aojea"},
+		Owners:      []string{"sig-foo-leads"},
+	}}
+	if err := CreateTeam(o, "kubernetes/sig-foo/foo-reviewers"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	o.TeamOptions = TeamOptions{Description: "Admins of foo", Parent: "foo-reviewers"}
+	if err := CreateTeam(o, "kubernetes/sig-foo/foo-admins"); err != nil {
+		t.Fatalf("unexpected error creating a child team: %v", err)
+	}
+
+	expected := `teams:
+  foo-reviewers:
+    description: Reviewers of foo
+    maintainers:
+    - cblecker
+    - k8s-ci-robot
+    members:
+    - aojea
+    - zed
+    privacy: closed
+    teams:
+      foo-admins:
+        description: Admins of foo
+        privacy: closed
+`
+	if got := string(mustReadFile(t, filepath.Join(root, "config/kubernetes/sig-foo/teams.yaml"))); got != expected {
+		t.Errorf("expected teams.yaml:\n%s\ngot:\n%s", expected, got)
+	}
+	expectedOwners := `# See the OWNERS docs at https://go.k8s.io/owners
+
+reviewers:
+  - sig-foo-leads
+approvers:
+  - sig-foo-leads
+`
+	if got := string(mustReadFile(t, filepath.Join(root, "config/kubernetes/sig-foo/OWNERS"))); got != expectedOwners {
+		t.Errorf("expected OWNERS:\n%s\ngot:\n%s", expectedOwners, got)
+	}
+	expectedMessages := []string{"create team kubernetes/sig-foo/foo-reviewers", "create team kubernetes/sig-foo/foo-admins"}
+	if got := commitMessages(t, root); !reflect.DeepEqual(got, expectedMessages) {
+		t.Errorf("expected commits %q, got %q", expectedMessages, got)
+	}
+}
+
+func TestCreateTeamRefusesInvalidTeams(t *testing.T) {
+	cases := []struct {
+		name    string
+		ref     string
+		options TeamOptions
+		err     string
+	}{
+		{
+			name:    "existing team",
+			ref:     "kubernetes/sig-foo/SIG-NETWORK-LEADS",
+			options: TeamOptions{Description: "foo", Owners: []string{"cblecker"}},
+			err:     "already exists",
+		},
+		{
+			name:    "no description",
+			ref:     "kubernetes/sig-network/foo",
+			options: TeamOptions{},
+			err:     "description is required",
+		},
+		{
+			name:    "new directory without owners",
+			ref:     "kubernetes/sig-foo/foo",
+			options: TeamOptions{Description: "foo"},
+			err:     "pass --owner",
+		},
+		{
+			name:    "maintainer that is not an org admin",
+			ref:     "kubernetes/sig-network/foo",
+			options: TeamOptions{Description: "foo", Maintainers: []string{"aojea"}},
+			err:     "cannot be a team maintainer",
+		},
+		{
+			name:    "member that is not an org member",
+			ref:     "kubernetes/sig-network/foo",
+			options: TeamOptions{Description: "foo", Members: []string{"nobody"}},
+			err:     "[team-members-in-org]",
+		},
+		{
+			name:    "parent defined in another file",
+			ref:     "kubernetes/sig-foo/foo",
+			options: TeamOptions{Description: "foo", Parent: "sig-network-leads", Owners: []string{"cblecker"}},
+			err:     "is defined in",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			root := setupRepoRoot(t)
+			before := mustReadFile(t, filepath.Join(root, "config/kubernetes/sig-network/teams.yaml"))
+			err := CreateTeam(Options{Confirm: true, RepoRoot: root, TeamOptions: c.options}, c.ref)
+			if err == nil || !strings.Contains(err.Error(), c.err) {
+				t.Fatalf("expected an error containing %q, got %v", c.err, err)
+			}
+			if got := mustReadFile(t, filepath.Join(root, "config/kubernetes/sig-network/teams.yaml")); string(got) != string(before) {
+				t.Errorf("expected teams.yaml to be left alone, got:\n%s", got)
+			}
+			if _, err := os.Stat(filepath.Join(root, "config/kubernetes/sig-foo")); !os.IsNotExist(err) {
+				t.Errorf("expected no new directory, got %v", err)
+			}
+		})
+	}
+}
+
+func TestRenameTeam(t *testing.T) {
+	root := setupRepoRoot(t)
+	o := Options{Confirm: true, RepoRoot: root}
+	if err := RenameTeam(o, "kubernetes/sig-network/sig-network-solo", "a-network-team"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := string(mustReadFile(t, filepath.Join(root, "config/kubernetes/sig-network/teams.yaml")))
+	expected := `teams:
+  a-network-team:
+    description: |
+      Multi-line
+      description
+    members:
+      - shaneutt
+    previously:
+    - sig-network-solo
+    privacy: closed
+`
+	if !strings.HasPrefix(got, expected) {
+		t.Errorf("expected teams.yaml to start with:\n%s\ngot:\n%s", expected, got)
+	}
+
+	if err := RenameTeam(o, "kubernetes/a-network-team", "sig-network-leads"); err == nil {
+		t.Errorf("expected an error renaming to an existing team")
+	}
+	if err := RenameTeam(o, "kubernetes/sig-apps/a-network-team", "foo"); err == nil {
+		t.Errorf("expected an error referencing the team in the wrong directory")
+	}
+}
+
+func TestRenameTeamKeepsFileMode(t *testing.T) {
+	root := setupRepoRoot(t)
+	path := filepath.Join(root, "config/kubernetes/sig-network/teams.yaml")
+	if err := os.Chmod(path, 0600); err != nil {
+		t.Fatal(err)
+	}
+	o := Options{Confirm: true, RepoRoot: root}
+	if err := RenameTeam(o, "kubernetes/sig-network/sig-network-solo", "a-network-team"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("expected teams.yaml to keep mode 0600, got %v", info.Mode().Perm())
+	}
+}
+
+func TestMoveTeam(t *testing.T) {
+	root := setupRepoRoot(t)
+	writeFile(t, root, "config/restrictions.yaml", `restrictions:
+  - path: "kubernetes/sig-foo/teams.yaml"
+    allowedRepos:
+    - "^foo"
+`)
+	o := Options{Confirm: true, RepoRoot: root}
+
+	o.TeamOptions = TeamOptions{Parent: "sig-network-leads"}
+	if err := MoveTeam(o, "kubernetes/sig-network/sig-network-solo"); err != nil {
+		t.Fatalf("unexpected error moving under a parent: %v", err)
+	}
+	if err := MoveTeam(o, "kubernetes/sig-network-leads"); err == nil || !strings.Contains(err.Error(), "under itself") {
+		t.Errorf("expected an error moving a team under itself, got %v", err)
+	}
+
+	o.TeamOptions = TeamOptions{Dir: "sig-foo", Owners: []string{"cblecker"}}
+	if err := MoveTeam(o, "kubernetes/sig-network-leads"); err == nil || !strings.Contains(err.Error(), "[restrictions]") {
+		t.Errorf("expected a restrictions error, got %v", err)
+	}
+	if err := MoveTeam(o, "kubernetes/sig-network-solo"); err != nil {
+		t.Fatalf("unexpected error moving to a new directory: %v", err)
+	}
+	if err := MoveTeam(o, "kubernetes/sig-network-reviewers"); err != nil {
+		t.Fatalf("unexpected error moving to a new directory: %v", err)
+	}
+
+	o.TeamOptions = TeamOptions{Dir: "sig-foo"}
+	if err := MoveTeam(o, "kubernetes/sig-network-leads"); err == nil {
+		t.Errorf("expected a restrictions error")
+	}
+
+	expected := `teams:
+  sig-network-reviewers:
+    description: ""
+    members: []
+    privacy: closed
+  sig-network-solo:
+    description: |
+      Multi-line
+      description
+    members:
+      - shaneutt
+    privacy: closed
+`
+	if got := string(mustReadFile(t, filepath.Join(root, "config/kubernetes/sig-foo/teams.yaml"))); got != expected {
+		t.Errorf("expected sig-foo teams.yaml:\n%s\ngot:\n%s", expected, got)
+	}
+	if got := string(mustReadFile(t, filepath.Join(root, "config/kubernetes/sig-network/teams.yaml"))); strings.Contains(got, "sig-network-solo") || strings.Contains(got, "\n    teams:") {
+		t.Errorf("expected the moved teams to be removed from sig-network, got:\n%s", got)
+	}
+	expectedMessages := []string{
+		"move team kubernetes/sig-network-solo to team sig-network-leads",
+		"move team kubernetes/sig-network-leads/sig-network-solo to config/kubernetes/sig-foo/teams.yaml",
+		"move team kubernetes/sig-network-reviewers to config/kubernetes/sig-foo/teams.yaml",
+	}
+	if got := commitMessages(t, root); !reflect.DeepEqual(got, expectedMessages) {
+		t.Errorf("expected commits %q, got %q", expectedMessages, got)
+	}
+}
+
+func TestDeleteTeam(t *testing.T) {
+	root := setupRepoRoot(t)
+	o := Options{Confirm: true, RepoRoot: root}
+	// git refuses to commit an empty index, so track the org config first as
+	// in the real repo
+	if err := commitChanges(root, []string{"config/kubernetes/org.yaml"}, "initial commit"); err != nil {
+		t.Fatal(err)
+	}
+	// git refuses to commit an empty index, so track the org config first as
+	// in the real repo
+	if err := commitChanges(root, []string{"config/kubernetes/org.yaml"}, "initial commit"); err != nil {
+		t.Fatal(err)
+	}
+
+	o.TeamOptions = TeamOptions{Parent: "sig-network-leads"}
+	if err := MoveTeam(o, "kubernetes/sig-network-solo"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := DeleteTeam(o, "kubernetes/sig-network-leads"); err == nil || !strings.Contains(err.Error(), "child teams sig-network-solo") {
+		t.Errorf("expected an error deleting a team with child teams, got %v", err)
+	}
+	for _, team := range []string{"sig-network-solo", "sig-network-leads", "sig-network-reviewers"} {
+		if err := DeleteTeam(o, "kubernetes/sig-network/"+team); err != nil {
+			t.Fatalf("unexpected error deleting %s: %v", team, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(root, "config/kubernetes/sig-network/teams.yaml")); !os.IsNotExist(err) {
+		t.Errorf("expected teams.yaml without teams to be deleted, got %v", err)
+	}
+
+	r, err := git.PlainOpen(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := r.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	status, err := w.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s, ok := status["config/kubernetes/sig-network/teams.yaml"]; ok {
+		t.Errorf("expected the deletion of teams.yaml to be committed, got %c%c", s.Staging, s.Worktree)
+	}
+}
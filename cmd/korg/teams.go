@@ -0,0 +1,177 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"sigs.k8s.io/prow/pkg/config/org"
+
+	"k8s.io/org/pkg/orgconfig"
+)
+
+var teamsConfigPathFormat = "config/%s/%s/teams.yaml"
+
+// teamRef identifies a team by the directory of the teams.yaml defining it,
+// e.g. sig-release/milestone-maintainers. Teams defined directly in org.yaml
+// are referenced by their name alone.
+type teamRef struct {
+	Dir  string
+	Name string
+}
+
+func parseTeamRef(ref string) (teamRef, error) {
+	dir, name := "", ref
+	if i := strings.LastIndex(ref, "/"); i >= 0 {
+		dir, name = ref[:i], ref[i+1:]
+	}
+	if name == "" || strings.HasPrefix(dir, "/") || strings.Contains(dir, "..") {
+		return teamRef{}, fmt.Errorf("invalid team %q, expected <dir>/<team> or <team>", ref)
+	}
+	return teamRef{Dir: dir, Name: name}, nil
+}
+
+func (t teamRef) String() string {
+	if t.Dir == "" {
+		return t.Name
+	}
+	return t.Dir + "/" + t.Name
+}
+
+// configPath returns the path of the file defining the team relative to the
+// repo root.
+func (t teamRef) configPath(orgName string) string {
+	if t.Dir == "" {
+		return fmt.Sprintf(orgConfigPathFormat, orgName)
+	}
+	return fmt.Sprintf(teamsConfigPathFormat, orgName, t.Dir)
+}
+
+func parseTeamRefs(refs []string) ([]teamRef, error) {
+	teams := make([]teamRef, 0, len(refs))
+	for _, ref := range refs {
+		t, err := parseTeamRef(ref)
+		if err != nil {
+			return nil, err
+		}
+		teams = append(teams, t)
+	}
+	return teams, nil
+}
+
+// teamListKey returns the key of the list the user belongs in. Org admins
+// can only be team maintainers and only org admins can be team maintainers,
+// matching testTeamMembers in config/config_test.go.
+func teamListKey(username string, orgConfig *org.Config, maintainer bool) (string, error) {
+	isAdmin := orgconfig.ContainsLogin(orgConfig.Admins, username)
+	switch {
+	case maintainer && !isAdmin:
+		return "", fmt.Errorf("user %s is not an org admin and cannot be a team maintainer", username)
+	case isAdmin:
+		return "maintainers", nil
+	default:
+		return "members", nil
+	}
+}
+
+// resolveTeam returns the team of orgCfg referenced by t. Teams referenced
+// with a directory, including child teams, must be defined in the teams.yaml
+// file of that directory.
+func resolveTeam(orgCfg *orgconfig.Org, t teamRef) (orgconfig.Team, error) {
+	team, ok := orgCfg.Team(t.Name)
+	if !ok {
+		return orgconfig.Team{}, fmt.Errorf("team %s is not defined in org %s", t.Name, orgCfg.Name)
+	}
+	if t.Dir != "" && filepath.ToSlash(team.File) != t.configPath(orgCfg.Name) {
+		return orgconfig.Team{}, fmt.Errorf("team %s is defined in %s, not %s", team.Name, team.File, t.configPath(orgCfg.Name))
+	}
+	return team, nil
+}
+
+// AddMemberToTeams records the edits adding username to the given teams of
+// orgCfg in changes. orgCfg must already reflect any change to the org
+// membership of username. Nothing is written, so an invalid team leaves every
+// file untouched.
+func AddMemberToTeams(changes *orgconfig.Changes, orgCfg *orgconfig.Org, username string, teams []teamRef, o Options) error {
+	if !orgCfg.IsMember(username) {
+		return fmt.Errorf("user %s is not a member of org %s", username, orgCfg.Name)
+	}
+
+	key, err := teamListKey(username, &orgCfg.Config, o.Maintainer)
+	if err != nil {
+		return err
+	}
+
+	for _, t := range teams {
+		team, err := resolveTeam(orgCfg, t)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("adding %s to %s team in %s org as %s\n", username, t, orgCfg.Name, strings.TrimSuffix(key, "s"))
+		e, added, err := orgCfg.AddTeamMember(team.Name, username, orgconfig.Role(key))
+		if err != nil {
+			return err
+		}
+		if !added {
+			return fmt.Errorf("user %s already exists in team %s", username, t)
+		}
+		changes.Add(e)
+	}
+	return nil
+}
+
+// RemoveMemberFromTeams records the edits removing username from the given
+// teams of orgCfg in changes, whether they are a member or a maintainer.
+func RemoveMemberFromTeams(changes *orgconfig.Changes, orgCfg *orgconfig.Org, username string, teams []teamRef) error {
+	for _, t := range teams {
+		team, err := resolveTeam(orgCfg, t)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("removing %s from %s team in %s org\n", username, t, orgCfg.Name)
+		e, removed, err := orgCfg.RemoveTeamMember(team.Name, username)
+		if err != nil {
+			return err
+		}
+		if !removed {
+			return fmt.Errorf("user %s doesn't exist in team %s", username, t)
+		}
+		changes.Add(e)
+	}
+	return nil
+}
+
+// removeFromAllTeams records the edits removing username from every team of
+// orgCfg they are a member or maintainer of in changes, including child teams
+// defined in other files.
+func removeFromAllTeams(changes *orgconfig.Changes, orgCfg *orgconfig.Org, username string) error {
+	for _, t := range orgCfg.Teams() {
+		e, removed, err := orgCfg.RemoveTeamMember(t.Name, username)
+		if err != nil {
+			return err
+		}
+		if removed {
+			fmt.Printf("removing %s from %s team in %s org\n", username, t.FullName(), orgCfg.Name)
+			changes.Add(e)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,366 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+
+	"k8s.io/org/pkg/orgconfig"
+)
+
+func TestParseTeamRef(t *testing.T) {
+	cases := []struct {
+		ref         string
+		expected    teamRef
+		expectError bool
+	}{
+		{
+			ref:      "sig-release/milestone-maintainers",
+			expected: teamRef{Dir: "sig-release", Name: "milestone-maintainers"},
+		},
+		{
+			ref:      "org-admins",
+			expected: teamRef{Name: "org-admins"},
+		},
+		{
+			ref:         "sig-release/",
+			expectError: true,
+		},
+		{
+			ref:         "../sig-release/team",
+			expectError: true,
+		},
+	}
+
+	for _, c := range cases {
+		got, err := parseTeamRef(c.ref)
+		if c.expectError {
+			if err == nil {
+				t.Errorf("expected error for %q", c.ref)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("unexpected error for %q: %v", c.ref, err)
+		}
+		if got != c.expected {
+			t.Errorf("expected %#v for %q, got %#v", c.expected, c.ref, got)
+		}
+	}
+}
+
+func TestAddMemberToTeams(t *testing.T) {
+	cases := []struct {
+		name        string
+		user        string
+		maintainer  bool
+		team        string
+		expectError bool
+		expected    func(orgYAML, teamsYAML *orgconfig.Document) error
+	}{
+		{
+			name: "existing org member is added to team members",
+			user: "Bob",
+			team: "sig-network/sig-network-leads",
+			expected: func(orgYAML, teamsYAML *orgconfig.Document) error {
+				_, err := teamsYAML.AddToList("Bob", "teams", "sig-network-leads", "members")
+				return err
+			},
+		},
+		{
+			name: "new user is added to the org and team members",
+			user: "alice",
+			team: "sig-network/sig-network-solo",
+			expected: func(orgYAML, teamsYAML *orgconfig.Document) error {
+				if _, err := orgYAML.AddToList("alice", "members"); err != nil {
+					return err
+				}
+				_, err := teamsYAML.AddToList("alice", "teams", "sig-network-solo", "members")
+				return err
+			},
+		},
+		{
+			name: "org admin is added to team maintainers",
+			user: "k8s-ci-robot",
+			team: "sig-network/sig-network-leads",
+			expected: func(orgYAML, teamsYAML *orgconfig.Document) error {
+				_, err := teamsYAML.AddToList("k8s-ci-robot", "teams", "sig-network-leads", "maintainers")
+				return err
+			},
+		},
+		{
+			name:        "non-admin cannot be a maintainer",
+			user:        "Bob",
+			maintainer:  true,
+			team:        "sig-network/sig-network-leads",
+			expectError: true,
+		},
+		{
+			name:        "existing team member",
+			user:        "aojea",
+			team:        "sig-network/sig-network-leads",
+			expectError: true,
+		},
+		{
+			name:        "undefined team",
+			user:        "Bob",
+			team:        "sig-network/no-such-team",
+			expectError: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			root := setupRepoRoot(t)
+			o := Options{
+				Confirm:    true,
+				RepoRoot:   root,
+				Orgs:       []string{"kubernetes"},
+				Teams:      []string{c.team},
+				Maintainer: c.maintainer,
+				Sponsors:   []string{"cblecker", "aojea"},
+			}
+
+			err := AddMemberToOrgs(c.user, o)
+			if c.expectError {
+				if err == nil {
+					t.Fatalf("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			orgYAML, err := orgconfig.ParseDocument(readTestdata(t, "yamledit/org.yaml"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			teamsYAML, err := orgconfig.ParseDocument(readTestdata(t, "yamledit/teams.yaml"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := c.expected(orgYAML, teamsYAML); err != nil {
+				t.Fatal(err)
+			}
+
+			for path, doc := range map[string]*orgconfig.Document{
+				"config/kubernetes/org.yaml":               orgYAML,
+				"config/kubernetes/sig-network/teams.yaml": teamsYAML,
+			} {
+				if got := mustReadFile(t, filepath.Join(root, path)); string(got) != string(doc.Bytes()) {
+					t.Errorf("unexpected contents of %s:\n%s", path, got)
+				}
+			}
+		})
+	}
+}
+
+func TestAddMemberToChildTeams(t *testing.T) {
+	root := setupRepoRoot(t)
+	writeFile(t, root, "config/kubernetes/sig-network/sig-network-leads/teams.yaml", `teams:
+  sig-network-leads-emeritus:
+    members:
+    - cblecker
+`)
+	writeFile(t, root, "config/kubernetes/sig-foo/teams.yaml", `teams:
+  sig-foo:
+    members:
+    - aojea
+    teams:
+      sig-foo-leads:
+        members:
+        - aojea
+`)
+
+	for _, team := range []string{"sig-network/sig-network-leads/sig-network-leads-emeritus", "sig-foo/sig-foo-leads"} {
+		o := Options{Confirm: true, RepoRoot: root, Orgs: []string{"kubernetes"}, Teams: []string{team}}
+		if err := AddMemberToOrgs("Bob", o); err != nil {
+			t.Fatalf("unexpected error adding to %s: %v", team, err)
+		}
+	}
+
+	expected := map[string]string{
+		"config/kubernetes/sig-network/sig-network-leads/teams.yaml": `teams:
+  sig-network-leads-emeritus:
+    members:
+    - Bob
+    - cblecker
+`,
+		"config/kubernetes/sig-foo/teams.yaml": `teams:
+  sig-foo:
+    members:
+    - aojea
+    teams:
+      sig-foo-leads:
+        members:
+        - aojea
+        - Bob
+`,
+	}
+	for path, want := range expected {
+		if got := string(mustReadFile(t, filepath.Join(root, path))); got != want {
+			t.Errorf("unexpected contents of %s:\n%s", path, got)
+		}
+	}
+
+	o := Options{RepoRoot: root, Orgs: []string{"kubernetes"}, Teams: []string{"sig-network/sig-foo-leads"}}
+	if err := AddMemberToOrgs("Bob", o); err == nil || !strings.Contains(err.Error(), "is defined in") {
+		t.Errorf("expected an error for a team referenced with the wrong directory, got %v", err)
+	}
+}
+
+func TestAddAndRemoveWriteNothingOnError(t *testing.T) {
+	cases := []struct {
+		name   string
+		change func(o Options) error
+	}{
+		{
+			name: "add a new user to a valid and an undefined team",
+			change: func(o Options) error {
+				o.Teams = []string{"sig-network/sig-network-solo", "sig-network/no-such-team"}
+				o.Sponsors = []string{"cblecker", "aojea"}
+				return AddMemberToOrgs("alice", o)
+			},
+		},
+		{
+			name: "add a non-admin as a maintainer to a new org",
+			change: func(o Options) error {
+				o.Teams = []string{"sig-network/sig-network-solo"}
+				o.Sponsors = []string{"cblecker", "aojea"}
+				o.Maintainer = true
+				return AddMemberToOrgs("alice", o)
+			},
+		},
+		{
+			name: "remove a user from a team they belong to and one they don't",
+			change: func(o Options) error {
+				o.Teams = []string{"sig-network/sig-network-leads", "sig-network/sig-network-solo"}
+				return RemoveMemberFromOrgs(o, "thockin")
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			root := setupRepoRoot(t)
+			o := Options{Confirm: true, RepoRoot: root, Orgs: []string{"kubernetes"}}
+			if err := c.change(o); err == nil {
+				t.Fatalf("expected error")
+			}
+			compareGolden(t, "yamledit/org.yaml", mustReadFile(t, filepath.Join(root, "config/kubernetes/org.yaml")))
+			compareGolden(t, "yamledit/teams.yaml", mustReadFile(t, filepath.Join(root, "config/kubernetes/sig-network/teams.yaml")))
+		})
+	}
+}
+
+func TestRemoveMemberFromTeams(t *testing.T) {
+	root := setupRepoRoot(t)
+	o := Options{
+		Confirm:  true,
+		RepoRoot: root,
+		Orgs:     []string{"kubernetes"},
+		Teams:    []string{"sig-network/sig-network-leads"},
+	}
+
+	if err := RemoveMemberFromOrgs(o, "cblecker"); err != nil {
+		t.Fatalf("unexpected error removing maintainer: %v", err)
+	}
+	if err := RemoveMemberFromOrgs(o, "thockin"); err != nil {
+		t.Fatalf("unexpected error removing member: %v", err)
+	}
+	if err := RemoveMemberFromOrgs(o, "thockin"); err == nil {
+		t.Errorf("expected error removing a user that is not in the team")
+	}
+
+	doc, err := orgconfig.ParseDocument(readTestdata(t, "yamledit/teams.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := doc.RemoveFromList("cblecker", "teams", "sig-network-leads", "maintainers"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := doc.RemoveFromList("thockin", "teams", "sig-network-leads", "members"); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := mustReadFile(t, filepath.Join(root, "config/kubernetes/sig-network/teams.yaml")); string(got) != string(doc.Bytes()) {
+		t.Errorf("unexpected teams.yaml contents:\n%s", got)
+	}
+	compareGolden(t, "yamledit/org.yaml", mustReadFile(t, filepath.Join(root, "config/kubernetes/org.yaml")))
+}
+
+func TestRemoveMemberFromOrgsCascadesToTeams(t *testing.T) {
+	root := setupRepoRoot(t)
+	writeFile(t, root, "config/kubernetes/sig-foo/teams.yaml", `teams:
+  sig-foo:
+    description: SIG Foo
+    members:
+    - AOJEA
+    privacy: closed
+    teams:
+      sig-foo-leads:
+        description: SIG Foo leads
+        maintainers:
+        - aojea
+        privacy: closed
+`)
+	o := Options{Confirm: true, RepoRoot: root, Orgs: []string{"kubernetes"}}
+
+	if err := RemoveMemberFromOrgs(o, "aojea"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	teamsYAML, err := orgconfig.ParseDocument(readTestdata(t, "yamledit/teams.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := teamsYAML.RemoveFromList("aojea", "teams", "sig-network-leads", "members"); err != nil {
+		t.Fatal(err)
+	}
+	if got := mustReadFile(t, filepath.Join(root, "config/kubernetes/sig-network/teams.yaml")); string(got) != string(teamsYAML.Bytes()) {
+		t.Errorf("unexpected sig-network teams.yaml contents:\n%s", got)
+	}
+	if got := string(mustReadFile(t, filepath.Join(root, "config/kubernetes/sig-foo/teams.yaml"))); strings.Contains(strings.ToLower(got), "aojea") {
+		t.Errorf("expected aojea to be removed from nested teams, got:\n%s", got)
+	}
+	if got := string(mustReadFile(t, filepath.Join(root, "config/kubernetes/org.yaml"))); strings.Contains(got, "aojea") {
+		t.Errorf("expected aojea to be removed from org.yaml, got:\n%s", got)
+	}
+
+	r, err := git.PlainOpen(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	head, err := r.Head()
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := r.CommitObject(head.Hash())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range []string{"config/kubernetes/org.yaml", "config/kubernetes/sig-network/teams.yaml", "config/kubernetes/sig-foo/teams.yaml"} {
+		if _, err := c.File(file); err != nil {
+			t.Errorf("expected %s to be committed: %v", file, err)
+		}
+	}
+}
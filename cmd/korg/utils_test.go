@@ -0,0 +1,121 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+// testdataPath returns the path of the testdata file name. The yamledit
+// fixtures are shared with pkg/orgconfig instead of being copied.
+func testdataPath(name string) string {
+	if strings.HasPrefix(filepath.ToSlash(name), "yamledit/") {
+		return filepath.Join("..", "..", "pkg", "orgconfig", "testdata", name)
+	}
+	return filepath.Join("testdata", name)
+}
+
+func readTestdata(t *testing.T, name string) []byte {
+	t.Helper()
+	b, err := os.ReadFile(testdataPath(name))
+	if err != nil {
+		t.Fatalf("reading %s: %v", name, err)
+	}
+	return b
+}
+
+func mustReadFile(t *testing.T, path string) []byte {
+	t.Helper()
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func compareGolden(t *testing.T, golden string, got []byte) {
+	t.Helper()
+	path := testdataPath(golden)
+	if *update {
+		if err := os.WriteFile(path, got, 0644); err != nil {
+			t.Fatalf("updating %s: %v", path, err)
+		}
+		return
+	}
+	if want := readTestdata(t, golden); string(want) != string(got) {
+		t.Errorf("output does not match %s\nwant:\n%s\ngot:\n%s", path, want, got)
+	}
+}
+
+// setupRepoRoot copies the yamledit fixtures into a fresh git repository laid
+// out like k/org and returns its path.
+func setupRepoRoot(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	files := map[string]string{
+		"config/kubernetes/org.yaml":               "yamledit/org.yaml",
+		"config/kubernetes/sig-network/teams.yaml": "yamledit/teams.yaml",
+	}
+	for dst, src := range files {
+		path := filepath.Join(root, dst)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, readTestdata(t, src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r, err := git.PlainInit(root, false)
+	if err != nil {
+		t.Fatalf("initializing repo: %v", err)
+	}
+	cfg, err := r.Config()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.User.Name = "korg"
+	cfg.User.Email = "korg@example.com"
+	if err := r.SetConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+	return root
+}
+
+func TestAddAndRemoveKeepUntouchedFiles(t *testing.T) {
+	root := setupRepoRoot(t)
+	o := Options{Confirm: true, RepoRoot: root, Orgs: []string{"kubernetes"}, Sponsors: []string{"cblecker", "aojea"}}
+
+	if err := AddMemberToOrgs("alice", o); err != nil {
+		t.Fatalf("unexpected error adding: %v", err)
+	}
+	compareGolden(t, "yamledit/org-add-middle.golden", mustReadFile(t, filepath.Join(root, "config/kubernetes/org.yaml")))
+
+	if err := RemoveMemberFromOrgs(o, "alice"); err != nil {
+		t.Fatalf("unexpected error removing: %v", err)
+	}
+	compareGolden(t, "yamledit/org.yaml", mustReadFile(t, filepath.Join(root, "config/kubernetes/org.yaml")))
+	compareGolden(t, "yamledit/teams.yaml", mustReadFile(t, filepath.Join(root, "config/kubernetes/sig-network/teams.yaml")))
+}
@@ -0,0 +1,110 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/olekukonko/tablewriter"
+
+	"k8s.io/org/pkg/validate"
+)
+
+var validationConfigPath = "config/validation.yaml"
+
+// ValidateOrgs checks the given orgs, or all orgs if none are given, against
+// the rules of pkg/validate and prints every violation. It returns an error if
+// any violation has error severity.
+func ValidateOrgs(o Options, out io.Writer) error {
+	rules := validate.DefaultRegistry()
+	if o.ListRules {
+		listRules(out, rules)
+		return nil
+	}
+
+	policy, err := loadValidationConfig(o, rules)
+	if err != nil {
+		return err
+	}
+
+	names := o.Orgs
+	if len(names) == 0 {
+		registry, err := loadOrgRegistry(o.RepoRoot)
+		if err != nil {
+			return err
+		}
+		names = registry.Names(true)
+	}
+	orgs, err := validate.LoadOrgs(o.RepoRoot, names)
+	if err != nil {
+		return err
+	}
+
+	errs, warnings := 0, 0
+	for _, v := range rules.Validate(orgs, policy) {
+		fmt.Fprintln(out, v)
+		if o.GitHubAnnotations {
+			fmt.Fprintln(out, v.Annotation())
+		}
+		if v.Severity == validate.SeverityError {
+			errs++
+		} else {
+			warnings++
+		}
+	}
+	fmt.Fprintf(out, "validated %d org(s): %d error(s), %d warning(s)\n", len(orgs), errs, warnings)
+
+	if errs > 0 {
+		return fmt.Errorf("found %d error(s)", errs)
+	}
+	return nil
+}
+
+// loadValidationConfig reads --validation-config, or config/validation.yaml
+// if it exists, and checks it against rules.
+func loadValidationConfig(o Options, rules *validate.Registry) (*validate.Config, error) {
+	configPath := o.ValidationConfig
+	if configPath == "" {
+		configPath = filepath.Join(o.RepoRoot, validationConfigPath)
+	}
+	policy := &validate.Config{}
+	if _, err := os.Stat(configPath); err == nil || o.ValidationConfig != "" {
+		policy, err = validate.LoadConfig(configPath)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := policy.Check(rules); err != nil {
+		return nil, fmt.Errorf("%s: %s", configPath, err)
+	}
+	return policy, nil
+}
+
+func listRules(out io.Writer, rules *validate.Registry) {
+	table := tablewriter.NewWriter(out)
+	table.SetAutoWrapText(false)
+	table.SetHeader([]string{"Rule", "Severity", "Description"})
+	table.SetBorders(tablewriter.Border{Left: true, Top: false, Right: true, Bottom: false})
+	table.SetCenterSeparator("|")
+	for _, r := range rules.Rules() {
+		table.Append([]string{r.ID, string(r.Severity), r.Description})
+	}
+	table.Render()
+}
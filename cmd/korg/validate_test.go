@@ -0,0 +1,52 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestValidateOrgs(t *testing.T) {
+	root := setupRepoRoot(t)
+	writeFile(t, root, "config/validation.yaml", `requiredAdmins:
+- k8s-ci-robot
+rules:
+  team-members-in-org:
+    severity: warning
+`)
+	o := Options{RepoRoot: root}
+
+	var out bytes.Buffer
+	if err := ValidateOrgs(o, &out); err == nil {
+		t.Fatalf("expected error for missing OWNERS file")
+	}
+	expected := `config/kubernetes/OWNERS: error: org kubernetes has no OWNERS file [owners-required]
+config/kubernetes/sig-network/teams.yaml:10:7: warning: thockin is a member of team sig-network-leads but not of org kubernetes [team-members-in-org]
+config/kubernetes/sig-network/teams.yaml:23:9: warning: shaneutt is a member of team sig-network-solo but not of org kubernetes [team-members-in-org]
+validated 1 org(s): 1 error(s), 2 warning(s)
+`
+	if out.String() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, out.String())
+	}
+
+	writeFile(t, root, "config/kubernetes/OWNERS", "approvers:\n- cblecker\n")
+	out.Reset()
+	if err := ValidateOrgs(o, &out); err != nil {
+		t.Errorf("unexpected error: %v\n%s", err, out.String())
+	}
+}
@@ -0,0 +1,241 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/prow/pkg/github"
+
+	"k8s.io/org/pkg/orgconfig"
+)
+
+// formats of the whois and access reports
+const (
+	reportFormatText = "text"
+	reportFormatJSON = "json"
+)
+
+var reportFormats = []string{reportFormatText, reportFormatJSON}
+
+// WhoisReport lists everything a user has across orgs, teams and OWNERS
+// files.
+type WhoisReport struct {
+	User string     `json:"user"`
+	Orgs []WhoisOrg `json:"orgs"`
+	// Owners are the entries of the OWNERS files under config/ listing the
+	// user.
+	Owners []OwnerEntry `json:"owners"`
+}
+
+// WhoisOrg is the access of a user to an org.
+type WhoisOrg struct {
+	Org string `json:"org"`
+	// Role is admin or member, or empty if the user is only listed in teams.
+	Role string `json:"role,omitempty"`
+	File string `json:"file,omitempty"`
+	// BasePermission is the permission the user has on every repo of the org,
+	// admin for org admins and the default repository permission for members.
+	BasePermission string      `json:"basePermission,omitempty"`
+	Teams          []WhoisTeam `json:"teams,omitempty"`
+	Repos          []WhoisRepo `json:"repos,omitempty"`
+}
+
+// WhoisTeam is a membership of a user in a team.
+type WhoisTeam struct {
+	// Team is the full name of the team, with parent teams separated by "/".
+	Team string `json:"team"`
+	Role string `json:"role"`
+	File string `json:"file"`
+}
+
+// WhoisRepo is the effective permission of a user on a repo through teams.
+type WhoisRepo struct {
+	Repo       string       `json:"repo"`
+	Permission string       `json:"permission"`
+	Grants     []WhoisGrant `json:"grants"`
+}
+
+// WhoisGrant is a team granting a permission on a repo.
+type WhoisGrant struct {
+	// Team is the full name of the team listing the repo.
+	Team string `json:"team"`
+	// Via is set to the full name of the child team the user is a member of if
+	// the permission is inherited from a parent team.
+	Via string `json:"via,omitempty"`
+}
+
+// Whois builds the report of username across the orgs in o.Orgs, or all orgs
+// if none are set, and writes it to out.
+func Whois(o Options, username string, out io.Writer) error {
+	repo := &orgconfig.Repo{Root: o.RepoRoot, AllowOverride: o.AllowOverride}
+	orgs := o.Orgs
+	if len(orgs) == 0 {
+		var err error
+		if orgs, err = repo.OrgNames(); err != nil {
+			return err
+		}
+	}
+
+	report := WhoisReport{User: username, Orgs: []WhoisOrg{}, Owners: []OwnerEntry{}}
+	for _, name := range orgs {
+		orgCfg, err := repo.LoadOrg(name)
+		if err != nil {
+			return err
+		}
+		if w, ok := whoisOrg(orgCfg, username); ok {
+			report.Orgs = append(report.Orgs, w)
+		}
+	}
+
+	owners, err := loadConfigOwners(o.RepoRoot)
+	if err != nil {
+		return fmt.Errorf("reading OWNERS files: %s", err)
+	}
+	if entries := owners[strings.ToLower(username)]; len(entries) > 0 {
+		report.Owners = entries
+	}
+
+	if o.WhoisFormat == reportFormatJSON {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	}
+	writeWhoisText(out, report)
+	return nil
+}
+
+// whoisOrg returns the access of username to the org and whether they are
+// listed in it at all.
+func whoisOrg(o *orgconfig.Org, username string) (WhoisOrg, bool) {
+	memberships := o.Memberships(username)
+	if len(memberships) == 0 {
+		return WhoisOrg{}, false
+	}
+	w := WhoisOrg{Org: o.Name}
+
+	type grant struct {
+		permission github.RepoPermissionLevel
+		WhoisGrant
+	}
+	grants := map[string][]grant{}
+	for _, m := range memberships {
+		role := strings.TrimSuffix(string(m.Role), "s")
+		if m.Team == "" {
+			w.Role, w.File = role, m.File
+			continue
+		}
+		w.Teams = append(w.Teams, WhoisTeam{Team: m.Team, Role: role, File: m.File})
+
+		// child teams inherit the repos of their parents
+		parts := strings.Split(m.Team, "/")
+		for i := len(parts) - 1; i >= 0; i-- {
+			t, ok := o.Team(parts[i])
+			if !ok {
+				continue
+			}
+			for repoName, permission := range t.Repos {
+				g := grant{permission: permission, WhoisGrant: WhoisGrant{Team: t.FullName()}}
+				if i < len(parts)-1 {
+					g.Via = m.Team
+				}
+				grants[repoName] = append(grants[repoName], g)
+			}
+		}
+	}
+
+	switch w.Role {
+	case "admin":
+		w.BasePermission = string(github.Admin)
+	case "member":
+		w.BasePermission = string(github.Read)
+		if p := o.Config.DefaultRepositoryPermission; p != nil {
+			w.BasePermission = string(*p)
+		}
+	}
+
+	for repoName, gs := range grants {
+		var best github.RepoPermissionLevel
+		for _, g := range gs {
+			if orgconfig.PermissionRank(g.permission) > orgconfig.PermissionRank(best) {
+				best = g.permission
+			}
+		}
+		r := WhoisRepo{Repo: repoName, Permission: string(best)}
+		// a team the user is a member of is listed once, not also through
+		// its child teams
+		sort.SliceStable(gs, func(i, j int) bool {
+			return gs[i].Via == "" && gs[j].Via != ""
+		})
+		seen := map[string]bool{}
+		for _, g := range gs {
+			if g.permission == best && !seen[g.Team] {
+				seen[g.Team] = true
+				r.Grants = append(r.Grants, g.WhoisGrant)
+			}
+		}
+		sort.Slice(r.Grants, func(i, j int) bool {
+			return r.Grants[i].Team < r.Grants[j].Team
+		})
+		w.Repos = append(w.Repos, r)
+	}
+	sort.Slice(w.Repos, func(i, j int) bool {
+		return w.Repos[i].Repo < w.Repos[j].Repo
+	})
+	return w, true
+}
+
+func writeWhoisText(w io.Writer, r WhoisReport) {
+	fmt.Fprintf(w, "%s:\n", r.User)
+	if len(r.Orgs) == 0 {
+		fmt.Fprintln(w, "  not listed in any org")
+	}
+	for _, o := range r.Orgs {
+		if o.Role != "" {
+			fmt.Fprintf(w, "  %s: %s (%s)\n", o.Org, o.Role, o.File)
+			fmt.Fprintf(w, "    %s on every repo\n", o.BasePermission)
+		} else {
+			fmt.Fprintf(w, "  %s: not a member\n", o.Org)
+		}
+		for _, t := range o.Teams {
+			fmt.Fprintf(w, "    team %s: %s (%s)\n", t.Team, t.Role, t.File)
+		}
+		for _, repo := range o.Repos {
+			var via []string
+			for _, g := range repo.Grants {
+				if g.Via != "" {
+					via = append(via, fmt.Sprintf("%s through %s", g.Team, g.Via))
+				} else {
+					via = append(via, g.Team)
+				}
+			}
+			fmt.Fprintf(w, "    repo %s: %s via %s\n", repo.Repo, repo.Permission, strings.Join(via, ", "))
+		}
+	}
+	if len(r.Owners) == 0 {
+		fmt.Fprintln(w, "  not listed in any OWNERS file under config/")
+		return
+	}
+	fmt.Fprintln(w, "  OWNERS:")
+	for _, e := range r.Owners {
+		fmt.Fprintf(w, "    %s\n", e)
+	}
+}
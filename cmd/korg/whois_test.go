@@ -0,0 +1,68 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestWhois(t *testing.T) {
+	root := setupRepoRoot(t)
+	writeFile(t, root, "config/kubernetes/sig-network/sig-network-leads/teams.yaml", `teams:
+  sig-network-leads-emeritus:
+    members:
+    - aojea
+    repos:
+      ingress-gce: write
+      kube-proxy: maintain
+`)
+	writeFile(t, root, "config/kubernetes/OWNERS", "approvers:\n- network-leads\n")
+	writeFile(t, root, "OWNERS_ALIASES", "aliases:\n  network-leads:\n  - AOJEA\n")
+
+	expected := `Aojea:
+  kubernetes: member (config/kubernetes/org.yaml)
+    read on every repo
+    team sig-network-leads: member (config/kubernetes/sig-network/teams.yaml)
+    team sig-network-leads/sig-network-leads-emeritus: member (config/kubernetes/sig-network/sig-network-leads/teams.yaml)
+    repo ingress-gce: admin via sig-network-leads
+    repo kube-proxy: maintain via sig-network-leads/sig-network-leads-emeritus
+  OWNERS:
+    config/kubernetes/OWNERS (approver via network-leads)
+`
+	var out bytes.Buffer
+	if err := Whois(Options{RepoRoot: root, WhoisFormat: reportFormatText}, "Aojea", &out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out.String() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, out.String())
+	}
+
+	out.Reset()
+	if err := Whois(Options{RepoRoot: root, WhoisFormat: reportFormatJSON}, "nobody", &out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var report WhoisReport
+	if err := json.Unmarshal(out.Bytes(), &report); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := (WhoisReport{User: "nobody", Orgs: []WhoisOrg{}, Owners: []OwnerEntry{}}); !reflect.DeepEqual(report, expected) {
+		t.Errorf("expected %#v, got %#v", expected, report)
+	}
+}
@@ -20,11 +20,13 @@ import (
 	"flag"
 	"fmt"
 	"os"
-	"path/filepath"
 	"strings"
 
 	"sigs.k8s.io/prow/pkg/config/org"
 
+	"k8s.io/org/pkg/diagnostics"
+	"k8s.io/org/pkg/orgconfig"
+
 	"github.com/sirupsen/logrus"
 	"sigs.k8s.io/yaml"
 )
@@ -61,16 +63,20 @@ func (fm flagMap) Set(s string) error {
 }
 
 type options struct {
-	orgs        flagMap
-	mergeTeams  bool
-	ignoreTeams bool
+	orgs              flagMap
+	mergeTeams        bool
+	ignoreTeams       bool
+	allowOverride     bool
+	githubAnnotations bool
 }
 
 func main() {
 	o := options{orgs: flagMap{}}
 	flag.Var(o.orgs, "org-part", "Each instance adds an org-name=org.yaml part")
-	flag.BoolVar(&o.mergeTeams, "merge-teams", false, "Merge team-name/team.yaml files in each org.yaml dir")
+	flag.BoolVar(&o.mergeTeams, "merge-teams", false, "Merge team-name/teams.yaml files in each org.yaml dir, with nested dirs adding child teams")
 	flag.BoolVar(&o.ignoreTeams, "ignore-teams", false, "Never configure teams")
+	flag.BoolVar(&o.allowOverride, "allow-override", false, "Allow teams.yaml files to redefine a top-level team defined in an earlier file instead of failing. Overrides are logged")
+	flag.BoolVar(&o.githubAnnotations, "github-annotations", diagnostics.GitHubActions(), "Also print errors as GitHub Actions annotations")
 	flag.Parse()
 
 	for _, a := range flag.Args() {
@@ -84,7 +90,9 @@ func main() {
 
 	cfg, err := loadOrgs(o)
 	if err != nil {
-		logrus.Fatalf("Failed to load orgs: %v", err)
+		printer := &diagnostics.Printer{W: os.Stderr, Annotate: o.githubAnnotations}
+		printer.Error(err)
+		logrus.Fatal("Failed to load orgs")
 	}
 	pc := org.FullConfig{
 		Orgs: cfg,
@@ -96,64 +104,30 @@ func main() {
 	fmt.Println(string(out))
 }
 
-func unmarshalFromFile(path string) (*org.Config, error) {
-	buf, err := os.ReadFile(path)
-	if err != nil {
-		return nil, fmt.Errorf("read: %v", err)
-	}
-
-	return unmarshal(buf)
-}
-
-func unmarshal(buf []byte) (*org.Config, error) {
-	var cfg org.Config
-	if err := yaml.Unmarshal(buf, &cfg, yaml.DisallowUnknownFields); err != nil {
-		return nil, fmt.Errorf("unmarshal: %v", err)
-	}
-	return &cfg, nil
-}
-
 func loadOrgs(o options) (map[string]org.Config, error) {
 	config := map[string]org.Config{}
 	for name, path := range o.orgs {
-		cfg, err := unmarshalFromFile(path)
-		if err != nil {
-			return nil, fmt.Errorf("error in %s: %v", path, err)
-		}
-		switch {
-		case o.ignoreTeams:
-			cfg.Teams = nil
-		case o.mergeTeams:
-			if cfg.Teams == nil {
-				cfg.Teams = map[string]org.Team{}
-			}
-			prefix := filepath.Dir(path)
-			err := filepath.Walk(prefix, func(path string, info os.FileInfo, err error) error {
-				switch {
-				case path == prefix:
-					return nil // Skip base dir
-				case info.IsDir() && filepath.Dir(path) != prefix:
-					logrus.Infof("Skipping %s and its children", path)
-					return filepath.SkipDir // Skip prefix/foo/bar/ dirs
-				case !info.IsDir() && filepath.Dir(path) == prefix:
-					return nil // Ignore prefix/foo files
-				case filepath.Base(path) == "teams.yaml":
-					teamCfg, err := unmarshalFromFile(path)
-					if err != nil {
-						return fmt.Errorf("error in %s: %v", path, err)
-					}
-
-					for name, team := range teamCfg.Teams {
-						cfg.Teams[name] = team
-					}
-				}
-				return nil
-			})
+		if !o.mergeTeams {
+			cfg, err := orgconfig.ReadFile(path, nil)
 			if err != nil {
-				return nil, fmt.Errorf("merge teams %s: %v", path, err)
+				return nil, err
 			}
+			if o.ignoreTeams {
+				cfg.Teams = nil
+			}
+			config[name] = *cfg
+			continue
+		}
+
+		repo := &orgconfig.Repo{AllowOverride: o.allowOverride}
+		orgCfg, err := repo.LoadOrgFile(name, path)
+		if err != nil {
+			return nil, err
+		}
+		for _, override := range orgCfg.Overrides {
+			logrus.Warn(override)
 		}
-		config[name] = *cfg
+		config[name] = orgCfg.Config
 	}
 	return config, nil
 }
@@ -17,8 +17,9 @@ limitations under the License.
 package main
 
 import (
-	"bytes"
 	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
 )
 
@@ -45,33 +46,30 @@ teams:
       abc: write
 `
 
-func TestStrictUnmarshalling(t *testing.T) {
-	cases := []struct {
-		repoKey     string
-		expectError bool
-		desc        string
-	}{
-		{
-			repoKey:     "repos",
-			expectError: false,
-			desc:        "with a valid field",
-		},
-		{
-			repoKey:     "somethingBizzare",
-			expectError: true,
-			desc:        "with an invalid field",
-		},
+func TestLoadOrgsDuplicateTeams(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "org.yaml")
+	if err := os.WriteFile(path, []byte(fmt.Sprintf(testOrgConfig, "repos")), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "sig-abc"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "sig-abc", "teams.yaml"), []byte("teams:\n  Team-ABC:\n    privacy: closed\n"), 0644); err != nil {
+		t.Fatal(err)
 	}
 
-	for _, c := range cases {
-		_, err := unmarshal(
-			bytes.NewBufferString(fmt.Sprintf(testOrgConfig, c.repoKey)).Bytes(),
-		)
-		if !c.expectError && err != nil {
-			t.Errorf("unexpected error for %s: %v", c.desc, err)
-		}
-		if c.expectError && err == nil {
-			t.Errorf("expected error for %s", c.desc)
-		}
+	o := options{orgs: flagMap{"org": path}, mergeTeams: true}
+	if _, err := loadOrgs(o); err == nil {
+		t.Errorf("expected error for team defined twice")
+	}
+
+	o.allowOverride = true
+	cfg, err := loadOrgs(o)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := cfg["org"].Teams["Team-ABC"]; !ok || len(cfg["org"].Teams) != 1 {
+		t.Errorf("expected team-abc to be overridden by Team-ABC, got %v", cfg["org"].Teams)
 	}
 }
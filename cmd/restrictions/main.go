@@ -19,153 +19,97 @@ package main
 import (
 	"errors"
 	"flag"
-	"fmt"
 	"os"
 	"path/filepath"
-	"regexp"
+	"sort"
 
 	"k8s.io/org/cmd/helpers"
+	"k8s.io/org/pkg/diagnostics"
+	"k8s.io/org/pkg/orgconfig"
+	"k8s.io/org/pkg/restrictions"
 
-	"github.com/bmatcuk/doublestar"
 	"github.com/sirupsen/logrus"
-	"sigs.k8s.io/yaml"
-)
-
-var (
-	emptyRegexp        = regexp.MustCompile("")
-	defaultRestriction = Restriction{Path: "*", AllowedReposRe: []*regexp.Regexp{emptyRegexp}}
 )
 
 var errRestrictionViolation = errors.New("restriction violated")
 
-type Config struct {
-	Restrictions []Restriction `json:"restrictions"`
-}
-
-type Restriction struct {
-	Path           string   `json:"path"`
-	AllowedRepos   []string `json:"allowedRepos,omitempty"`
-	AllowedReposRe []*regexp.Regexp
-}
-
 type options struct {
-	orgs         helpers.FlagMap
-	restrictions string
+	orgs              helpers.FlagMap
+	restrictions      string
+	githubAnnotations bool
 }
 
 func main() {
 	o := options{orgs: helpers.FlagMap{}}
 	flag.Var(o.orgs, "orgs", "Each instance adds an org-name=org.yaml part")
 	flag.StringVar(&o.restrictions, "restrictions", "restrictions.yaml", "path to a configuration file containing restrictions")
+	flag.BoolVar(&o.githubAnnotations, "github-annotations", diagnostics.GitHubActions(), "Also print violations as GitHub Actions annotations")
 	flag.Parse()
 
 	for _, a := range flag.Args() {
 		o.orgs.Set(a)
 	}
 
-	cfg, err := unmarshalPathToRestrictionsConfig(o.restrictions)
+	cfg, err := restrictions.Load(o.restrictions)
 	if err != nil {
-		logrus.Fatalf("Failed to unmarshal restrictions config: %v", err)
-	}
-
-	restrictions, err := compileRegexps(cfg.Restrictions)
-	if err != nil {
-		logrus.Fatalf("Failed to compile regexp for restrictions config: %v", err)
+		logrus.Fatalf("Failed to load restrictions config: %v", err)
 	}
 
+	printer := &diagnostics.Printer{W: os.Stderr, Annotate: o.githubAnnotations}
 	var restrictionViolated bool
 	for name, path := range o.orgs {
 		logrus.Infof("Validating restrictions for %s org", name)
-		prefix := filepath.Dir(path)
-		err := filepath.Walk(prefix, func(path string, info os.FileInfo, err error) error {
-			switch {
-			case path == prefix:
-				return nil // Skip base dir
-			case info.IsDir() && filepath.Dir(path) != prefix:
-				logrus.Infof("Skipping %s and its children", path)
-				return filepath.SkipDir // Skip prefix/foo/bar/ dirs
-			case !info.IsDir() && filepath.Dir(path) == prefix && filepath.Base(path) != "org.yaml":
-				return nil // Ignore prefix/foo files
-			case filepath.Base(path) == "teams.yaml" || filepath.Base(path) == "org.yaml":
-				if err := resolveRestriction(restrictions, path); err != nil {
-					if errors.Is(err, errRestrictionViolation) {
-						restrictionViolated = true
-					}
-					logrus.Error(err)
-				}
-			}
-			return nil
-		})
+		teamsFiles, err := orgconfig.TeamsFiles(filepath.Dir(path))
 		if err != nil {
 			logrus.Fatalf("Failed to walk through files at %s", path)
 		}
+		for _, file := range append([]string{path}, teamsFiles...) {
+			violations, err := resolveRestriction(cfg.Restrictions, file)
+			if err != nil {
+				printer.Error(err)
+			}
+			for _, v := range violations {
+				restrictionViolated = true
+				printer.Error(v)
+			}
+		}
 	}
 	if restrictionViolated {
 		logrus.Fatal("restriction violation(s) detected.")
 	}
 }
 
-func unmarshalPathToRestrictionsConfig(path string) (*Config, error) {
-	buf, err := os.ReadFile(path)
+// resolveRestriction returns a diagnostic, pointing at the repo entry, for
+// every repo of a team defined in the file at path that isn't allowed by the
+// restriction matching path.
+func resolveRestriction(rs []restrictions.Restriction, path string) ([]diagnostics.Diagnostic, error) {
+	sources := diagnostics.SourceMap{}
+	orgCfg, err := orgconfig.ReadFile(path, sources)
 	if err != nil {
-		return nil, fmt.Errorf("read restrictions config: %v", err)
-	}
-	var restrictionsCfg Config
-	if err := yaml.Unmarshal(buf, &restrictionsCfg); err != nil {
-		return nil, fmt.Errorf("unmarshal restrictions config: %v", err)
+		return nil, err
 	}
-	return &restrictionsCfg, nil
-}
+	r := restrictions.For(rs, path)
 
-func compileRegexps(restrictions []Restriction) ([]Restriction, error) {
-	ret := make([]Restriction, 0, len(restrictions))
-	for _, r := range restrictions {
-		r.AllowedReposRe = make([]*regexp.Regexp, 0, len(r.AllowedRepos))
-		for _, repo := range r.AllowedRepos {
-			re, err := regexp.Compile(repo)
-			if err != nil {
-				return restrictions, fmt.Errorf("failed to parse repo pattern %q: %v", repo, err)
-			}
-			r.AllowedReposRe = append(r.AllowedReposRe, re)
-		}
-		ret = append(ret, r)
+	teamNames := make([]string, 0, len(orgCfg.Teams))
+	for teamName := range orgCfg.Teams {
+		teamNames = append(teamNames, teamName)
 	}
-	return ret, nil
-}
+	sort.Strings(teamNames)
 
-func resolveRestriction(restrictions []Restriction, path string) error {
-	orgCfg, err := helpers.UnmarshalPathToOrgConfig(path)
-	if err != nil {
-		return fmt.Errorf("error in unmarshalling path %s: %v", path, err)
-	}
-	r := getRestrictionForPath(restrictions, path)
-
-	var err2 error
-	for teamName, team := range orgCfg.Teams {
-		for repo := range team.Repos {
-			if !matchesRegexList(repo, r.AllowedReposRe) {
-				err2 = errRestrictionViolation
-				err2 = fmt.Errorf("%w\n%q: cannot define repo %q for team %q", err2, path, repo, teamName)
-			}
+	var violations []diagnostics.Diagnostic
+	for _, teamName := range teamNames {
+		repos := make([]string, 0, len(orgCfg.Teams[teamName].Repos))
+		for repo := range orgCfg.Teams[teamName].Repos {
+			repos = append(repos, repo)
 		}
-	}
-	return err2
-}
+		sort.Strings(repos)
 
-func getRestrictionForPath(restrictions []Restriction, path string) Restriction {
-	for _, r := range restrictions {
-		if match, err := doublestar.Match(r.Path, path); err == nil && match {
-			return r
-		}
-	}
-	return defaultRestriction
-}
-
-func matchesRegexList(s string, list []*regexp.Regexp) bool {
-	for _, r := range list {
-		if r.MatchString(s) {
-			return true
+		for _, repo := range repos {
+			if !r.Allows(repo) {
+				loc := sources.Locate(path, "teams", teamName, "repos", repo)
+				violations = append(violations, diagnostics.Errorf(loc, "%s: cannot define repo %q for team %q", errRestrictionViolation, repo, teamName))
+			}
 		}
 	}
-	return false
+	return violations, nil
 }
@@ -0,0 +1,66 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"k8s.io/org/pkg/diagnostics"
+	"k8s.io/org/pkg/restrictions"
+)
+
+func TestResolveRestriction(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "teams.yaml")
+	err := os.WriteFile(path, []byte(`teams:
+  sig-foo:
+    repos:
+      kubectl: write
+      website: admin
+  sig-bar:
+    repos:
+      community: read
+`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rs, err := restrictions.Compile([]restrictions.Restriction{{Path: "**/teams.yaml", AllowedRepos: []string{"^kube"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	violations, err := resolveRestriction(rs, path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []diagnostics.Diagnostic{
+		{
+			Location: diagnostics.Location{File: path, Line: 8, Column: 7},
+			Message:  `restriction violated: cannot define repo "community" for team "sig-bar"`,
+		},
+		{
+			Location: diagnostics.Location{File: path, Line: 5, Column: 7},
+			Message:  `restriction violated: cannot define repo "website" for team "sig-foo"`,
+		},
+	}
+	if !reflect.DeepEqual(violations, expected) {
+		t.Errorf("expected %v, got %v", expected, violations)
+	}
+}
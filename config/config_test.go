@@ -19,14 +19,13 @@ package config
 import (
 	"fmt"
 	"os"
-	"sort"
 	"strings"
 	"testing"
 
-	"k8s.io/apimachinery/pkg/util/sets"
 	"sigs.k8s.io/prow/pkg/config/org"
-	"sigs.k8s.io/prow/pkg/github"
 
+	"k8s.io/org/pkg/diagnostics"
+	"k8s.io/org/pkg/validate"
 	"sigs.k8s.io/yaml"
 )
 
@@ -53,166 +52,18 @@ func TestMain(m *testing.M) {
 	os.Exit(m.Run())
 }
 
-type owners struct {
-	Reviewers []string `json:"reviewers,omitempty"`
-	Approvers []string `json:"approvers"`
-}
-
-func readInto(path string, i interface{}) error {
-	buf, err := os.ReadFile(path)
-	if err != nil {
-		return fmt.Errorf("read: %v", err)
-	}
-	if err := yaml.Unmarshal(buf, i); err != nil {
-		return fmt.Errorf("unmarshal: %v", err)
-	}
-	return nil
-}
-
-func loadOwners(dir string) (*owners, error) {
-	var own owners
-	if err := readInto(dir+"/OWNERS", &own); err != nil {
-		return nil, err
-	}
-	return &own, nil
-}
-
-func loadOrg(dir string) (*org.Config, error) {
-	var cfg org.Config
-	if err := readInto(dir+"/org.yaml", &cfg); err != nil {
-		return nil, err
-	}
-	return &cfg, nil
-}
-
-func testDuplicates(list sets.String) error {
-	found := sets.String{}
-	dups := sets.String{}
-	all := list.List()
-	for _, i := range all {
-		if found.Has(i) {
-			dups.Insert(i)
-		}
-		found.Insert(i)
-	}
-	if n := len(dups); n > 0 {
-		return fmt.Errorf("%d duplicate names: %s", n, strings.Join(dups.List(), ", "))
-	}
-	return nil
-}
-
-func isSorted(list []string) bool {
-	items := make([]string, len(list))
-	for _, l := range list {
-		items = append(items, strings.ToLower(l))
-	}
-
-	return sort.StringsAreSorted(items)
-}
-
-func normalize(s sets.String) sets.String {
-	out := sets.String{}
-	for i := range s {
-		out.Insert(github.NormLogin(i))
-	}
-	return out
-}
-
-// testTeamMembers ensures that a user is not a maintainer and member at the same time,
-// there are no duplicate names in the list and all users are org members.
-func testTeamMembers(teams map[string]org.Team, admins sets.String, orgMembers sets.String, orgName string) []error {
-	var errs []error
-	for teamName, team := range teams {
-		teamMaintainers := sets.NewString(team.Maintainers...)
-		teamMembers := sets.NewString(team.Members...)
-
-		teamMaintainers = normalize(teamMaintainers)
-		teamMembers = normalize(teamMembers)
-
-		// ensure all teams have privacy as closed
-		if team.Privacy == nil || (team.Privacy != nil && *team.Privacy != org.Closed) {
-			errs = append(errs, fmt.Errorf("The team %s in org %s doesn't have the `privacy: closed` field", teamName, orgName))
-		}
-
-		// check for non-admins in maintainers list
-		if nonAdminMaintainers := teamMaintainers.Difference(admins); len(nonAdminMaintainers) > 0 {
-			errs = append(errs, fmt.Errorf("The team %s in org %s has non-admins listed as maintainers; these users should be in the members list instead: %s", teamName, orgName, strings.Join(nonAdminMaintainers.List(), ",")))
-		}
-
-		// check for users in both maintainers and members
-		if both := teamMaintainers.Intersection(teamMembers); len(both) > 0 {
-			errs = append(errs, fmt.Errorf("The team %s in org %s has users in both maintainer admin and member roles: %s", teamName, orgName, strings.Join(both.List(), ", ")))
-		}
-
-		// check for duplicates
-		if err := testDuplicates(teamMaintainers); err != nil {
-			errs = append(errs, fmt.Errorf("The team %s in org %s has duplicate maintainers: %v", teamName, orgName, err))
-		}
-		if err := testDuplicates(teamMembers); err != nil {
-			errs = append(errs, fmt.Errorf("The team %s in org %s has duplicate members: %v", teamMembers, orgName, err))
-		}
-
-		// check if all are org members
-		if missing := teamMembers.Difference(orgMembers); len(missing) > 0 {
-			errs = append(errs, fmt.Errorf("The following members of team %s are not %s org members: %s", teamName, orgName, strings.Join(missing.List(), ", ")))
-		}
-
-		// check if admins are a regular member of team
-		if adminTeamMembers := teamMembers.Intersection(admins); len(adminTeamMembers) > 0 {
-			errs = append(errs, fmt.Errorf("The team %s in org %s has org admins listed as members; these users should be in the maintainers list instead, and cannot be on the members list: %s", teamName, orgName, strings.Join(adminTeamMembers.List(), ", ")))
-		}
-
-		// check if lists are sorted
-		if !isSorted(team.Maintainers) {
-			errs = append(errs, fmt.Errorf("The team %s in org %s has an unsorted list of maintainers", teamName, orgName))
-		}
-		if !isSorted(team.Members) {
-			errs = append(errs, fmt.Errorf("The team %s in org %s has an unsorted list of members", teamName, orgName))
-		}
-
-		if team.Children != nil {
-			errs = append(errs, testTeamMembers(team.Children, admins, orgMembers, orgName)...)
-		}
-	}
-	return errs
-}
-
-func testOrg(targetDir string, t *testing.T) {
-	cfg, err := loadOrg(targetDir)
-	if err != nil {
-		t.Fatalf("failed to load org.yaml: %v", err)
-	}
-	own, err := loadOwners(targetDir)
+// TestAllOrgs runs the rules of pkg/validate, configured by validation.yaml,
+// against every org. This is equivalent to running korg validate.
+func TestAllOrgs(t *testing.T) {
+	policy, err := validate.LoadConfig("validation.yaml")
 	if err != nil {
-		t.Fatalf("failed to load OWNERS: %v", err)
-	}
-
-	members := normalize(sets.NewString(cfg.Members...))
-	admins := normalize(sets.NewString(cfg.Admins...))
-	allOrgMembers := members.Union(admins)
-
-	reviewers := normalize(sets.NewString(own.Reviewers...))
-	approvers := normalize(sets.NewString(own.Approvers...))
-
-	if n := len(approvers); n < 5 {
-		t.Errorf("Require at least 5 approvers, found %d: %s", n, strings.Join(approvers.List(), ", "))
-	}
-
-	if missing := reviewers.Difference(allOrgMembers); len(missing) > 0 {
-		t.Errorf("The following reviewers must be members: %s", strings.Join(missing.List(), ", "))
+		t.Fatalf("cannot load validation config: %v", err)
 	}
-	if missing := approvers.Difference(allOrgMembers); len(missing) > 0 {
-		t.Errorf("The following approvers must be members: %s", strings.Join(missing.List(), ", "))
+	rules := validate.DefaultRegistry()
+	if err := policy.Check(rules); err != nil {
+		t.Fatalf("invalid validation config: %v", err)
 	}
-	if err := testDuplicates(reviewers); err != nil {
-		t.Errorf("duplicate reviewers: %v", err)
-	}
-	if err := testDuplicates(approvers); err != nil {
-		t.Errorf("duplicate approvers: %v", err)
-	}
-}
 
-func TestAllOrgs(t *testing.T) {
 	f, err := os.Open(".")
 	if err != nil {
 		t.Fatalf("cannot read config: %v", err)
@@ -230,44 +81,78 @@ func TestAllOrgs(t *testing.T) {
 			continue
 		}
 		t.Run(n, func(t *testing.T) {
-			if _, ok := cfg.Orgs[n]; !ok {
-				t.Errorf("%s missing from generated config.yaml", n)
+			merged, ok := cfg.Orgs[n]
+			if !ok {
+				report(t, validate.Violation{
+					Severity: validate.SeverityError,
+					Location: validate.Location{File: "config/" + n + "/org.yaml"},
+					Message:  fmt.Sprintf("%s missing from generated config.yaml", n),
+					Rule:     "merged-config",
+				})
+				return
 			}
-			testOrg(n, t)
-		})
-	}
-
-	for _, org := range cfg.Orgs {
-		members := normalize(sets.NewString(org.Members...))
-		admins := normalize(sets.NewString(org.Admins...))
-		allOrgMembers := members.Union(admins)
 
-		if both := admins.Intersection(members); len(both) > 0 {
-			t.Errorf("users in both org admin and member roles for org '%s': %s", *org.Name, strings.Join(both.List(), ", "))
-		}
+			o, err := validate.LoadOrg("..", n)
+			if err != nil {
+				t.Fatalf("failed to load org: %v", err)
+			}
+			if diff := configDiff(o.Config, merged); diff != "" {
+				report(t, validate.Violation{
+					Severity: validate.SeverityError,
+					Location: validate.Location{File: "config/" + n + "/org.yaml"},
+					Message:  fmt.Sprintf("generated config.yaml differs from the config of %s: %s", n, diff),
+					Rule:     "merged-config",
+				})
+			}
 
-		if !admins.Has("k8s-ci-robot") {
-			t.Errorf("k8s-ci-robot must be an admin")
-		}
+			// validate what peribolos deploys, locating violations in the
+			// files the org was loaded from
+			o.Config = merged
+			for _, v := range rules.Validate([]*validate.Org{o}, policy) {
+				report(t, v)
+			}
+		})
+	}
+}
 
-		if err := testDuplicates(admins); err != nil {
-			t.Errorf("duplicate admins: %v", err)
-		}
-		if err := testDuplicates(allOrgMembers); err != nil {
-			t.Errorf("duplicate members: %v", err)
-		}
-		if !isSorted(org.Admins) {
-			t.Errorf("admins for %s org are unsorted", *org.Name)
+// configDiff returns the first line at which want and got differ once
+// marshaled, or "" if they are the same. Marshaling ignores differences that
+// don't survive a round trip through YAML, like nil and empty lists.
+func configDiff(want, got org.Config) string {
+	w, err := yaml.Marshal(want)
+	if err != nil {
+		return err.Error()
+	}
+	g, err := yaml.Marshal(got)
+	if err != nil {
+		return err.Error()
+	}
+	wantLines, gotLines := strings.Split(string(w), "\n"), strings.Split(string(g), "\n")
+	for i := 0; i < len(wantLines) || i < len(gotLines); i++ {
+		var wl, gl string
+		if i < len(wantLines) {
+			wl = wantLines[i]
 		}
-		if !isSorted(org.Members) {
-			t.Errorf("members for %s org are unsorted", *org.Name)
+		if i < len(gotLines) {
+			gl = gotLines[i]
 		}
-
-		if errs := testTeamMembers(org.Teams, admins, allOrgMembers, *org.Name); errs != nil {
-			for _, err := range errs {
-				t.Error(err)
-			}
+		if wl != gl {
+			return fmt.Sprintf("line %d: expected %q, got %q", i+1, wl, gl)
 		}
+	}
+	return ""
+}
 
+// report fails t for violations with error severity and logs the others.
+// Violations are also printed as annotations when running in GitHub Actions.
+func report(t *testing.T, v validate.Violation) {
+	t.Helper()
+	if diagnostics.GitHubActions() {
+		fmt.Println(v.Annotation())
+	}
+	if v.Severity == validate.SeverityError {
+		t.Error(v)
+	} else {
+		t.Log(v)
 	}
 }
@@ -25,17 +25,38 @@ if [[ -z ${WHO:-} ]]; then
    exit 1
 fi
 
+if [[ -z ${SPONSORS:-} && -z ${SPONSOR_OVERRIDE:-} ]]; then
+   echo "No sponsors specified. Specify the sponsors of the users with SPONSORS=sponsor1,sponsor2"
+   exit 1
+fi
+
 [ -z ${REPOS+x} ] && echo "No repos specified. Defaulting to kubernetes."
 REPOS=${REPOS:-"kubernetes"}
 
 cd "$SCRIPT_ROOT"
+
+MANIFEST=$(mktemp --suffix=.csv)
+trap 'rm -f "$MANIFEST"' EXIT
+
+echo "username" > "$MANIFEST"
 for username in ${WHO//,/ }
 do
-    echo "Adding $username to $REPOS"
-   if [ "$DRY_RUN" = true ]; then
-     echo "Running in dry run mode."
-     go run ./cmd/korg add "$username" --org "$REPOS"
-   else
-     go run ./cmd/korg add "$username" --org "$REPOS" --confirm
-   fi
+  echo "$username" >> "$MANIFEST"
 done
+
+# new members need two sponsors, see korg add --help
+ARGS=(--from-file "$MANIFEST" --org "$REPOS")
+if [[ -n ${SPONSORS:-} ]]; then
+  ARGS+=(--sponsor "$SPONSORS")
+fi
+if [[ -n ${SPONSOR_OVERRIDE:-} ]]; then
+  ARGS+=(--sponsor-override "$SPONSOR_OVERRIDE")
+fi
+
+echo "Adding ${WHO} to $REPOS"
+if [ "$DRY_RUN" = true ]; then
+  echo "Running in dry run mode."
+  go run ./cmd/korg add "${ARGS[@]}"
+else
+  go run ./cmd/korg add "${ARGS[@]}" --confirm
+fi
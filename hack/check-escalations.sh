@@ -0,0 +1,34 @@
+#!/usr/bin/env bash
+
+# Copyright The Kubernetes Authors.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+
+# Usage: hack/check-escalations.sh [base-ref] [escalations flags...]
+#
+# Merges the config at base-ref (default: origin/main), read from git without
+# checking it out, and in the working tree and reports the privilege
+# escalations between them, e.g.
+#   hack/check-escalations.sh origin/main --labels --fail-on=none
+
+set -o errexit
+set -o nounset
+set -o pipefail
+
+REPO_ROOT=$(cd "$(dirname "${BASH_SOURCE[0]}")/.." && pwd)
+cd "${REPO_ROOT}"
+
+BASE_REF="${1:-origin/main}"
+shift || true
+
+go run ./cmd/escalations --root="${REPO_ROOT}" --base-rev="${BASE_REF}" "$@"
@@ -0,0 +1,167 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package diagnostics reports problems in config files as file:line:col
+// diagnostics, optionally as GitHub Actions annotations, and maps entries of
+// YAML files back to their position.
+package diagnostics
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Location points at a position in a file. Line and Column are 1-indexed and
+// zero if unknown.
+type Location struct {
+	File   string `json:"file"`
+	Line   int    `json:"line,omitempty"`
+	Column int    `json:"column,omitempty"`
+}
+
+func (l Location) String() string {
+	switch {
+	case l.Line == 0:
+		return l.File
+	case l.Column == 0:
+		return fmt.Sprintf("%s:%d", l.File, l.Line)
+	default:
+		return fmt.Sprintf("%s:%d:%d", l.File, l.Line, l.Column)
+	}
+}
+
+// Diagnostic is a problem found at a location. It can be returned as an
+// error.
+type Diagnostic struct {
+	Location Location
+	Message  string
+}
+
+// Errorf returns a diagnostic at loc.
+func Errorf(loc Location, format string, args ...interface{}) Diagnostic {
+	return Diagnostic{Location: loc, Message: fmt.Sprintf(format, args...)}
+}
+
+func (d Diagnostic) Error() string {
+	return fmt.Sprintf("%s: %s", d.Location, d.Message)
+}
+
+// List is a list of diagnostics returned as a single error.
+type List []Diagnostic
+
+func (l List) Error() string {
+	msgs := make([]string, 0, len(l))
+	for _, d := range l {
+		msgs = append(msgs, d.Error())
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// GitHubActions reports whether we are running in a GitHub Actions workflow.
+func GitHubActions() bool {
+	return os.Getenv("GITHUB_ACTIONS") == "true"
+}
+
+// Annotation formats a GitHub Actions workflow command for a diagnostic of
+// the given level (error, warning or notice) at loc. Paths of files that
+// exist relative to the working directory are made relative to
+// GITHUB_WORKSPACE, if set, so annotations show up on the diff regardless of
+// the directory the tool was run from.
+func Annotation(level string, loc Location, message string) string {
+	props := []string{"file=" + escapeProperty(workspacePath(loc.File))}
+	if loc.Line > 0 {
+		props = append(props, fmt.Sprintf("line=%d", loc.Line))
+	}
+	if loc.Column > 0 {
+		props = append(props, fmt.Sprintf("col=%d", loc.Column))
+	}
+	return fmt.Sprintf("::%s %s::%s", level, strings.Join(props, ","), escapeData(message))
+}
+
+func workspacePath(file string) string {
+	workspace := os.Getenv("GITHUB_WORKSPACE")
+	if workspace == "" {
+		return file
+	}
+	if _, err := os.Stat(file); err != nil {
+		return file
+	}
+	abs, err := filepath.Abs(file)
+	if err != nil {
+		return file
+	}
+	rel, err := filepath.Rel(workspace, abs)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return file
+	}
+	return filepath.ToSlash(rel)
+}
+
+// escapeData and escapeProperty escape values the way the GitHub Actions
+// toolkit does.
+func escapeData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+func escapeProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
+
+// Printer writes diagnostics, followed by a GitHub Actions annotation for
+// each of them if Annotate is set.
+type Printer struct {
+	W        io.Writer
+	Annotate bool
+}
+
+// NewPrinter returns a printer writing to w that annotates diagnostics when
+// running in GitHub Actions.
+func NewPrinter(w io.Writer) *Printer {
+	return &Printer{W: w, Annotate: GitHubActions()}
+}
+
+// Print writes a diagnostic of the given level.
+func (p *Printer) Print(level string, loc Location, message string) {
+	fmt.Fprintf(p.W, "%s: %s: %s\n", loc, level, message)
+	if p.Annotate {
+		fmt.Fprintln(p.W, Annotation(level, loc, message))
+	}
+}
+
+// Error writes err as an error diagnostic. Errors that don't wrap a
+// diagnostic are printed as is.
+func (p *Printer) Error(err error) {
+	var l List
+	if errors.As(err, &l) {
+		for _, d := range l {
+			p.Print("error", d.Location, d.Message)
+		}
+		return
+	}
+	var d Diagnostic
+	if errors.As(err, &d) {
+		p.Print("error", d.Location, d.Message)
+		return
+	}
+	fmt.Fprintf(p.W, "error: %v\n", err)
+	if p.Annotate {
+		fmt.Fprintf(p.W, "::error::%s\n", escapeData(err.Error()))
+	}
+}
@@ -0,0 +1,135 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package diagnostics
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"sigs.k8s.io/prow/pkg/config/org"
+	"sigs.k8s.io/yaml"
+)
+
+const testTeams = `teams:
+  sig-foo:
+    members:
+    - alice
+    - "@Bob"
+    repos:
+      foo: write
+    teams:
+      sig-foo-child:
+        members:
+        - bob
+`
+
+func TestSourceMap(t *testing.T) {
+	m := SourceMap{}
+	if err := m.Add("teams.yaml", []byte(testTeams)); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name     string
+		got      Location
+		expected Location
+	}{
+		{
+			name:     "repo",
+			got:      m.Locate("teams.yaml", "teams", "sig-foo", "repos", "foo"),
+			expected: Location{File: "teams.yaml", Line: 7, Column: 7},
+		},
+		{
+			name:     "child team",
+			got:      m.Locate("teams.yaml", "teams", "sig-foo", "teams", "sig-foo-child"),
+			expected: Location{File: "teams.yaml", Line: 9, Column: 7},
+		},
+		{
+			name:     "missing key falls back to parent",
+			got:      m.Locate("teams.yaml", "teams", "sig-foo", "maintainers"),
+			expected: Location{File: "teams.yaml", Line: 2, Column: 3},
+		},
+		{
+			name:     "member",
+			got:      m.LocateItem("teams.yaml", "bob", "teams", "sig-foo", "members"),
+			expected: Location{File: "teams.yaml", Line: 5, Column: 7},
+		},
+		{
+			name:     "unknown file",
+			got:      m.Locate("org.yaml", "teams"),
+			expected: Location{File: "org.yaml"},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if c.got != c.expected {
+				t.Errorf("expected %v, got %v", c.expected, c.got)
+			}
+		})
+	}
+}
+
+func TestFromYAMLError(t *testing.T) {
+	cases := []struct {
+		name     string
+		contents string
+		expected Diagnostic
+	}{
+		{
+			name:     "unknown field",
+			contents: "teams:\n  foo:\n    privacy: closed\n    bogus: true\n",
+			expected: Diagnostic{Location: Location{File: "org.yaml", Line: 4, Column: 5}, Message: `unknown field "bogus"`},
+		},
+		{
+			name:     "syntax error",
+			contents: "teams:\n  foo:\n    members: [\n",
+			expected: Diagnostic{Location: Location{File: "org.yaml", Line: 3}, Message: "did not find expected node content"},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var cfg org.Config
+			err := yaml.Unmarshal([]byte(c.contents), &cfg, yaml.DisallowUnknownFields)
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if d := FromYAMLError("org.yaml", []byte(c.contents), fmt.Errorf("unmarshal: %v", err)); !reflect.DeepEqual(d, c.expected) {
+				t.Errorf("expected %#v, got %#v", c.expected, d)
+			}
+		})
+	}
+}
+
+func TestPrinter(t *testing.T) {
+	t.Setenv("GITHUB_WORKSPACE", "")
+	var b bytes.Buffer
+	p := &Printer{W: &b, Annotate: true}
+	p.Error(fmt.Errorf("loading: %w", Errorf(Location{File: "a,b.yaml", Line: 3, Column: 5}, "100%% broken\nreally")))
+	p.Error(fmt.Errorf("no location"))
+
+	expected := `a,b.yaml:3:5: error: 100% broken
+really
+::error file=a%2Cb.yaml,line=3,col=5::100%25 broken%0Areally
+error: no location
+::error::no location
+`
+	if b.String() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, b.String())
+	}
+}
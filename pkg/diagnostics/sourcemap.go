@@ -0,0 +1,162 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package diagnostics
+
+import (
+	"regexp"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+	"sigs.k8s.io/prow/pkg/github"
+)
+
+// SourceMap maps entries of YAML files, such as teams, members and repos, back
+// to their position in the file.
+type SourceMap map[string]*yaml.Node
+
+// Add parses the YAML in buf as the contents of file.
+func (m SourceMap) Add(file string, buf []byte) error {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(buf, &doc); err != nil {
+		return err
+	}
+	m[file] = &doc
+	return nil
+}
+
+func (m SourceMap) root(file string) *yaml.Node {
+	doc, ok := m[file]
+	if !ok || len(doc.Content) == 0 {
+		return nil
+	}
+	return doc.Content[0]
+}
+
+// Locate returns the location of the key at path in file, e.g. admins or
+// teams.foo.repos.bar. If the key doesn't exist the location of its nearest
+// existing parent is returned.
+func (m SourceMap) Locate(file string, path ...string) Location {
+	loc := Location{File: file}
+	n := m.root(file)
+	for _, p := range path {
+		key, value := mappingEntry(n, p)
+		if key == nil {
+			break
+		}
+		loc.Line, loc.Column = key.Line, key.Column
+		n = value
+	}
+	return loc
+}
+
+// LocateItems returns the locations of every item of the list at path in
+// file that matches login, in order. Logins are compared the same way GitHub
+// does, ignoring case and any leading @.
+func (m SourceMap) LocateItems(file, login string, path ...string) []Location {
+	n := m.root(file)
+	for _, p := range path {
+		_, n = mappingEntry(n, p)
+	}
+	if n == nil || n.Kind != yaml.SequenceNode {
+		return nil
+	}
+
+	var locs []Location
+	for _, item := range n.Content {
+		if github.NormLogin(item.Value) == github.NormLogin(login) {
+			locs = append(locs, Location{File: file, Line: item.Line, Column: item.Column})
+		}
+	}
+	return locs
+}
+
+// LocateItem returns the location of the first item matching login in the
+// list at path, or the location of the list if there is none.
+func (m SourceMap) LocateItem(file, login string, path ...string) Location {
+	if locs := m.LocateItems(file, login, path...); len(locs) > 0 {
+		return locs[0]
+	}
+	return m.Locate(file, path...)
+}
+
+// LocateKey returns the location of the first mapping key named key in file,
+// searching depth first.
+func (m SourceMap) LocateKey(file, key string) Location {
+	loc := Location{File: file}
+	var find func(n *yaml.Node) bool
+	find = func(n *yaml.Node) bool {
+		if n == nil {
+			return false
+		}
+		if n.Kind == yaml.MappingNode {
+			for i := 0; i+1 < len(n.Content); i += 2 {
+				if n.Content[i].Value == key {
+					loc.Line, loc.Column = n.Content[i].Line, n.Content[i].Column
+					return true
+				}
+				if find(n.Content[i+1]) {
+					return true
+				}
+			}
+			return false
+		}
+		for _, c := range n.Content {
+			if find(c) {
+				return true
+			}
+		}
+		return false
+	}
+	find(m.root(file))
+	return loc
+}
+
+func mappingEntry(n *yaml.Node, name string) (*yaml.Node, *yaml.Node) {
+	if n == nil || n.Kind != yaml.MappingNode {
+		return nil, nil
+	}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if n.Content[i].Value == name {
+			return n.Content[i], n.Content[i+1]
+		}
+	}
+	return nil, nil
+}
+
+var (
+	lineRe         = regexp.MustCompile(`line (\d+): `)
+	unknownFieldRe = regexp.MustCompile(`unknown field "([^"]+)"`)
+)
+
+// FromYAMLError turns an error unmarshalling the YAML in buf, the contents of
+// file, into a diagnostic pointing at the offending line where possible.
+func FromYAMLError(file string, buf []byte, err error) Diagnostic {
+	d := Diagnostic{Location: Location{File: file}, Message: err.Error()}
+	if m := unknownFieldRe.FindStringSubmatch(err.Error()); m != nil {
+		sm := SourceMap{}
+		if sm.Add(file, buf) == nil {
+			d.Location = sm.LocateKey(file, m[1])
+		}
+		d.Message = "unknown field " + strconv.Quote(m[1])
+		return d
+	}
+	if m := lineRe.FindStringSubmatchIndex(err.Error()); m != nil {
+		d.Location.Line, _ = strconv.Atoi(err.Error()[m[2]:m[3]])
+		d.Message = err.Error()[m[1]:]
+	}
+	return d
+}
@@ -0,0 +1,279 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package escalation finds the changes between two merged configs that grant
+// more privileges than a plain membership change, so that they can get extra
+// scrutiny in review.
+package escalation
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/prow/pkg/config/org"
+	"sigs.k8s.io/prow/pkg/github"
+
+	"k8s.io/org/pkg/orgconfig"
+)
+
+// Risk classifies how sensitive an escalation is.
+type Risk int
+
+const (
+	Medium Risk = iota + 1
+	High
+)
+
+func (r Risk) String() string {
+	switch r {
+	case High:
+		return "high"
+	case Medium:
+		return "medium"
+	}
+	return fmt.Sprintf("Risk(%d)", int(r))
+}
+
+// ParseRisk parses high or medium.
+func ParseRisk(s string) (Risk, error) {
+	switch strings.ToLower(s) {
+	case "high":
+		return High, nil
+	case "medium":
+		return Medium, nil
+	}
+	return 0, fmt.Errorf("invalid risk %q, must be one of: high, medium", s)
+}
+
+// Kind is the kind of privilege escalation.
+type Kind string
+
+const (
+	OrgAdminAdded           Kind = "org-admin-added"
+	DefaultPermissionRaised Kind = "default-permission-raised"
+	RepoAdminGranted        Kind = "repo-admin-granted"
+	RepoMaintainGranted     Kind = "repo-maintain-granted"
+	TeamMaintainerAdded     Kind = "team-maintainer-added"
+	TeamMadeSecret          Kind = "team-made-secret"
+)
+
+// Risk returns the risk of escalations of kind k.
+func (k Kind) Risk() Risk {
+	switch k {
+	case OrgAdminAdded, DefaultPermissionRaised, RepoAdminGranted:
+		return High
+	}
+	return Medium
+}
+
+// Label returns the label for PRs containing escalations of kind k.
+func (k Kind) Label() string {
+	return "privilege-escalation/" + string(k)
+}
+
+// Escalation is a single change granting more privileges.
+type Escalation struct {
+	Kind Kind
+	Org  string
+	// Team is the name of the team, with parent teams separated by "/", or
+	// empty for org level changes.
+	Team string
+	// Subject is the user or repo the privileges were granted to or on.
+	Subject string
+	// Old and New are the previous and new values, Old is empty if there was
+	// none.
+	Old string
+	New string
+}
+
+func (e Escalation) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s: %s: ", e.Kind.Risk(), e.Org)
+	switch e.Kind {
+	case OrgAdminAdded:
+		fmt.Fprintf(&b, "%s added as org admin", e.Subject)
+	case DefaultPermissionRaised:
+		fmt.Fprintf(&b, "default_repository_permission raised from %s to %s", e.Old, e.New)
+	case RepoAdminGranted, RepoMaintainGranted:
+		from := ""
+		if e.Old != "" {
+			from = fmt.Sprintf(" (was %s)", e.Old)
+		}
+		fmt.Fprintf(&b, "team %s granted %s on repo %s%s", e.Team, e.New, e.Subject, from)
+	case TeamMaintainerAdded:
+		fmt.Fprintf(&b, "%s added as maintainer of team %s", e.Subject, e.Team)
+	case TeamMadeSecret:
+		from := "new team"
+		if e.Old != "" {
+			from = "was " + e.Old
+		}
+		fmt.Fprintf(&b, "team %s made secret (%s)", e.Team, from)
+	}
+	fmt.Fprintf(&b, " [%s]", e.Kind)
+	return b.String()
+}
+
+// Find returns the escalations from base to head, sorted by org, risk and
+// kind.
+func Find(base, head org.FullConfig) []Escalation {
+	var escalations []Escalation
+	for name, h := range head.Orgs {
+		b := base.Orgs[name]
+		escalations = append(escalations, findOrg(name, b, h)...)
+	}
+
+	rank := map[Kind]int{}
+	for i, k := range []Kind{OrgAdminAdded, DefaultPermissionRaised, RepoAdminGranted, RepoMaintainGranted, TeamMaintainerAdded, TeamMadeSecret} {
+		rank[k] = i
+	}
+	sort.SliceStable(escalations, func(i, j int) bool {
+		a, b := escalations[i], escalations[j]
+		switch {
+		case a.Org != b.Org:
+			return a.Org < b.Org
+		case rank[a.Kind] != rank[b.Kind]:
+			return rank[a.Kind] < rank[b.Kind]
+		case a.Team != b.Team:
+			return a.Team < b.Team
+		}
+		return strings.ToLower(a.Subject) < strings.ToLower(b.Subject)
+	})
+	return escalations
+}
+
+func findOrg(name string, base, head org.Config) []Escalation {
+	var escalations []Escalation
+	admins := logins(base.Admins)
+	for _, admin := range head.Admins {
+		if !admins[github.NormLogin(admin)] {
+			escalations = append(escalations, Escalation{Kind: OrgAdminAdded, Org: name, Subject: admin})
+		}
+	}
+
+	oldPermission, newPermission := github.Read, github.Read
+	if base.DefaultRepositoryPermission != nil {
+		oldPermission = *base.DefaultRepositoryPermission
+	}
+	if head.DefaultRepositoryPermission != nil {
+		newPermission = *head.DefaultRepositoryPermission
+	}
+	if orgconfig.PermissionRank(newPermission) > orgconfig.PermissionRank(oldPermission) {
+		escalations = append(escalations, Escalation{Kind: DefaultPermissionRaised, Org: name, Old: string(oldPermission), New: string(newPermission)})
+	}
+
+	baseTeams := map[string]org.Team{}
+	walkTeams(base.Teams, "", func(_ string, teamName string, t org.Team) {
+		baseTeams[github.NormLogin(teamName)] = t
+	})
+	inHead := map[string]bool{}
+	walkTeams(head.Teams, "", func(_ string, teamName string, _ org.Team) {
+		inHead[github.NormLogin(teamName)] = true
+	})
+	walkTeams(head.Teams, "", func(fullName, teamName string, t org.Team) {
+		b, existed := baseTeams[github.NormLogin(teamName)]
+		// a renamed team keeps the privileges it had under its previous name
+		for _, previous := range t.Previously {
+			if existed {
+				break
+			}
+			if !inHead[github.NormLogin(previous)] {
+				b, existed = baseTeams[github.NormLogin(previous)]
+			}
+		}
+		escalations = append(escalations, findTeam(name, fullName, b, existed, t)...)
+	})
+	return escalations
+}
+
+func findTeam(orgName, teamName string, base org.Team, existed bool, head org.Team) []Escalation {
+	var escalations []Escalation
+	for repo, permission := range head.Repos {
+		var kind Kind
+		switch permission {
+		case github.Admin:
+			kind = RepoAdminGranted
+		case github.Maintain:
+			kind = RepoMaintainGranted
+		default:
+			continue
+		}
+		old, ok := base.Repos[repo]
+		if ok && orgconfig.PermissionRank(old) >= orgconfig.PermissionRank(permission) {
+			continue
+		}
+		escalations = append(escalations, Escalation{Kind: kind, Org: orgName, Team: teamName, Subject: repo, Old: string(old), New: string(permission)})
+	}
+
+	maintainers := logins(base.Maintainers)
+	for _, maintainer := range head.Maintainers {
+		if !maintainers[github.NormLogin(maintainer)] {
+			escalations = append(escalations, Escalation{Kind: TeamMaintainerAdded, Org: orgName, Team: teamName, Subject: maintainer})
+		}
+	}
+
+	if head.Privacy != nil && *head.Privacy == org.Secret && (base.Privacy == nil || *base.Privacy != org.Secret) {
+		e := Escalation{Kind: TeamMadeSecret, Org: orgName, Team: teamName, New: string(org.Secret)}
+		if existed {
+			e.Old = string(org.Closed)
+			if base.Privacy != nil {
+				e.Old = string(*base.Privacy)
+			}
+		}
+		escalations = append(escalations, e)
+	}
+	return escalations
+}
+
+// Labels returns the sorted labels for the kinds of escalations.
+func Labels(escalations []Escalation) []string {
+	seen := map[string]bool{}
+	var labels []string
+	for _, e := range escalations {
+		if l := e.Kind.Label(); !seen[l] {
+			seen[l] = true
+			labels = append(labels, l)
+		}
+	}
+	sort.Strings(labels)
+	return labels
+}
+
+// MaxRisk returns the highest risk of the escalations, 0 if there are none.
+func MaxRisk(escalations []Escalation) Risk {
+	var max Risk
+	for _, e := range escalations {
+		if r := e.Kind.Risk(); r > max {
+			max = r
+		}
+	}
+	return max
+}
+
+func walkTeams(teams map[string]org.Team, prefix string, visit func(fullName, name string, t org.Team)) {
+	for name, t := range teams {
+		visit(prefix+name, name, t)
+		walkTeams(t.Children, prefix+name+"/", visit)
+	}
+}
+
+func logins(list []string) map[string]bool {
+	set := map[string]bool{}
+	for _, login := range list {
+		set[github.NormLogin(login)] = true
+	}
+	return set
+}
@@ -0,0 +1,186 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package escalation
+
+import (
+	"reflect"
+	"testing"
+
+	"sigs.k8s.io/prow/pkg/config/org"
+	"sigs.k8s.io/yaml"
+)
+
+func mustUnmarshal(t *testing.T, s string) org.FullConfig {
+	t.Helper()
+	var cfg org.FullConfig
+	if err := yaml.Unmarshal([]byte(s), &cfg); err != nil {
+		t.Fatal(err)
+	}
+	return cfg
+}
+
+func TestFind(t *testing.T) {
+	base := mustUnmarshal(t, `orgs:
+  kubernetes:
+    admins: [alice]
+    members: [bob, carol]
+    teams:
+      sig-foo:
+        maintainers: [Bob]
+        members: [carol]
+        repos:
+          kubectl: write
+          website: admin
+        teams:
+          sig-foo-leads:
+            privacy: closed
+            repos:
+              kubectl: maintain
+`)
+	head := mustUnmarshal(t, `orgs:
+  kubernetes:
+    admins: [alice, carol]
+    default_repository_permission: write
+    members: [bob, dave]
+    teams:
+      sig-foo:
+        maintainers: [bob, carol]
+        members: [dave]
+        repos:
+          kubectl: maintain
+          website: admin
+          community: admin
+        teams:
+          sig-foo-leads:
+            privacy: secret
+            repos:
+              kubectl: write
+      sig-bar:
+        maintainers: [dave]
+        privacy: secret
+  kubernetes-sigs:
+    admins: [erin]
+`)
+
+	expected := []Escalation{
+		{Kind: OrgAdminAdded, Org: "kubernetes", Subject: "carol"},
+		{Kind: DefaultPermissionRaised, Org: "kubernetes", Old: "read", New: "write"},
+		{Kind: RepoAdminGranted, Org: "kubernetes", Team: "sig-foo", Subject: "community", New: "admin"},
+		{Kind: RepoMaintainGranted, Org: "kubernetes", Team: "sig-foo", Subject: "kubectl", Old: "write", New: "maintain"},
+		{Kind: TeamMaintainerAdded, Org: "kubernetes", Team: "sig-bar", Subject: "dave"},
+		{Kind: TeamMaintainerAdded, Org: "kubernetes", Team: "sig-foo", Subject: "carol"},
+		{Kind: TeamMadeSecret, Org: "kubernetes", Team: "sig-bar", New: "secret"},
+		{Kind: TeamMadeSecret, Org: "kubernetes", Team: "sig-foo/sig-foo-leads", Old: "closed", New: "secret"},
+		{Kind: OrgAdminAdded, Org: "kubernetes-sigs", Subject: "erin"},
+	}
+	escalations := Find(base, head)
+	if !reflect.DeepEqual(escalations, expected) {
+		t.Errorf("expected %#v, got %#v", expected, escalations)
+	}
+
+	expectedLabels := []string{
+		"privilege-escalation/default-permission-raised",
+		"privilege-escalation/org-admin-added",
+		"privilege-escalation/repo-admin-granted",
+		"privilege-escalation/repo-maintain-granted",
+		"privilege-escalation/team-made-secret",
+		"privilege-escalation/team-maintainer-added",
+	}
+	if labels := Labels(escalations); !reflect.DeepEqual(labels, expectedLabels) {
+		t.Errorf("expected labels %v, got %v", expectedLabels, labels)
+	}
+	if r := MaxRisk(escalations); r != High {
+		t.Errorf("expected risk %s, got %s", High, r)
+	}
+
+	if escalations := Find(head, head); len(escalations) != 0 {
+		t.Errorf("expected no escalations without changes, got %v", escalations)
+	}
+}
+
+func TestFindMatchesRenamedTeams(t *testing.T) {
+	base := mustUnmarshal(t, `orgs:
+  kubernetes:
+    teams:
+      sig-foo:
+        maintainers: [bob]
+        privacy: secret
+        repos:
+          website: admin
+      sig-bar:
+        repos:
+          kubectl: write
+      sig-qux:
+        repos:
+          kubectl: admin
+`)
+	head := mustUnmarshal(t, `orgs:
+  kubernetes:
+    teams:
+      sig-foo-renamed:
+        previously: [sig-foo]
+        maintainers: [bob]
+        privacy: secret
+        repos:
+          website: admin
+      sig-baz:
+        previously: [sig-bar]
+        repos:
+          kubectl: admin
+      sig-qux-renamed:
+        previously: [sig-qux]
+        repos:
+          kubectl: admin
+      sig-qux:
+        maintainers: [carol]
+`)
+
+	// sig-qux-renamed cannot have been sig-qux as that team still exists
+	expected := []Escalation{
+		{Kind: RepoAdminGranted, Org: "kubernetes", Team: "sig-baz", Subject: "kubectl", Old: "write", New: "admin"},
+		{Kind: RepoAdminGranted, Org: "kubernetes", Team: "sig-qux-renamed", Subject: "kubectl", New: "admin"},
+		{Kind: TeamMaintainerAdded, Org: "kubernetes", Team: "sig-qux", Subject: "carol"},
+	}
+	if got := Find(base, head); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %#v, got %#v", expected, got)
+	}
+}
+
+func TestString(t *testing.T) {
+	cases := []struct {
+		e        Escalation
+		expected string
+	}{
+		{
+			e:        Escalation{Kind: OrgAdminAdded, Org: "kubernetes", Subject: "carol"},
+			expected: "high: kubernetes: carol added as org admin [org-admin-added]",
+		},
+		{
+			e:        Escalation{Kind: RepoMaintainGranted, Org: "kubernetes", Team: "sig-foo", Subject: "kubectl", Old: "write", New: "maintain"},
+			expected: "medium: kubernetes: team sig-foo granted maintain on repo kubectl (was write) [repo-maintain-granted]",
+		},
+		{
+			e:        Escalation{Kind: TeamMadeSecret, Org: "kubernetes", Team: "sig-bar", New: "secret"},
+			expected: "medium: kubernetes: team sig-bar made secret (new team) [team-made-secret]",
+		},
+	}
+	for _, c := range cases {
+		if s := c.e.String(); s != c.expected {
+			t.Errorf("expected %q, got %q", c.expected, s)
+		}
+	}
+}
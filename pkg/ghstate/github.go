@@ -0,0 +1,274 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ghstate
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/prow/pkg/config/org"
+	"sigs.k8s.io/prow/pkg/github"
+)
+
+// DefaultEndpoint is the GitHub REST API.
+const DefaultEndpoint = "https://api.github.com"
+
+var nextLink = regexp.MustCompile(`<([^>]+)>;\s*rel="next"`)
+
+// Client reads the state of orgs from the GitHub REST API.
+type Client struct {
+	// Endpoint defaults to DefaultEndpoint.
+	Endpoint string
+	Token    string
+	// HTTPClient defaults to http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+// Snapshot fetches the state of the given orgs.
+func (c *Client) Snapshot(orgs []string) (*State, error) {
+	s := &State{Orgs: map[string]Org{}}
+	for _, name := range orgs {
+		o, err := c.Org(name)
+		if err != nil {
+			return nil, err
+		}
+		s.Orgs[name] = o
+	}
+	return s, nil
+}
+
+// Org fetches the admins, members, invitations and teams of an org. The
+// invitations are left empty if the token is not allowed to list them.
+//
+// The GitHub API lists the members of child teams as members of their
+// parents too. They are left out of the parents so that each team lists its
+// direct members only, like the config does. A login that is a direct member
+// of both a team and one of its children is only listed in the child.
+func (c *Client) Org(name string) (Org, error) {
+	var o Org
+	var err error
+	if o.Admins, err = c.logins(fmt.Sprintf("orgs/%s/members?role=admin", name)); err != nil {
+		return o, fmt.Errorf("listing admins of %s: %w", name, err)
+	}
+	if o.Members, err = c.logins(fmt.Sprintf("orgs/%s/members?role=member", name)); err != nil {
+		return o, fmt.Errorf("listing members of %s: %w", name, err)
+	}
+	if o.Invitations, err = c.logins(fmt.Sprintf("orgs/%s/invitations", name)); err != nil {
+		var status statusError
+		if !errors.As(err, &status) || (status != http.StatusForbidden && status != http.StatusNotFound) {
+			return o, fmt.Errorf("listing invitations of %s: %w", name, err)
+		}
+		o.Invitations = nil
+	}
+
+	var teams []apiTeam
+	err = c.list(fmt.Sprintf("orgs/%s/teams", name), func(page []byte) error {
+		var items []apiTeam
+		if err := json.Unmarshal(page, &items); err != nil {
+			return err
+		}
+		teams = append(teams, items...)
+		return nil
+	})
+	if err != nil {
+		return o, fmt.Errorf("listing teams of %s: %w", name, err)
+	}
+
+	o.Teams = map[string]Team{}
+	for _, t := range teams {
+		team := Team{Slug: t.Slug, Description: t.Description, Privacy: t.Privacy}
+		if t.Parent != nil {
+			team.Parent = t.Parent.Name
+		}
+		prefix := fmt.Sprintf("orgs/%s/teams/%s", name, t.Slug)
+		if team.Maintainers, err = c.logins(prefix + "/members?role=maintainer"); err != nil {
+			return o, fmt.Errorf("listing maintainers of team %s: %w", t.Name, err)
+		}
+		if team.Members, err = c.logins(prefix + "/members?role=member"); err != nil {
+			return o, fmt.Errorf("listing members of team %s: %w", t.Name, err)
+		}
+		if team.Repos, err = c.teamRepos(prefix + "/repos"); err != nil {
+			return o, fmt.Errorf("listing repos of team %s: %w", t.Name, err)
+		}
+		o.Teams[t.Name] = team
+	}
+
+	// the lists of child teams already include their own children
+	inherited := map[string]map[string]bool{}
+	for _, t := range o.Teams {
+		if t.Parent == "" {
+			continue
+		}
+		if inherited[t.Parent] == nil {
+			inherited[t.Parent] = map[string]bool{}
+		}
+		for _, login := range append(append([]string{}, t.Maintainers...), t.Members...) {
+			inherited[t.Parent][github.NormLogin(login)] = true
+		}
+	}
+	for name, t := range o.Teams {
+		if logins := inherited[name]; logins != nil {
+			t.Maintainers = directLogins(t.Maintainers, logins)
+			t.Members = directLogins(t.Members, logins)
+			o.Teams[name] = t
+		}
+	}
+	return o, nil
+}
+
+// directLogins returns the logins that are not inherited from a child team.
+func directLogins(logins []string, inherited map[string]bool) []string {
+	var direct []string
+	for _, login := range logins {
+		if !inherited[github.NormLogin(login)] {
+			direct = append(direct, login)
+		}
+	}
+	return direct
+}
+
+// apiTeam is a team as returned by the GitHub API.
+type apiTeam struct {
+	Name        string      `json:"name"`
+	Slug        string      `json:"slug"`
+	Description string      `json:"description"`
+	Privacy     org.Privacy `json:"privacy"`
+	Parent      *struct {
+		Name string `json:"name"`
+	} `json:"parent"`
+}
+
+// logins lists the logins of the users, or invitees, at path.
+func (c *Client) logins(path string) ([]string, error) {
+	var logins []string
+	err := c.list(path, func(page []byte) error {
+		var users []struct {
+			Login string `json:"login"`
+		}
+		if err := json.Unmarshal(page, &users); err != nil {
+			return err
+		}
+		for _, u := range users {
+			if u.Login != "" {
+				logins = append(logins, u.Login)
+			}
+		}
+		return nil
+	})
+	sortLogins(logins)
+	return logins, err
+}
+
+func (c *Client) teamRepos(path string) (map[string]github.RepoPermissionLevel, error) {
+	repos := map[string]github.RepoPermissionLevel{}
+	err := c.list(path, func(page []byte) error {
+		var items []struct {
+			Name        string                 `json:"name"`
+			RoleName    string                 `json:"role_name"`
+			Permissions github.RepoPermissions `json:"permissions"`
+		}
+		if err := json.Unmarshal(page, &items); err != nil {
+			return err
+		}
+		for _, r := range items {
+			permission := github.RepoPermissionLevel(r.RoleName)
+			switch permission {
+			case "pull":
+				permission = github.Read
+			case "push":
+				permission = github.Write
+			case github.Read, github.Triage, github.Write, github.Maintain, github.Admin:
+			default:
+				permission = github.LevelFromPermissions(r.Permissions)
+			}
+			repos[r.Name] = permission
+		}
+		return nil
+	})
+	return repos, err
+}
+
+// statusError is the HTTP status of a failed request.
+type statusError int
+
+func (e statusError) Error() string {
+	return fmt.Sprintf("unexpected status %d %s", int(e), http.StatusText(int(e)))
+}
+
+// list calls visit with the body of every page of the list at path.
+func (c *Client) list(path string, visit func(page []byte) error) error {
+	endpoint := c.Endpoint
+	if endpoint == "" {
+		endpoint = DefaultEndpoint
+	}
+	u, err := url.Parse(strings.TrimSuffix(endpoint, "/") + "/" + path)
+	if err != nil {
+		return err
+	}
+	q := u.Query()
+	q.Set("per_page", "100")
+	u.RawQuery = q.Encode()
+
+	client := c.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	next := u.String()
+	for next != "" {
+		req, err := http.NewRequest(http.MethodGet, next, nil)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Accept", "application/vnd.github+json")
+		if c.Token != "" {
+			req.Header.Set("Authorization", "Bearer "+c.Token)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode != http.StatusOK {
+			return statusError(resp.StatusCode)
+		}
+		if err := visit(body); err != nil {
+			return fmt.Errorf("decoding %s: %v", next, err)
+		}
+		next = ""
+		if m := nextLink.FindStringSubmatch(resp.Header.Get("Link")); m != nil {
+			next = m[1]
+		}
+	}
+	return nil
+}
+
+func sortLogins(logins []string) {
+	sort.Slice(logins, func(i, j int) bool {
+		return strings.ToLower(logins[i]) < strings.ToLower(logins[j])
+	})
+}
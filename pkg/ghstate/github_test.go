@@ -0,0 +1,196 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ghstate
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"sigs.k8s.io/prow/pkg/config/org"
+	"sigs.k8s.io/prow/pkg/github"
+	"sigs.k8s.io/yaml"
+)
+
+// fakeGitHub serves the responses keyed by path and query, without per_page
+// and page, splitting lists of more than one item into pages of one.
+func fakeGitHub(t *testing.T, responses map[string][]string) *httptest.Server {
+	t.Helper()
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		q := r.URL.Query()
+		page := 0
+		fmt.Sscan(q.Get("page"), &page)
+		q.Del("page")
+		q.Del("per_page")
+		key := r.URL.Path
+		if len(q) > 0 {
+			key += "?" + q.Encode()
+		}
+		items, ok := responses[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if len(items) == 0 {
+			fmt.Fprint(w, "[]")
+			return
+		}
+		if page+1 < len(items) {
+			next := *r.URL
+			next.Host = r.Host
+			next.Scheme = "http"
+			nq := next.Query()
+			nq.Set("page", fmt.Sprint(page+1))
+			next.RawQuery = nq.Encode()
+			w.Header().Set("Link", fmt.Sprintf(`<%s>; rel="next"`, next.String()))
+		}
+		fmt.Fprintf(w, "[%s]", items[page])
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestSnapshot(t *testing.T) {
+	server := fakeGitHub(t, map[string][]string{
+		"/orgs/kubernetes/members?role=admin":  {`{"login": "cblecker"}`},
+		"/orgs/kubernetes/members?role=member": {`{"login": "bob"}`, `{"login": "Alice"}`},
+		"/orgs/kubernetes/teams": {
+			`{"name": "sig-foo", "slug": "sig-foo", "description": "SIG Foo", "privacy": "closed"}`,
+			`{"name": "sig-foo-leads", "slug": "sig-foo-leads", "privacy": "closed", "parent": {"name": "sig-foo", "slug": "sig-foo"}}`,
+		},
+		"/orgs/kubernetes/teams/sig-foo/members?role=maintainer": {`{"login": "cblecker"}`},
+		"/orgs/kubernetes/teams/sig-foo/members?role=member":     {`{"login": "alice"}`, `{"login": "bob"}`},
+		"/orgs/kubernetes/teams/sig-foo/repos": {
+			`{"name": "foo", "role_name": "maintain"}`,
+			`{"name": "bar", "permissions": {"pull": true, "push": true}}`,
+		},
+		"/orgs/kubernetes/teams/sig-foo-leads/members?role=maintainer": {},
+		"/orgs/kubernetes/teams/sig-foo-leads/members?role=member":     {`{"login": "alice"}`},
+		"/orgs/kubernetes/teams/sig-foo-leads/repos":                   {},
+	})
+
+	c := &Client{Endpoint: server.URL, Token: "token"}
+	state, err := c.Snapshot([]string{"kubernetes"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := &State{Orgs: map[string]Org{
+		"kubernetes": {
+			Admins:  []string{"cblecker"},
+			Members: []string{"Alice", "bob"},
+			Teams: map[string]Team{
+				"sig-foo": {
+					Slug:        "sig-foo",
+					Description: "SIG Foo",
+					Privacy:     org.Closed,
+					Maintainers: []string{"cblecker"},
+					Members:     []string{"bob"},
+					Repos:       map[string]github.RepoPermissionLevel{"foo": github.Maintain, "bar": github.Write},
+				},
+				"sig-foo-leads": {
+					Slug:    "sig-foo-leads",
+					Privacy: org.Closed,
+					Parent:  "sig-foo",
+					Members: []string{"alice"},
+					Repos:   map[string]github.RepoPermissionLevel{},
+				},
+			},
+		},
+	}}
+	if !reflect.DeepEqual(state, expected) {
+		t.Errorf("expected %#v, got %#v", expected, state)
+	}
+
+	cfg, err := yaml.Marshal(state.Orgs["kubernetes"].Config())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedConfig := `admins:
+- cblecker
+members:
+- Alice
+- bob
+teams:
+  sig-foo:
+    description: SIG Foo
+    maintainers:
+    - cblecker
+    members:
+    - bob
+    privacy: closed
+    repos:
+      bar: write
+      foo: maintain
+    teams:
+      sig-foo-leads:
+        members:
+        - alice
+        privacy: closed
+`
+	if string(cfg) != expectedConfig {
+		t.Errorf("expected config:\n%s\ngot:\n%s", expectedConfig, cfg)
+	}
+
+	if _, err := (&Client{Endpoint: server.URL}).Snapshot([]string{"kubernetes"}); err == nil {
+		t.Errorf("expected an error without a token")
+	}
+}
+
+func TestOrgListsDirectTeamMembers(t *testing.T) {
+	// GitHub lists the members of child teams as members of their parents
+	server := fakeGitHub(t, map[string][]string{
+		"/orgs/kubernetes/members?role=admin":  {`{"login": "cblecker"}`},
+		"/orgs/kubernetes/members?role=member": {`{"login": "alice"}`, `{"login": "bob"}`, `{"login": "dave"}`},
+		"/orgs/kubernetes/teams": {
+			`{"name": "sig-foo", "slug": "sig-foo", "privacy": "closed"}`,
+			`{"name": "sig-foo-leads", "slug": "sig-foo-leads", "privacy": "closed", "parent": {"name": "sig-foo", "slug": "sig-foo"}}`,
+			`{"name": "sig-foo-chairs", "slug": "sig-foo-chairs", "privacy": "closed", "parent": {"name": "sig-foo-leads", "slug": "sig-foo-leads"}}`,
+		},
+		"/orgs/kubernetes/teams/sig-foo/members?role=maintainer":        {`{"login": "cblecker"}`},
+		"/orgs/kubernetes/teams/sig-foo/members?role=member":            {`{"login": "Alice"}`, `{"login": "bob"}`, `{"login": "dave"}`},
+		"/orgs/kubernetes/teams/sig-foo/repos":                          {},
+		"/orgs/kubernetes/teams/sig-foo-leads/members?role=maintainer":  {},
+		"/orgs/kubernetes/teams/sig-foo-leads/members?role=member":      {`{"login": "alice"}`, `{"login": "bob"}`},
+		"/orgs/kubernetes/teams/sig-foo-leads/repos":                    {},
+		"/orgs/kubernetes/teams/sig-foo-chairs/members?role=maintainer": {},
+		"/orgs/kubernetes/teams/sig-foo-chairs/members?role=member":     {`{"login": "alice"}`},
+		"/orgs/kubernetes/teams/sig-foo-chairs/repos":                   {},
+	})
+
+	o, err := (&Client{Endpoint: server.URL, Token: "token"}).Org("kubernetes")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := map[string][2][]string{
+		"sig-foo":        {{"cblecker"}, {"dave"}},
+		"sig-foo-leads":  {nil, {"bob"}},
+		"sig-foo-chairs": {nil, {"alice"}},
+	}
+	for name, want := range expected {
+		team := o.Teams[name]
+		if got := [2][]string{team.Maintainers, team.Members}; !reflect.DeepEqual(got, want) {
+			t.Errorf("expected maintainers and members %v of team %s, got %v", want, name, got)
+		}
+	}
+}
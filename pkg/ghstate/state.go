@@ -0,0 +1,156 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package ghstate describes the actual state of GitHub orgs, as opposed to
+// the state declared in the org configs, so that the two can be compared
+// offline.
+package ghstate
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"sigs.k8s.io/prow/pkg/config/org"
+	"sigs.k8s.io/prow/pkg/github"
+)
+
+// State is a snapshot of GitHub orgs, keyed by org name.
+type State struct {
+	Orgs map[string]Org `json:"orgs"`
+}
+
+// Org is the state of a GitHub org.
+type Org struct {
+	// Admins and Members are disjoint, like in org.yaml.
+	Admins  []string `json:"admins,omitempty"`
+	Members []string `json:"members,omitempty"`
+	// Invitations are the logins with a pending invitation to the org.
+	Invitations []string `json:"invitations,omitempty"`
+	// Teams are keyed by team name. Child teams are not nested but name their
+	// parent.
+	Teams map[string]Team `json:"teams,omitempty"`
+}
+
+// Team is the state of a GitHub team.
+type Team struct {
+	Slug        string      `json:"slug,omitempty"`
+	Description string      `json:"description,omitempty"`
+	Privacy     org.Privacy `json:"privacy,omitempty"`
+	Parent      string      `json:"parent,omitempty"`
+	// Maintainers and Members are disjoint, like in teams.yaml, and only list
+	// the direct members of the team, not those of its child teams.
+	Maintainers []string                              `json:"maintainers,omitempty"`
+	Members     []string                              `json:"members,omitempty"`
+	Repos       map[string]github.RepoPermissionLevel `json:"repos,omitempty"`
+}
+
+// Load reads a JSON state snapshot from path.
+func Load(path string) (*State, error) {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read state: %v", err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(buf))
+	dec.DisallowUnknownFields()
+	var s State
+	if err := dec.Decode(&s); err != nil {
+		return nil, fmt.Errorf("unmarshal state %s: %v", path, err)
+	}
+	return &s, nil
+}
+
+// Team returns the team with the given name, compared case-insensitively.
+func (o Org) Team(name string) (string, Team, bool) {
+	if t, ok := o.Teams[name]; ok {
+		return name, t, true
+	}
+	for n, t := range o.Teams {
+		if github.NormLogin(n) == github.NormLogin(name) {
+			return n, t, true
+		}
+	}
+	return "", Team{}, false
+}
+
+// Config converts the state of the org to its config, with child teams nested
+// under their parents.
+func (o Org) Config() org.Config {
+	cfg := org.Config{
+		Admins:  sortedLogins(o.Admins),
+		Members: sortedLogins(o.Members),
+	}
+	children := map[string][]string{}
+	for name, t := range o.Teams {
+		parent := ""
+		if p, _, ok := o.Team(t.Parent); ok && t.Parent != "" {
+			parent = p
+		}
+		children[parent] = append(children[parent], name)
+	}
+	var teams func(parent string) map[string]org.Team
+	teams = func(parent string) map[string]org.Team {
+		if len(children[parent]) == 0 {
+			return nil
+		}
+		m := map[string]org.Team{}
+		for _, name := range children[parent] {
+			t := o.Teams[name]
+			team := org.Team{
+				Maintainers: sortedLogins(t.Maintainers),
+				Members:     sortedLogins(t.Members),
+				Children:    teams(name),
+			}
+			if t.Description != "" {
+				description := t.Description
+				team.Description = &description
+			}
+			if t.Privacy != "" {
+				privacy := t.Privacy
+				team.Privacy = &privacy
+			}
+			if len(t.Repos) > 0 {
+				team.Repos = t.Repos
+			}
+			m[name] = team
+		}
+		return m
+	}
+	cfg.Teams = teams("")
+	return cfg
+}
+
+func sortedLogins(logins []string) []string {
+	if len(logins) == 0 {
+		return nil
+	}
+	sorted := append([]string{}, logins...)
+	sortLogins(sorted)
+	return sorted
+}
+
+// Save writes the state to path as indented JSON.
+func (s *State) Save(path string) error {
+	buf, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal state: %v", err)
+	}
+	if err := os.WriteFile(path, append(buf, '\n'), 0644); err != nil {
+		return fmt.Errorf("write state: %v", err)
+	}
+	return nil
+}
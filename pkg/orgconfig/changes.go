@@ -0,0 +1,214 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package orgconfig
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"sigs.k8s.io/prow/pkg/config/org"
+)
+
+// Edit is a single pending change to a list in a config file.
+type Edit struct {
+	// File is the config file relative to the root of the repo.
+	File string
+	// Path is the path of the list within File, e.g. teams.foo.members.
+	Path   []string
+	Value  string
+	Remove bool
+	// Rename is set to the value replacing Value in the list.
+	Rename string
+}
+
+// Changes accumulates edits to config files so they can be reviewed up front
+// and then written together.
+type Changes struct {
+	edits map[string][]Edit
+	files []string
+}
+
+// Add records e.
+func (c *Changes) Add(e Edit) {
+	if c.edits == nil {
+		c.edits = map[string][]Edit{}
+	}
+	if _, ok := c.edits[e.File]; !ok {
+		c.files = append(c.files, e.File)
+	}
+	c.edits[e.File] = append(c.edits[e.File], e)
+}
+
+// Files returns the files with pending edits in the order they were first
+// edited.
+func (c *Changes) Files() []string {
+	return append([]string{}, c.files...)
+}
+
+// Edits returns the pending edits of file.
+func (c *Changes) Edits(file string) []Edit {
+	return c.edits[file]
+}
+
+// Len returns the number of pending edits.
+func (c *Changes) Len() int {
+	n := 0
+	for _, edits := range c.edits {
+		n += len(edits)
+	}
+	return n
+}
+
+// Write applies the changes to the files under the root of the repo. Every
+// file is edited before any is written so that an edit that cannot be applied
+// leaves all files untouched.
+func (r *Repo) Write(c *Changes) error {
+	contents := make(map[string][]byte, len(c.files))
+	for _, file := range c.files {
+		path := filepath.Join(r.Root, file)
+		buf, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("unable to read file at %s: %s", path, err)
+		}
+		doc, err := ParseDocument(buf)
+		if err != nil {
+			return fmt.Errorf("%s: %s", path, err)
+		}
+		for _, e := range c.edits[file] {
+			switch {
+			case e.Remove:
+				_, err = doc.RemoveFromList(e.Value, e.Path...)
+			case e.Rename != "":
+				_, err = doc.RenameInList(e.Value, e.Rename, e.Path...)
+			default:
+				_, err = doc.AddToList(e.Value, e.Path...)
+			}
+			if err != nil {
+				return fmt.Errorf("%s: %s", path, err)
+			}
+		}
+		if updated := doc.Bytes(); !bytes.Equal(updated, buf) {
+			contents[file] = updated
+		}
+	}
+	for _, file := range c.files {
+		if _, ok := contents[file]; !ok {
+			continue
+		}
+		if err := WriteFile(filepath.Join(r.Root, file), contents[file]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AddMember returns the edit adding login to the members of the org and
+// applies it to Config. It returns false if login already is a member or an
+// admin of the org.
+func (o *Org) AddMember(login string) (Edit, bool) {
+	if o.IsMember(login) {
+		return Edit{}, false
+	}
+	o.Config.Members = append(o.Config.Members, login)
+	return Edit{File: o.File, Path: []string{string(Member)}, Value: login}, true
+}
+
+// RemoveMember returns the edit removing login from the members of the org
+// and applies it to Config. It returns false if login is not a member. Admins
+// are never removed.
+func (o *Org) RemoveMember(login string) (Edit, bool) {
+	if !ContainsLogin(o.Config.Members, login) {
+		return Edit{}, false
+	}
+	o.Config.Members = removeLogin(o.Config.Members, login)
+	return Edit{File: o.File, Path: []string{string(Member)}, Value: login, Remove: true}, true
+}
+
+// AddTeamMember returns the edit adding login to the team with the given
+// role, either Member or Maintainer, and applies it to Config. It returns
+// false if login already is a member or maintainer of the team.
+func (o *Org) AddTeamMember(team, login string, role Role) (Edit, bool, error) {
+	if role != Member && role != Maintainer {
+		return Edit{}, false, fmt.Errorf("invalid team role %q", role)
+	}
+	t, ok := o.Team(team)
+	if !ok {
+		return Edit{}, false, fmt.Errorf("team %s is not defined in org %s", team, o.Name)
+	}
+	if ContainsLogin(t.Members, login) || ContainsLogin(t.Maintainers, login) {
+		return Edit{}, false, nil
+	}
+	o.updateTeam(t.Name, func(t *org.Team) {
+		if role == Maintainer {
+			t.Maintainers = append(t.Maintainers, login)
+		} else {
+			t.Members = append(t.Members, login)
+		}
+	})
+	return Edit{File: t.File, Path: append(append([]string{}, t.Path...), string(role)), Value: login}, true, nil
+}
+
+// RemoveTeamMember returns the edit removing login from the team, whether
+// they are a member or a maintainer, and applies it to Config. It returns
+// false if login is neither.
+func (o *Org) RemoveTeamMember(team, login string) (Edit, bool, error) {
+	t, ok := o.Team(team)
+	if !ok {
+		return Edit{}, false, fmt.Errorf("team %s is not defined in org %s", team, o.Name)
+	}
+	role := Member
+	switch {
+	case ContainsLogin(t.Maintainers, login):
+		role = Maintainer
+	case !ContainsLogin(t.Members, login):
+		return Edit{}, false, nil
+	}
+	o.updateTeam(t.Name, func(t *org.Team) {
+		t.Maintainers = removeLogin(t.Maintainers, login)
+		t.Members = removeLogin(t.Members, login)
+	})
+	return Edit{File: t.File, Path: append(append([]string{}, t.Path...), string(role)), Value: login, Remove: true}, true, nil
+}
+
+// updateTeam applies update to the team named name in Config.
+func (o *Org) updateTeam(name string, update func(*org.Team)) {
+	def := o.teams.teams[strings.ToLower(name)]
+	var walk func(teams map[string]org.Team, tree []string)
+	walk = func(teams map[string]org.Team, tree []string) {
+		t := teams[tree[0]]
+		if len(tree) == 1 {
+			update(&t)
+		} else {
+			walk(t.Children, tree[1:])
+		}
+		teams[tree[0]] = t
+	}
+	walk(o.Config.Teams, def.tree)
+}
+
+func removeLogin(list []string, login string) []string {
+	out := make([]string, 0, len(list))
+	for _, l := range list {
+		if !strings.EqualFold(l, login) {
+			out = append(out, l)
+		}
+	}
+	return out
+}
@@ -0,0 +1,152 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package orgconfig
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/prow/pkg/config/org"
+	"sigs.k8s.io/yaml"
+
+	"k8s.io/org/pkg/diagnostics"
+)
+
+// ReadFile reads the org.yaml or teams.yaml file at path. Unknown fields are
+// rejected, and unmarshalling errors are returned as diagnostics. If sources
+// is not nil the positions of the entries of the file are added to it.
+func ReadFile(path string, sources diagnostics.SourceMap) (*org.Config, error) {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %v", path, err)
+	}
+	return parse(path, buf, sources)
+}
+
+func parse(file string, buf []byte, sources diagnostics.SourceMap) (*org.Config, error) {
+	var cfg org.Config
+	if err := yaml.Unmarshal(buf, &cfg, yaml.DisallowUnknownFields); err != nil {
+		return nil, diagnostics.FromYAMLError(file, buf, fmt.Errorf("unmarshal: %v", err))
+	}
+	if sources != nil {
+		if err := sources.Add(file, buf); err != nil {
+			return nil, diagnostics.FromYAMLError(file, buf, err)
+		}
+	}
+	return &cfg, nil
+}
+
+// osFS is an fs.FS reading paths of the local filesystem as they are, unlike
+// os.DirFS which only accepts paths relative to its root.
+type osFS struct{}
+
+func (osFS) Open(name string) (fs.File, error)          { return os.Open(name) }
+func (osFS) ReadFile(name string) ([]byte, error)       { return os.ReadFile(name) }
+func (osFS) ReadDir(name string) ([]fs.DirEntry, error) { return os.ReadDir(name) }
+func (osFS) Stat(name string) (fs.FileInfo, error)      { return os.Stat(name) }
+
+// TeamsFiles returns the teams.yaml files in the subdirectories of orgDir,
+// at any depth, with shallower files first.
+func TeamsFiles(orgDir string) ([]string, error) {
+	return teamsFiles(osFS{}, orgDir)
+}
+
+func teamsFiles(fsys fs.FS, orgDir string) ([]string, error) {
+	var files []string
+	err := fs.WalkDir(fsys, orgDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && d.Name() == "teams.yaml" && filepath.Dir(path) != orgDir {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	depth := func(path string) int {
+		return strings.Count(filepath.ToSlash(path), "/")
+	}
+	sort.SliceStable(files, func(i, j int) bool {
+		if depth(files[i]) != depth(files[j]) {
+			return depth(files[i]) < depth(files[j])
+		}
+		return files[i] < files[j]
+	})
+	return files, nil
+}
+
+// MergeTeamsFiles merges the teams.yaml files found under the directory of
+// orgPath into cfg, the config loaded from orgPath, using load to read them.
+//
+// Teams defined in config/<org>/<dir>/teams.yaml are top-level teams. Deeper
+// directories map onto child teams: the teams defined in
+// config/<org>/<dir>/<parent>/teams.yaml become children of the team named
+// <parent>, which must be defined in config/<org>/<dir>/teams.yaml, and so on.
+func MergeTeamsFiles(cfg *org.Config, orgPath string, idx *TeamIndex, load func(path string) (*org.Config, error)) error {
+	orgTeams := cfg.Teams
+	cfg.Teams = map[string]org.Team{}
+
+	var errs diagnostics.List
+	collect := func(err error) error {
+		var l diagnostics.List
+		if errors.As(err, &l) {
+			errs = append(errs, l...)
+			return nil
+		}
+		return err
+	}
+
+	if err := collect(idx.Merge(cfg.Teams, orgPath, orgTeams)); err != nil {
+		return err
+	}
+
+	orgDir := filepath.Dir(orgPath)
+	files, err := teamsFiles(idx.fsys, orgDir)
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		teamCfg, err := load(f)
+		if err != nil {
+			return err
+		}
+
+		dir := filepath.Dir(f)
+		if filepath.Dir(dir) == orgDir {
+			err = idx.Merge(cfg.Teams, f, teamCfg.Teams)
+		} else {
+			enclosing := filepath.Join(filepath.Dir(dir), "teams.yaml")
+			err = idx.MergeChildren(cfg.Teams, f, filepath.Base(dir), enclosing, teamCfg.Teams)
+		}
+		if err := collect(err); err != nil {
+			return err
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
@@ -0,0 +1,244 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package orgconfig
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"sigs.k8s.io/prow/pkg/config/org"
+
+	"k8s.io/org/pkg/diagnostics"
+)
+
+const testOrgConfig = `admins:
+- admin1
+- admin2
+billing_email: github@kubernetes.io
+default_repository_permission: read
+description: Org desc
+has_organization_projects: true
+has_repository_projects: true
+members:
+- member1
+- member2
+members_can_create_repositories: false
+name: Org
+teams:
+  team-abc:
+    description: team-abc desc
+    members:
+    - team-member1
+    privacy: closed
+    %s:
+      abc: write
+`
+
+func TestStrictUnmarshalling(t *testing.T) {
+	cases := []struct {
+		repoKey     string
+		expectError bool
+		desc        string
+	}{
+		{
+			repoKey:     "repos",
+			expectError: false,
+			desc:        "with a valid field",
+		},
+		{
+			repoKey:     "somethingBizzare",
+			expectError: true,
+			desc:        "with an invalid field",
+		},
+	}
+
+	for _, c := range cases {
+		path := filepath.Join(t.TempDir(), "org.yaml")
+		if err := os.WriteFile(path, []byte(fmt.Sprintf(testOrgConfig, c.repoKey)), 0644); err != nil {
+			t.Fatal(err)
+		}
+		_, err := ReadFile(path, nil)
+		if !c.expectError && err != nil {
+			t.Errorf("unexpected error for %s: %v", c.desc, err)
+		}
+		if c.expectError && err == nil {
+			t.Errorf("expected error for %s", c.desc)
+		}
+	}
+}
+
+func TestReadFileReportsLocation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "org.yaml")
+	if err := os.WriteFile(path, []byte(fmt.Sprintf(testOrgConfig, "somethingBizzare")), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := ReadFile(path, nil)
+	var d diagnostics.Diagnostic
+	if !errors.As(err, &d) {
+		t.Fatalf("expected a diagnostic, got %v", err)
+	}
+	expected := diagnostics.Location{File: path, Line: 20, Column: 5}
+	if d.Location != expected {
+		t.Errorf("expected error at %v, got %v", expected, d.Location)
+	}
+}
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, contents := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func loadConfig(path string) (*org.Config, error) {
+	return ReadFile(path, nil)
+}
+
+func teamTree(teams map[string]org.Team, prefix string) []string {
+	var names []string
+	for name, team := range teams {
+		names = append(names, prefix+name)
+		names = append(names, teamTree(team.Children, prefix+name+"/")...)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestMergeTeamsFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"org.yaml": "teams:\n  org-team: {}\n",
+		"sig-release/teams.yaml": `teams:
+  sig-release:
+    teams:
+      release-team: {}
+`,
+		"sig-release/release-team/teams.yaml": `teams:
+  release-team-leads: {}
+  release-managers: {}
+`,
+		"sig-release/release-team/release-managers/teams.yaml": "teams:\n  release-managers-associates: {}\n",
+		"sig-release/release-team/release-managers/OWNERS":     "approvers: []\n",
+		"sig-testing/teams.yaml":                               "teams:\n  sig-testing: {}\n",
+	})
+	orgYAML := filepath.Join(dir, "org.yaml")
+
+	files, err := TeamsFiles(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedFiles := []string{
+		filepath.Join(dir, "sig-release/teams.yaml"),
+		filepath.Join(dir, "sig-testing/teams.yaml"),
+		filepath.Join(dir, "sig-release/release-team/teams.yaml"),
+		filepath.Join(dir, "sig-release/release-team/release-managers/teams.yaml"),
+	}
+	if !reflect.DeepEqual(files, expectedFiles) {
+		t.Errorf("expected files %v, got %v", expectedFiles, files)
+	}
+
+	cfg, err := loadConfig(orgYAML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idx := NewTeamIndex(false)
+	if err := MergeTeamsFiles(cfg, orgYAML, idx, loadConfig); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []string{
+		"org-team",
+		"sig-release",
+		"sig-release/release-team",
+		"sig-release/release-team/release-managers",
+		"sig-release/release-team/release-managers/release-managers-associates",
+		"sig-release/release-team/release-team-leads",
+		"sig-testing",
+	}
+	if got := teamTree(cfg.Teams, ""); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected teams %v, got %v", expected, got)
+	}
+
+	file, path, _ := idx.Source("release-managers-associates")
+	if file != expectedFiles[3] || !reflect.DeepEqual(path, []string{"teams", "release-managers-associates"}) {
+		t.Errorf("expected release-managers-associates at %s teams.release-managers-associates, got %s %v", expectedFiles[3], file, path)
+	}
+}
+
+func TestMergeTeamsFilesErrors(t *testing.T) {
+	cases := []struct {
+		name     string
+		files    map[string]string
+		expected func(dir string) diagnostics.List
+	}{
+		{
+			name: "parent not defined in enclosing directory",
+			files: map[string]string{
+				"org.yaml":                  "teams:\n  parent: {}\n",
+				"sig-foo/teams.yaml":        "teams:\n  sig-foo: {}\n",
+				"sig-foo/parent/teams.yaml": "teams:\n  child: {}\n",
+			},
+			expected: func(dir string) diagnostics.List {
+				return diagnostics.List{
+					diagnostics.Errorf(diagnostics.Location{File: filepath.Join(dir, "sig-foo/parent/teams.yaml")}, "parent team parent is not defined in %s", filepath.Join(dir, "sig-foo/teams.yaml")),
+				}
+			},
+		},
+		{
+			name: "nested team already defined",
+			files: map[string]string{
+				"org.yaml":                   "teams:\n  child: {}\n",
+				"sig-foo/teams.yaml":         "teams:\n  sig-foo: {}\n",
+				"sig-foo/sig-foo/teams.yaml": "teams:\n  child: {}\n",
+			},
+			expected: func(dir string) diagnostics.List {
+				return diagnostics.List{
+					diagnostics.Errorf(diagnostics.Location{File: filepath.Join(dir, "sig-foo/sig-foo/teams.yaml"), Line: 2, Column: 3}, "team child is already defined at %s:2:3", filepath.Join(dir, "org.yaml")),
+				}
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFiles(t, dir, tc.files)
+			orgYAML := filepath.Join(dir, "org.yaml")
+			cfg, err := loadConfig(orgYAML)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Nested teams can never override, even when overrides are allowed.
+			err = MergeTeamsFiles(cfg, orgYAML, NewTeamIndex(true), loadConfig)
+			if expected := tc.expected(dir); !reflect.DeepEqual(err, expected) {
+				t.Errorf("expected %v, got %v", expected, err)
+			}
+		})
+	}
+}
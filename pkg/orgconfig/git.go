@@ -0,0 +1,175 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package orgconfig
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// RepoAt returns the repo as of the revision rev of the git repository at
+// root, e.g. a branch, a tag, a commit hash or HEAD~1, without checking it out.
+// Paths of the files of its orgs are relative to root.
+func RepoAt(root, rev string) (*Repo, error) {
+	r, err := git.PlainOpen(root)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open repository: %s", err)
+	}
+	hash, err := r.ResolveRevision(plumbing.Revision(rev))
+	if err != nil {
+		return nil, fmt.Errorf("unable to resolve %s: %s", rev, err)
+	}
+	commit, err := r.CommitObject(*hash)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read commit %s: %s", rev, err)
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, fmt.Errorf("unable to read tree of %s: %s", rev, err)
+	}
+	return &Repo{FS: treeFS{tree: tree}}, nil
+}
+
+// treeFS is a read-only fs.FS over a git tree.
+type treeFS struct {
+	tree *object.Tree
+}
+
+func notExist(op, name string, err error) error {
+	switch {
+	case errors.Is(err, object.ErrFileNotFound),
+		errors.Is(err, object.ErrDirectoryNotFound),
+		errors.Is(err, object.ErrEntryNotFound):
+		err = fs.ErrNotExist
+	}
+	return &fs.PathError{Op: op, Path: name, Err: err}
+}
+
+func (t treeFS) Open(name string) (fs.File, error) {
+	info, err := t.Stat(name)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: errors.New("is a directory")}
+	}
+	buf, err := t.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	return &treeFile{Reader: bytes.NewReader(buf), info: info}, nil
+}
+
+func (t treeFS) ReadFile(name string) ([]byte, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: fs.ErrInvalid}
+	}
+	f, err := t.tree.File(name)
+	if err != nil {
+		return nil, notExist("read", name, err)
+	}
+	contents, err := f.Contents()
+	if err != nil {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: err}
+	}
+	return []byte(contents), nil
+}
+
+func (t treeFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrInvalid}
+	}
+	tree := t.tree
+	if name != "." {
+		var err error
+		if tree, err = t.tree.Tree(name); err != nil {
+			return nil, notExist("readdir", name, err)
+		}
+	}
+	entries := make([]fs.DirEntry, 0, len(tree.Entries))
+	for _, e := range tree.Entries {
+		mode, err := e.Mode.ToOSFileMode()
+		if err != nil {
+			return nil, &fs.PathError{Op: "readdir", Path: path.Join(name, e.Name), Err: err}
+		}
+		// sizes are only looked up by Stat
+		entries = append(entries, treeEntry{name: e.Name, mode: mode})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name() < entries[j].Name()
+	})
+	return entries, nil
+}
+
+func (t treeFS) Stat(name string) (fs.FileInfo, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrInvalid}
+	}
+	if name == "." {
+		return treeEntry{name: ".", mode: fs.ModeDir | 0755}, nil
+	}
+	e, err := t.tree.FindEntry(name)
+	if err != nil {
+		return nil, notExist("stat", name, err)
+	}
+	mode, err := e.Mode.ToOSFileMode()
+	if err != nil {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: err}
+	}
+	info := treeEntry{name: e.Name, mode: mode}
+	if !mode.IsDir() {
+		f, err := t.tree.TreeEntryFile(e)
+		if err != nil {
+			return nil, &fs.PathError{Op: "stat", Path: name, Err: err}
+		}
+		info.size = f.Size
+	}
+	return info, nil
+}
+
+// treeEntry describes a file or directory of a git tree.
+type treeEntry struct {
+	name string
+	mode fs.FileMode
+	size int64
+}
+
+func (e treeEntry) Name() string               { return e.name }
+func (e treeEntry) Size() int64                { return e.size }
+func (e treeEntry) Mode() fs.FileMode          { return e.mode }
+func (e treeEntry) ModTime() time.Time         { return time.Time{} }
+func (e treeEntry) IsDir() bool                { return e.mode.IsDir() }
+func (e treeEntry) Sys() interface{}           { return nil }
+func (e treeEntry) Type() fs.FileMode          { return e.mode.Type() }
+func (e treeEntry) Info() (fs.FileInfo, error) { return e, nil }
+
+type treeFile struct {
+	*bytes.Reader
+	info fs.FileInfo
+}
+
+func (f *treeFile) Stat() (fs.FileInfo, error) { return f.info, nil }
+func (f *treeFile) Close() error               { return nil }
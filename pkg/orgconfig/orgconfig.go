@@ -0,0 +1,329 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package orgconfig loads and edits the org configs of a checkout of this
+// repo.
+//
+// Each org is defined by config/<org>/org.yaml along with the teams.yaml
+// files in the directories below it, which are merged into a single
+// org.Config the way peribolos expects it. Every team keeps track of the file
+// it was defined in so that changes can be written back to the right place
+// without touching the formatting or comments of the rest of the file.
+package orgconfig
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/prow/pkg/config/org"
+	"sigs.k8s.io/prow/pkg/github"
+
+	"k8s.io/org/pkg/diagnostics"
+)
+
+// Repo is a checkout of the org configs.
+type Repo struct {
+	// Root is the directory containing the config directory. Paths of the
+	// files of an org are relative to Root.
+	Root string
+	// AllowOverride lets a teams.yaml file redefine a top-level team defined
+	// in an earlier file instead of failing to load the org.
+	AllowOverride bool
+	// FS holds the files of the repo, the local filesystem if nil. Write
+	// always writes to the local filesystem.
+	FS fs.FS
+}
+
+func (r *Repo) fsys() fs.FS {
+	if r.FS == nil {
+		return osFS{}
+	}
+	return r.FS
+}
+
+// OrgFile returns the path of the org.yaml file of the org relative to the
+// root of the repo.
+func OrgFile(name string) string {
+	return filepath.Join("config", name, "org.yaml")
+}
+
+// OrgNames returns the sorted names of every org with a config/<org>/org.yaml.
+func (r *Repo) OrgNames() ([]string, error) {
+	configDir := filepath.Join(r.Root, "config")
+	entries, err := fs.ReadDir(r.fsys(), configDir)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list orgs in %s: %s", configDir, err)
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		if _, err := fs.Stat(r.fsys(), filepath.Join(r.Root, OrgFile(entry.Name()))); err == nil {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// LoadOrg loads config/<name>/org.yaml with its teams.yaml files.
+func (r *Repo) LoadOrg(name string) (*Org, error) {
+	return r.LoadOrgFile(name, OrgFile(name))
+}
+
+// LoadOrgFile loads the org defined by the org.yaml file at file, relative to
+// the root of the repo, and the teams.yaml files in the directories below it.
+func (r *Repo) LoadOrgFile(name, file string) (*Org, error) {
+	o := &Org{
+		Name:    name,
+		File:    file,
+		repo:    r,
+		teams:   NewTeamIndex(r.AllowOverride),
+		sources: diagnostics.SourceMap{},
+	}
+	o.teams.fsys = r.fsys()
+
+	cfg, err := o.read(filepath.Join(r.Root, file))
+	if err != nil {
+		return nil, err
+	}
+	if err := MergeTeamsFiles(cfg, filepath.Join(r.Root, file), o.teams, o.read); err != nil {
+		return nil, fmt.Errorf("merge teams %s: %w", file, err)
+	}
+	o.Config = *cfg
+	o.Overrides = o.teams.Overrides
+	return o, nil
+}
+
+// Org is the configuration of an org along with the files it was loaded from.
+type Org struct {
+	Name string
+	// Config is org.yaml with the teams of every teams.yaml merged in.
+	Config org.Config
+	// File is the path of org.yaml relative to the root of the repo.
+	File string
+	// Overrides describes every team definition that was replaced by a later
+	// one when the repo allows overrides.
+	Overrides []string
+
+	repo  *Repo
+	teams *TeamIndex
+	files []string
+	// sources maps the entries of every file the org was loaded from back to
+	// their position, keyed by paths relative to the root of the repo.
+	sources diagnostics.SourceMap
+}
+
+// read reads the file at path and records it as one of the files of the org.
+func (o *Org) read(path string) (*org.Config, error) {
+	file := o.relative(path)
+	buf, err := fs.ReadFile(o.repo.fsys(), path)
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %v", file, err)
+	}
+	cfg, err := parse(file, buf, o.sources)
+	if err != nil {
+		return nil, err
+	}
+	o.files = append(o.files, file)
+	return cfg, nil
+}
+
+func (o *Org) relative(path string) string {
+	if o.repo.Root == "" {
+		return path
+	}
+	if rel, err := filepath.Rel(o.repo.Root, path); err == nil {
+		return rel
+	}
+	return path
+}
+
+// Files returns the org.yaml and teams.yaml files of the org relative to the
+// root of the repo, in the order they were merged.
+func (o *Org) Files() []string {
+	return append([]string{}, o.files...)
+}
+
+// Sources returns the parsed files of the org, keyed by their path relative
+// to the root of the repo. Other files, e.g. OWNERS, can be added to it to
+// locate their entries as well.
+func (o *Org) Sources() diagnostics.SourceMap {
+	return o.sources
+}
+
+// Locate returns the location of the key at path in file, e.g. admins or
+// teams.foo.members.
+func (o *Org) Locate(file string, path ...string) diagnostics.Location {
+	return o.sources.Locate(file, path...)
+}
+
+// Team is a team of an org, which may be a child team.
+type Team struct {
+	org.Team
+	// Name is the name of the team as defined in its file.
+	Name string
+	// Parents are the names of the parent teams of the team, starting from
+	// the top-level team.
+	Parents []string
+	// File is the file defining the team relative to the root of the repo.
+	File string
+	// Path is the path of the team within File, e.g. teams.foo.teams.bar.
+	Path []string
+}
+
+// FullName returns the name of the team prefixed with its parent teams,
+// separated by "/".
+func (t Team) FullName() string {
+	return strings.Join(append(append([]string{}, t.Parents...), t.Name), "/")
+}
+
+// Team returns the team of the org named name, compared case-insensitively.
+func (o *Org) Team(name string) (Team, bool) {
+	def, ok := o.teams.teams[strings.ToLower(name)]
+	if !ok {
+		return Team{}, false
+	}
+	teams := o.Config.Teams
+	var t org.Team
+	for _, n := range def.tree {
+		t = teams[n]
+		teams = t.Children
+	}
+	return Team{
+		Team:    t,
+		Name:    def.name,
+		Parents: append([]string{}, def.tree[:len(def.tree)-1]...),
+		File:    o.relative(def.file),
+		Path:    append([]string{}, def.path...),
+	}, true
+}
+
+// Teams returns every team of the org, including child teams, sorted by their
+// full name.
+func (o *Org) Teams() []Team {
+	var teams []Team
+	var walk func(children map[string]org.Team)
+	walk = func(children map[string]org.Team) {
+		for _, name := range sortedTeamNames(children) {
+			if t, ok := o.Team(name); ok {
+				teams = append(teams, t)
+			}
+			walk(children[name].Children)
+		}
+	}
+	walk(o.Config.Teams)
+	sort.SliceStable(teams, func(i, j int) bool {
+		return teams[i].FullName() < teams[j].FullName()
+	})
+	return teams
+}
+
+// Role is the key of a list of users in an org or team.
+type Role string
+
+const (
+	Admin      Role = "admins"
+	Member     Role = "members"
+	Maintainer Role = "maintainers"
+)
+
+// Membership locates a user in a list of an org or one of its teams.
+type Membership struct {
+	// Team is the full name of the team, empty for the org itself.
+	Team string
+	Role Role
+	// File is the file defining the list relative to the root of the repo.
+	File string
+	// Path is the path of the list within File, e.g. teams.foo.members.
+	Path []string
+}
+
+// Memberships returns every list of the org and its teams containing login,
+// compared case-insensitively.
+func (o *Org) Memberships(login string) []Membership {
+	var memberships []Membership
+	for _, role := range []Role{Admin, Member} {
+		list := o.Config.Admins
+		if role == Member {
+			list = o.Config.Members
+		}
+		if ContainsLogin(list, login) {
+			memberships = append(memberships, Membership{Role: role, File: o.File, Path: []string{string(role)}})
+		}
+	}
+	for _, t := range o.Teams() {
+		for _, role := range []Role{Maintainer, Member} {
+			list := t.Members
+			if role == Maintainer {
+				list = t.Maintainers
+			}
+			if ContainsLogin(list, login) {
+				memberships = append(memberships, Membership{
+					Team: t.FullName(),
+					Role: role,
+					File: t.File,
+					Path: append(append([]string{}, t.Path...), string(role)),
+				})
+			}
+		}
+	}
+	return memberships
+}
+
+// IsMember returns whether login is a member or an admin of the org.
+func (o *Org) IsMember(login string) bool {
+	return ContainsLogin(o.Config.Members, login) || ContainsLogin(o.Config.Admins, login)
+}
+
+// IsAdmin returns whether login is an admin of the org.
+func (o *Org) IsAdmin(login string) bool {
+	return ContainsLogin(o.Config.Admins, login)
+}
+
+// ContainsLogin returns whether list contains login. GitHub logins are
+// compared case-insensitively.
+func ContainsLogin(list []string, login string) bool {
+	for _, l := range list {
+		if strings.EqualFold(l, login) {
+			return true
+		}
+	}
+	return false
+}
+
+// PermissionRank orders repo permissions from read to admin so that they can
+// be compared. Unknown permissions rank lowest.
+func PermissionRank(p github.RepoPermissionLevel) int {
+	switch p {
+	case github.Read:
+		return 1
+	case github.Triage:
+		return 2
+	case github.Write:
+		return 3
+	case github.Maintain:
+		return 4
+	case github.Admin:
+		return 5
+	}
+	return 0
+}
@@ -0,0 +1,187 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package orgconfig
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// setupRepo copies the yamledit fixtures into a directory laid out like this
+// repo and returns it.
+func setupRepo(t *testing.T) *Repo {
+	t.Helper()
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"config/kubernetes/org.yaml":               string(readTestdata(t, "yamledit/org.yaml")),
+		"config/kubernetes/sig-network/teams.yaml": string(readTestdata(t, "yamledit/teams.yaml")),
+		"config/kubernetes/sig-network/sig-network-leads/teams.yaml": `teams:
+  sig-network-leads-emeritus:
+    members:
+    - cblecker
+`,
+		"config/not-an-org/README.md": "",
+	})
+	return &Repo{Root: root}
+}
+
+func TestRepoLoadOrg(t *testing.T) {
+	repo := setupRepo(t)
+
+	names, err := repo.OrgNames()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := []string{"kubernetes"}; !reflect.DeepEqual(names, expected) {
+		t.Errorf("expected orgs %v, got %v", expected, names)
+	}
+
+	o, err := repo.LoadOrg("kubernetes")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedFiles := []string{
+		"config/kubernetes/org.yaml",
+		"config/kubernetes/sig-network/teams.yaml",
+		"config/kubernetes/sig-network/sig-network-leads/teams.yaml",
+	}
+	if files := o.Files(); !reflect.DeepEqual(files, expectedFiles) {
+		t.Errorf("expected files %v, got %v", expectedFiles, files)
+	}
+
+	var teamNames []string
+	for _, team := range o.Teams() {
+		teamNames = append(teamNames, team.FullName())
+	}
+	expectedTeams := []string{
+		"sig-network-leads",
+		"sig-network-leads/sig-network-leads-emeritus",
+		"sig-network-reviewers",
+		"sig-network-solo",
+	}
+	if !reflect.DeepEqual(teamNames, expectedTeams) {
+		t.Errorf("expected teams %v, got %v", expectedTeams, teamNames)
+	}
+
+	expected := []Membership{
+		{
+			Role: Admin,
+			File: "config/kubernetes/org.yaml",
+			Path: []string{"admins"},
+		},
+		{
+			Team: "sig-network-leads",
+			Role: Maintainer,
+			File: "config/kubernetes/sig-network/teams.yaml",
+			Path: []string{"teams", "sig-network-leads", "maintainers"},
+		},
+		{
+			Team: "sig-network-leads/sig-network-leads-emeritus",
+			Role: Member,
+			File: "config/kubernetes/sig-network/sig-network-leads/teams.yaml",
+			Path: []string{"teams", "sig-network-leads-emeritus", "members"},
+		},
+	}
+	if memberships := o.Memberships("CBlecker"); !reflect.DeepEqual(memberships, expected) {
+		t.Errorf("expected memberships %#v, got %#v", expected, memberships)
+	}
+}
+
+func TestOrgEdits(t *testing.T) {
+	repo := setupRepo(t)
+	o, err := repo.LoadOrg("kubernetes")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var changes Changes
+	e, ok := o.AddMember("alice")
+	if !ok {
+		t.Fatalf("expected alice to be added")
+	}
+	changes.Add(e)
+	if _, ok := o.AddMember("ALICE"); ok {
+		t.Errorf("expected ALICE to already be a member")
+	}
+
+	e, ok, err = o.AddTeamMember("sig-network-leads-emeritus", "alice", Member)
+	if err != nil || !ok {
+		t.Fatalf("expected alice to be added to sig-network-leads-emeritus, got %v %v", ok, err)
+	}
+	changes.Add(e)
+	if team, _ := o.Team("sig-network-leads-emeritus"); !ContainsLogin(team.Members, "alice") {
+		t.Errorf("expected the config to be updated, got members %v", team.Members)
+	}
+
+	e, ok, err = o.RemoveTeamMember("sig-network-leads", "thockin")
+	if err != nil || !ok {
+		t.Fatalf("expected thockin to be removed from sig-network-leads, got %v %v", ok, err)
+	}
+	changes.Add(e)
+	if _, _, err := o.AddTeamMember("no-such-team", "alice", Member); err == nil {
+		t.Errorf("expected an error for an undefined team")
+	}
+
+	expectedFiles := []string{
+		"config/kubernetes/org.yaml",
+		"config/kubernetes/sig-network/sig-network-leads/teams.yaml",
+		"config/kubernetes/sig-network/teams.yaml",
+	}
+	if files := changes.Files(); !reflect.DeepEqual(files, expectedFiles) || changes.Len() != 3 {
+		t.Errorf("expected 3 edits of %v, got %d of %v", expectedFiles, changes.Len(), files)
+	}
+	if err := repo.Write(&changes); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	compareGolden(t, "yamledit/org-add-middle.golden", mustReadFile(t, filepath.Join(repo.Root, expectedFiles[0])))
+	compareGolden(t, "yamledit/teams-remove-head-comment.golden", mustReadFile(t, filepath.Join(repo.Root, expectedFiles[2])))
+	reloaded, err := repo.LoadOrg("kubernetes")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if team, _ := reloaded.Team("sig-network-leads-emeritus"); !reflect.DeepEqual(team.Members, []string{"alice", "cblecker"}) {
+		t.Errorf("expected alice to be written to sig-network-leads-emeritus, got %v", team.Members)
+	}
+}
+
+func TestWriteLeavesFilesUntouchedOnError(t *testing.T) {
+	repo := setupRepo(t)
+	orgFile := filepath.Join(repo.Root, "config/kubernetes/org.yaml")
+	before := mustReadFile(t, orgFile)
+
+	var changes Changes
+	changes.Add(Edit{File: "config/kubernetes/org.yaml", Path: []string{"members"}, Value: "alice"})
+	changes.Add(Edit{File: "config/kubernetes/sig-network/teams.yaml", Path: []string{"teams", "no-such-team", "members"}, Value: "alice"})
+	if err := repo.Write(&changes); err == nil {
+		t.Fatalf("expected an error editing an undefined team")
+	}
+	if after := mustReadFile(t, orgFile); string(after) != string(before) {
+		t.Errorf("expected org.yaml to be untouched, got:\n%s", after)
+	}
+}
+
+func mustReadFile(t *testing.T, path string) []byte {
+	t.Helper()
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
@@ -0,0 +1,208 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package orgconfig
+
+import (
+	"fmt"
+	"io/fs"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/prow/pkg/config/org"
+
+	"k8s.io/org/pkg/diagnostics"
+)
+
+// teamDefinition records where a team, or a child team, is defined.
+type teamDefinition struct {
+	name string
+	file string
+	// path is the path of the team within file, e.g. teams.foo.teams.bar.
+	path []string
+	// tree is the names of the team and its parents in the merged config,
+	// starting from the top-level team.
+	tree []string
+}
+
+func (d teamDefinition) topLevel() bool {
+	return len(d.tree) == 1
+}
+
+// TeamIndex merges the teams of an org defined across org.yaml and teams.yaml
+// files. GitHub team names are unique within an org regardless of case and
+// nesting, so a team defined more than once is an error unless overrides are
+// allowed, in which case a top-level team replaces an earlier definition from
+// another file.
+type TeamIndex struct {
+	allowOverride bool
+	teams         map[string]teamDefinition
+	sources       diagnostics.SourceMap
+	// fsys holds the files being merged, the local filesystem by default.
+	fsys fs.FS
+	// Overrides describes every team definition that was replaced by a later
+	// one.
+	Overrides []string
+}
+
+// NewTeamIndex returns an empty index.
+func NewTeamIndex(allowOverride bool) *TeamIndex {
+	return &TeamIndex{
+		allowOverride: allowOverride,
+		teams:         map[string]teamDefinition{},
+		sources:       diagnostics.SourceMap{},
+		fsys:          osFS{},
+	}
+}
+
+// Merge adds the teams defined in file to into. It returns a diagnostics.List
+// with an entry for every team that was already defined.
+func (idx *TeamIndex) Merge(into map[string]org.Team, file string, teams map[string]org.Team) error {
+	var errs diagnostics.List
+	for _, name := range sortedTeamNames(teams) {
+		def := teamDefinition{name: name, file: file, path: []string{"teams", name}, tree: []string{name}}
+		if prev, ok := idx.teams[strings.ToLower(name)]; ok {
+			if !idx.allowOverride || !prev.topLevel() || prev.file == file {
+				errs = append(errs, idx.collision(prev, def))
+				continue
+			}
+			idx.remove(prev)
+			delete(into, prev.name)
+			idx.Overrides = append(idx.Overrides, fmt.Sprintf("team %s defined at %s is overridden by %s", name, idx.locate(prev), idx.locate(def)))
+		}
+		idx.teams[strings.ToLower(name)] = def
+		errs = append(errs, idx.addChildren(def, teams[name].Children)...)
+		into[name] = teams[name]
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// MergeChildren adds the teams defined in file as children of the team named
+// parent, which must be defined in the file enclosing.
+func (idx *TeamIndex) MergeChildren(into map[string]org.Team, file, parent, enclosing string, teams map[string]org.Team) error {
+	p, ok := idx.teams[strings.ToLower(parent)]
+	if !ok || p.file != enclosing {
+		return diagnostics.List{
+			diagnostics.Errorf(diagnostics.Location{File: file}, "parent team %s is not defined in %s", parent, enclosing),
+		}
+	}
+
+	var errs diagnostics.List
+	children := map[string]org.Team{}
+	for _, name := range sortedTeamNames(teams) {
+		def := teamDefinition{
+			name: name,
+			file: file,
+			path: []string{"teams", name},
+			tree: append(append([]string{}, p.tree...), name),
+		}
+		if prev, ok := idx.teams[strings.ToLower(name)]; ok {
+			errs = append(errs, idx.collision(prev, def))
+			continue
+		}
+		idx.teams[strings.ToLower(name)] = def
+		errs = append(errs, idx.addChildren(def, teams[name].Children)...)
+		children[name] = teams[name]
+	}
+	attachChildren(into, p.tree, children)
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func (idx *TeamIndex) addChildren(parent teamDefinition, children map[string]org.Team) diagnostics.List {
+	var errs diagnostics.List
+	for _, name := range sortedTeamNames(children) {
+		def := teamDefinition{
+			name: name,
+			file: parent.file,
+			path: append(append([]string{}, parent.path...), "teams", name),
+			tree: append(append([]string{}, parent.tree...), name),
+		}
+		if prev, ok := idx.teams[strings.ToLower(name)]; ok {
+			errs = append(errs, idx.collision(prev, def))
+			continue
+		}
+		idx.teams[strings.ToLower(name)] = def
+		errs = append(errs, idx.addChildren(def, children[name].Children)...)
+	}
+	return errs
+}
+
+// attachChildren adds children to the team at tree in teams.
+func attachChildren(teams map[string]org.Team, tree []string, children map[string]org.Team) {
+	t := teams[tree[0]]
+	if t.Children == nil {
+		t.Children = map[string]org.Team{}
+	}
+	if len(tree) == 1 {
+		for name, child := range children {
+			t.Children[name] = child
+		}
+	} else {
+		attachChildren(t.Children, tree[1:], children)
+	}
+	teams[tree[0]] = t
+}
+
+// remove drops the top-level team def and every team under it, wherever they
+// are defined, from the index.
+func (idx *TeamIndex) remove(def teamDefinition) {
+	for key, d := range idx.teams {
+		if d.tree[0] == def.name {
+			delete(idx.teams, key)
+		}
+	}
+}
+
+// Source returns the file defining the team named name, and the path of the
+// team within that file.
+func (idx *TeamIndex) Source(name string) (string, []string, bool) {
+	def, ok := idx.teams[strings.ToLower(name)]
+	if !ok {
+		return "", nil, false
+	}
+	return def.file, def.path, true
+}
+
+func (idx *TeamIndex) collision(prev, def teamDefinition) diagnostics.Diagnostic {
+	return diagnostics.Errorf(idx.locate(def), "team %s is already defined at %s", def.name, idx.locate(prev))
+}
+
+func (idx *TeamIndex) locate(def teamDefinition) diagnostics.Location {
+	if _, ok := idx.sources[def.file]; !ok {
+		// positions are only needed to report collisions, so files are
+		// parsed lazily
+		if buf, err := fs.ReadFile(idx.fsys, def.file); err == nil {
+			_ = idx.sources.Add(def.file, buf)
+		}
+	}
+	return idx.sources.Locate(def.file, def.path...)
+}
+
+func sortedTeamNames(teams map[string]org.Team) []string {
+	names := make([]string, 0, len(teams))
+	for name := range teams {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,133 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package orgconfig
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"sigs.k8s.io/prow/pkg/config/org"
+
+	"k8s.io/org/pkg/diagnostics"
+)
+
+func TestTeamIndexMerge(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"org.yaml": `teams:
+  sig-foo:
+    teams:
+      sig-foo-leads: {}
+`,
+		"a/teams.yaml": `teams:
+  SIG-FOO:
+    description: redefined
+`,
+		"b/teams.yaml": `teams:
+  sig-bar:
+    teams:
+      sig-foo-leads: {}
+`,
+	}
+	for name, contents := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	orgYAML := filepath.Join(dir, "org.yaml")
+	aTeams := filepath.Join(dir, "a/teams.yaml")
+	bTeams := filepath.Join(dir, "b/teams.yaml")
+
+	orgTeams := map[string]org.Team{
+		"sig-foo": {Children: map[string]org.Team{"sig-foo-leads": {}}},
+	}
+	description := "redefined"
+	aTeamsCfg := map[string]org.Team{"SIG-FOO": {TeamMetadata: org.TeamMetadata{Description: &description}}}
+	bTeamsCfg := map[string]org.Team{
+		"sig-bar": {Children: map[string]org.Team{"sig-foo-leads": {}}},
+	}
+
+	t.Run("collisions", func(t *testing.T) {
+		idx := NewTeamIndex(false)
+		merged := map[string]org.Team{}
+		if err := idx.Merge(merged, orgYAML, orgTeams); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		err := idx.Merge(merged, aTeams, aTeamsCfg)
+		expected := diagnostics.List{
+			diagnostics.Errorf(diagnostics.Location{File: aTeams, Line: 2, Column: 3}, "team SIG-FOO is already defined at %s:2:3", orgYAML),
+		}
+		if !reflect.DeepEqual(err, expected) {
+			t.Errorf("expected %v, got %v", expected, err)
+		}
+
+		err = idx.Merge(merged, bTeams, bTeamsCfg)
+		expected = diagnostics.List{
+			diagnostics.Errorf(diagnostics.Location{File: bTeams, Line: 4, Column: 7}, "team sig-foo-leads is already defined at %s:4:7", orgYAML),
+		}
+		if !reflect.DeepEqual(err, expected) {
+			t.Errorf("expected %v, got %v", expected, err)
+		}
+	})
+
+	t.Run("allow override", func(t *testing.T) {
+		idx := NewTeamIndex(true)
+		merged := map[string]org.Team{}
+		if err := idx.Merge(merged, orgYAML, orgTeams); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := idx.Merge(merged, aTeams, aTeamsCfg); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		// sig-foo-leads went away with the overridden sig-foo
+		if err := idx.Merge(merged, bTeams, bTeamsCfg); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		var names []string
+		for name := range merged {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		if expected := []string{"SIG-FOO", "sig-bar"}; !reflect.DeepEqual(names, expected) {
+			t.Errorf("expected teams %v, got %v", expected, names)
+		}
+		expected := []string{"team SIG-FOO defined at " + orgYAML + ":2:3 is overridden by " + aTeams + ":2:3"}
+		if !reflect.DeepEqual(idx.Overrides, expected) {
+			t.Errorf("expected overrides %q, got %q", expected, idx.Overrides)
+		}
+	})
+
+	t.Run("child teams cannot be overridden", func(t *testing.T) {
+		idx := NewTeamIndex(true)
+		merged := map[string]org.Team{}
+		if err := idx.Merge(merged, orgYAML, orgTeams); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := idx.Merge(merged, bTeams, bTeamsCfg); err == nil {
+			t.Errorf("expected error")
+		}
+	})
+}
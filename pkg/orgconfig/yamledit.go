@@ -0,0 +1,574 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package orgconfig
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Document is a line oriented view of a YAML file that uses the parsed
+// node tree to locate entries. Edits are applied to the raw lines so that
+// comments, quoting and formatting of everything else in the file are kept
+// byte for byte.
+type Document struct {
+	lines           []string
+	trailingNewline bool
+	root            *yaml.Node
+}
+
+// ParseDocument parses content, which must be a valid YAML document.
+func ParseDocument(content []byte) (*Document, error) {
+	d := &Document{
+		trailingNewline: bytes.HasSuffix(content, []byte("\n")),
+	}
+	trimmed := strings.TrimSuffix(string(content), "\n")
+	if trimmed != "" {
+		d.lines = strings.Split(trimmed, "\n")
+	}
+	if err := d.reparse(); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+func (d *Document) reparse() error {
+	var root yaml.Node
+	if err := yaml.Unmarshal(d.Bytes(), &root); err != nil {
+		return fmt.Errorf("unable to parse yaml: %v", err)
+	}
+	d.root = &root
+	return nil
+}
+
+// Bytes returns the current contents of the document.
+func (d *Document) Bytes() []byte {
+	out := strings.Join(d.lines, "\n")
+	if d.trailingNewline || len(d.lines) == 0 {
+		out += "\n"
+	}
+	return []byte(out)
+}
+
+// lookup walks the mapping keys in path and returns the mapping holding the
+// last key along with the key and value nodes. key and value are nil if the
+// last key does not exist; an error is returned if any intermediate key is
+// missing or is not a mapping.
+func (d *Document) lookup(path ...string) (parent, key, value *yaml.Node, err error) {
+	if len(path) == 0 {
+		return nil, nil, nil, fmt.Errorf("empty path")
+	}
+	if len(d.root.Content) == 0 {
+		return nil, nil, nil, fmt.Errorf("empty document")
+	}
+	parent = d.root.Content[0]
+	for i, p := range path {
+		if parent.Kind != yaml.MappingNode {
+			return nil, nil, nil, fmt.Errorf("%s is not a mapping", strings.Join(path[:i], "."))
+		}
+		key, value = mappingEntry(parent, p)
+		if i == len(path)-1 {
+			break
+		}
+		if value == nil {
+			return nil, nil, nil, fmt.Errorf("%s not found", strings.Join(path[:i+1], "."))
+		}
+		parent = value
+	}
+	return parent, key, value, nil
+}
+
+func mappingEntry(mapping *yaml.Node, name string) (*yaml.Node, *yaml.Node) {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == name {
+			return mapping.Content[i], mapping.Content[i+1]
+		}
+	}
+	return nil, nil
+}
+
+// AddToList inserts value into the list at path, keeping the list sorted
+// case-insensitively. The list is created if it doesn't exist yet. It returns
+// false if value (compared case-insensitively) was already present.
+func (d *Document) AddToList(value string, path ...string) (bool, error) {
+	parent, key, list, err := d.lookup(path...)
+	if err != nil {
+		return false, err
+	}
+	item, err := formatScalar(value)
+	if err != nil {
+		return false, err
+	}
+
+	switch {
+	case list == nil:
+		d.insertKey(parent, path[len(path)-1], item)
+	case list.Kind != yaml.SequenceNode:
+		return false, fmt.Errorf("%s is not a list", strings.Join(path, "."))
+	case list.Style&yaml.FlowStyle != 0 && len(list.Content) > 0:
+		return false, fmt.Errorf("%s: unsupported flow list layout", strings.Join(path, "."))
+	case len(list.Content) == 0:
+		if key.Line != list.Line {
+			return false, fmt.Errorf("%s: unsupported empty list layout", strings.Join(path, "."))
+		}
+		indent := d.indentOf(key.Line)
+		d.replaceLines(key.Line-1, key.Line, indent+key.Value+":", indent+"- "+item)
+	default:
+		for _, n := range list.Content {
+			if strings.EqualFold(n.Value, value) {
+				return false, nil
+			}
+		}
+		prefix := d.lines[list.Content[0].Line-1][:list.Content[0].Column-1]
+		at := d.endLine(list.Content[len(list.Content)-1])
+		for _, n := range list.Content {
+			if strings.ToLower(n.Value) > strings.ToLower(value) {
+				at = d.startLine(n) - 1
+				break
+			}
+		}
+		d.replaceLines(at, at, prefix+item)
+	}
+	return true, d.reparse()
+}
+
+// RemoveFromList removes value (compared case-insensitively) from the list at
+// path along with any comments attached to it. If the list becomes empty its
+// key is removed as well. It returns false if value was not present.
+func (d *Document) RemoveFromList(value string, path ...string) (bool, error) {
+	_, key, list, err := d.lookup(path...)
+	if err != nil {
+		return false, err
+	}
+	if list == nil {
+		return false, nil
+	}
+	if list.Kind != yaml.SequenceNode {
+		return false, fmt.Errorf("%s is not a list", strings.Join(path, "."))
+	}
+	if list.Style&yaml.FlowStyle != 0 && len(list.Content) > 0 {
+		return false, fmt.Errorf("%s: unsupported flow list layout", strings.Join(path, "."))
+	}
+
+	for _, n := range list.Content {
+		if !strings.EqualFold(n.Value, value) {
+			continue
+		}
+		start, end := d.startLine(n), d.endLine(n)
+		if len(list.Content) == 1 && strings.TrimSpace(d.lines[key.Line-1]) == key.Value+":" {
+			start = d.startLine(key)
+		}
+		d.replaceLines(start-1, end)
+		return true, d.reparse()
+	}
+	return false, nil
+}
+
+// RenameInList replaces old (compared case-insensitively) in the list at path
+// with new. The item keeps its quoting style where possible and its comments,
+// and is moved along with them to keep the list sorted case-insensitively. It
+// returns false if old was not present.
+func (d *Document) RenameInList(old, new string, path ...string) (bool, error) {
+	_, _, list, err := d.lookup(path...)
+	if err != nil {
+		return false, err
+	}
+	if list == nil {
+		return false, nil
+	}
+	if list.Kind != yaml.SequenceNode {
+		return false, fmt.Errorf("%s is not a list", strings.Join(path, "."))
+	}
+	if list.Style&yaml.FlowStyle != 0 {
+		return false, fmt.Errorf("%s: unsupported flow list layout", strings.Join(path, "."))
+	}
+	item, err := formatScalar(new)
+	if err != nil {
+		return false, err
+	}
+
+	for i, n := range list.Content {
+		if !strings.EqualFold(n.Value, old) {
+			continue
+		}
+		if n.Kind != yaml.ScalarNode || n.Style&(yaml.LiteralStyle|yaml.FoldedStyle) != 0 {
+			return false, fmt.Errorf("%s: unsupported item layout for %s", strings.Join(path, "."), n.Value)
+		}
+		// keep the spacing between the item and its inline comment
+		line := d.lines[n.Line-1]
+		token, suffix := line[n.Column-1:], ""
+		if n.LineComment != "" {
+			if at := strings.LastIndex(token, n.LineComment); at >= 0 {
+				token, suffix = token[:at], token[at:]
+			}
+		}
+		trimmed := strings.TrimRight(token, " \t")
+		suffix = token[len(trimmed):] + suffix
+		if n.Style&(yaml.SingleQuotedStyle|yaml.DoubleQuotedStyle) != 0 && item == new {
+			item = trimmed[:1] + new + trimmed[:1]
+		}
+		d.lines[n.Line-1] = line[:n.Column-1] + item + suffix
+
+		if len(list.Content) == 1 {
+			return true, d.reparse()
+		}
+		start, end := d.startLine(n), d.endLine(n)
+		block := append([]string{}, d.lines[start-1:end]...)
+		others := append(append([]*yaml.Node{}, list.Content[:i]...), list.Content[i+1:]...)
+		// lines of the remaining items are shifted once the item is removed
+		shift := func(line int) int {
+			if line > end {
+				return line - (end - start + 1)
+			}
+			return line
+		}
+		at := shift(d.endLine(others[len(others)-1]))
+		for _, o := range others {
+			if strings.ToLower(o.Value) > strings.ToLower(new) {
+				at = shift(d.startLine(o)) - 1
+				break
+			}
+		}
+		d.replaceLines(start-1, end)
+		d.replaceLines(at, at, block...)
+		return true, d.reparse()
+	}
+	return false, nil
+}
+
+// ListsContaining returns the paths of the lists nested in mappings that
+// contain value, compared case-insensitively.
+func (d *Document) ListsContaining(value string) [][]string {
+	var paths [][]string
+	var walk func(n *yaml.Node, path []string)
+	walk = func(n *yaml.Node, path []string) {
+		switch n.Kind {
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(n.Content); i += 2 {
+				walk(n.Content[i+1], append(append([]string{}, path...), n.Content[i].Value))
+			}
+		case yaml.SequenceNode:
+			for _, c := range n.Content {
+				if c.Kind == yaml.ScalarNode && strings.EqualFold(c.Value, value) {
+					paths = append(paths, path)
+					return
+				}
+			}
+		}
+	}
+	if len(d.root.Content) > 0 {
+		walk(d.root.Content[0], nil)
+	}
+	return paths
+}
+
+// RemoveKey removes the key at path along with its value and head comment.
+// It returns the removed lines without the indentation of the key so that
+// they can be inserted elsewhere with InsertKey. If the mapping holding the
+// key becomes empty its own key is removed as well. It returns false if the
+// key does not exist.
+func (d *Document) RemoveKey(path ...string) ([]string, bool, error) {
+	parent, key, value, err := d.lookup(path...)
+	if err != nil {
+		return nil, false, err
+	}
+	if key == nil {
+		return nil, false, nil
+	}
+	start, end := d.startLine(key), d.endLine(value)
+	block := d.dedent(start, end, d.indentOf(key.Line))
+	if len(parent.Content) == 2 && len(path) > 1 {
+		if _, parentKey, _, err := d.lookup(path[:len(path)-1]...); err == nil && parentKey.Line < key.Line {
+			start = d.startLine(parentKey)
+		}
+	}
+	d.replaceLines(start-1, end)
+	return block, true, d.reparse()
+}
+
+// InsertKey inserts block, as returned by RemoveKey, as the entry named name
+// of the mapping at path, keeping the keys of the mapping sorted. Missing
+// mappings along path are created. An empty path inserts at the top level of
+// the document.
+func (d *Document) InsertKey(name string, block []string, path ...string) error {
+	var mapping, key *yaml.Node
+	if len(d.root.Content) > 0 {
+		mapping = d.root.Content[0]
+	}
+	for i, p := range path {
+		var k, value *yaml.Node
+		if mapping != nil {
+			if mapping.Kind != yaml.MappingNode {
+				return fmt.Errorf("%s is not a mapping", strings.Join(path[:i], "."))
+			}
+			k, value = mappingEntry(mapping, p)
+		}
+		if k == nil {
+			// nest the block under the missing keys and insert it as the
+			// first of them
+			for j := len(path) - 1; j >= i; j-- {
+				block = append([]string{path[j] + ":"}, indentLines(block, "  ")...)
+				name = path[j]
+			}
+			break
+		}
+		mapping, key = value, k
+	}
+	if mapping != nil && mapping.Kind == yaml.MappingNode {
+		if k, _ := mappingEntry(mapping, name); k != nil {
+			return fmt.Errorf("%s already exists", strings.Join(append(append([]string{}, path...), name), "."))
+		}
+	}
+
+	switch {
+	case mapping == nil:
+		d.lines = append(d.lines, block...)
+		d.trailingNewline = true
+	case mapping.Kind == yaml.MappingNode && len(mapping.Content) > 0:
+		indent := d.indentOf(mapping.Content[0].Line)
+		at := d.endLine(mapping.Content[len(mapping.Content)-1])
+		for i := 0; i+1 < len(mapping.Content); i += 2 {
+			if mapping.Content[i].Value > name {
+				at = d.startLine(mapping.Content[i]) - 1
+				break
+			}
+		}
+		d.replaceLines(at, at, indentLines(block, indent)...)
+	case key != nil && (mapping.Kind == yaml.MappingNode || mapping.Tag == "!!null") && mapping.Line == key.Line:
+		// an empty mapping, e.g. "teams: {}", or a key without a value
+		indent := d.indentOf(key.Line)
+		lines := append([]string{indent + key.Value + ":"}, indentLines(block, indent+"  ")...)
+		d.replaceLines(key.Line-1, key.Line, lines...)
+	default:
+		return fmt.Errorf("%s is not a mapping", strings.Join(path, "."))
+	}
+	return d.reparse()
+}
+
+// RenameKey renames the key at path to name, moving the entry along with its
+// head comment to keep the keys of the mapping sorted.
+func (d *Document) RenameKey(name string, path ...string) error {
+	parent, key, _, err := d.lookup(path...)
+	if err != nil {
+		return err
+	}
+	if key == nil {
+		return fmt.Errorf("%s not found", strings.Join(path, "."))
+	}
+	if k, _ := mappingEntry(parent, name); k != nil {
+		return fmt.Errorf("%s already exists", strings.Join(append(append([]string{}, path[:len(path)-1]...), name), "."))
+	}
+	formatted, err := formatScalar(name)
+	if err != nil {
+		return err
+	}
+
+	line := d.lines[key.Line-1]
+	token := line[key.Column-1:]
+	length := len(key.Value)
+	if key.Style&(yaml.SingleQuotedStyle|yaml.DoubleQuotedStyle) != 0 {
+		length = strings.Index(token[1:], token[:1]) + 2
+	}
+	d.lines[key.Line-1] = line[:key.Column-1] + formatted + token[length:]
+	if err := d.reparse(); err != nil {
+		return err
+	}
+	if len(parent.Content) == 2 {
+		return nil
+	}
+
+	renamed := append(append([]string{}, path[:len(path)-1]...), name)
+	block, _, err := d.RemoveKey(renamed...)
+	if err != nil {
+		return err
+	}
+	return d.InsertKey(name, block, path[:len(path)-1]...)
+}
+
+// SetValue sets the scalar at path to value, keeping any comment on its line.
+// If the key does not exist it is inserted, keeping the keys of its mapping
+// sorted, along with any missing mappings along path. It returns the previous
+// value, or "" if the key was inserted.
+func (d *Document) SetValue(value string, path ...string) (string, error) {
+	formatted, err := formatScalar(value)
+	if err != nil {
+		return "", err
+	}
+	_, key, node, err := d.lookup(path...)
+	if err != nil || key == nil {
+		name, err := formatScalar(path[len(path)-1])
+		if err != nil {
+			return "", err
+		}
+		return "", d.InsertKey(path[len(path)-1], []string{name + ": " + formatted}, path[:len(path)-1]...)
+	}
+	if node.Kind != yaml.ScalarNode || node.Line != key.Line || node.Style&(yaml.LiteralStyle|yaml.FoldedStyle) != 0 {
+		return "", fmt.Errorf("%s is not a single line scalar", strings.Join(path, "."))
+	}
+
+	previous := node.Value
+	line := d.lines[node.Line-1]
+	token := line[node.Column-1:]
+	length := len(node.Value)
+	if node.Style&(yaml.SingleQuotedStyle|yaml.DoubleQuotedStyle) != 0 {
+		length = strings.Index(token[1:], token[:1]) + 2
+	}
+	d.lines[node.Line-1] = line[:node.Column-1] + formatted + token[length:]
+	return previous, d.reparse()
+}
+
+// dedent returns lines start to end (1-indexed, inclusive) without indent.
+func (d *Document) dedent(start, end int, indent string) []string {
+	var lines []string
+	for _, l := range d.lines[start-1 : end] {
+		if strings.HasPrefix(l, indent) {
+			lines = append(lines, strings.TrimPrefix(l, indent))
+		} else {
+			lines = append(lines, strings.TrimLeft(l, " "))
+		}
+	}
+	return lines
+}
+
+func indentLines(lines []string, indent string) []string {
+	indented := make([]string, 0, len(lines))
+	for _, l := range lines {
+		if l == "" {
+			indented = append(indented, l)
+			continue
+		}
+		indented = append(indented, indent+l)
+	}
+	return indented
+}
+
+// insertKey adds a new "name:" entry holding a single item list to mapping,
+// placing it before the first key that sorts after it.
+func (d *Document) insertKey(mapping *yaml.Node, name, item string) {
+	var indent string
+	at := len(d.lines)
+	if len(mapping.Content) > 0 {
+		indent = d.indentOf(mapping.Content[0].Line)
+		at = d.endLine(mapping.Content[len(mapping.Content)-1])
+		for i := 0; i+1 < len(mapping.Content); i += 2 {
+			if mapping.Content[i].Value > name {
+				at = d.startLine(mapping.Content[i]) - 1
+				break
+			}
+		}
+	}
+	d.replaceLines(at, at, indent+name+":", indent+"- "+item)
+}
+
+// replaceLines replaces lines[start:end] (0-indexed) with the given lines.
+func (d *Document) replaceLines(start, end int, lines ...string) {
+	updated := make([]string, 0, len(d.lines)-(end-start)+len(lines))
+	updated = append(updated, d.lines[:start]...)
+	updated = append(updated, lines...)
+	updated = append(updated, d.lines[end:]...)
+	d.lines = updated
+}
+
+func (d *Document) indentOf(line int) string {
+	l := d.lines[line-1]
+	return l[:len(l)-len(strings.TrimLeft(l, " "))]
+}
+
+// startLine returns the first line (1-indexed) of n including its head comment.
+func (d *Document) startLine(n *yaml.Node) int {
+	if n.HeadComment == "" {
+		return n.Line
+	}
+	return n.Line - strings.Count(n.HeadComment, "\n") - 1
+}
+
+// endLine returns the last line (1-indexed) spanned by n and its children.
+func (d *Document) endLine(n *yaml.Node) int {
+	end := n.Line
+	if n.Kind == yaml.ScalarNode && n.Style&(yaml.LiteralStyle|yaml.FoldedStyle) != 0 {
+		end += strings.Count(strings.TrimSuffix(n.Value, "\n"), "\n") + 1
+	}
+	for _, c := range n.Content {
+		if e := d.endLine(c); e > end {
+			end = e
+		}
+	}
+	return end
+}
+
+// formatScalar renders value the way it should appear as a list item, quoting
+// it when it would otherwise not be read back as a string (e.g. "1234").
+func formatScalar(value string) (string, error) {
+	b, err := yaml.Marshal(value)
+	if err != nil {
+		return "", fmt.Errorf("unable to marshal %q: %v", value, err)
+	}
+	return strings.TrimSuffix(string(b), "\n"), nil
+}
+
+// EditFile applies edit to the YAML file at path and writes the result
+// back if anything changed.
+func EditFile(path string, edit func(*Document) error) error {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("unable to read file at %s: %s", path, err)
+	}
+
+	doc, err := ParseDocument(contents)
+	if err != nil {
+		return fmt.Errorf("%s: %s", path, err)
+	}
+
+	if err := edit(doc); err != nil {
+		return fmt.Errorf("%s: %s", path, err)
+	}
+
+	updated := doc.Bytes()
+	if bytes.Equal(updated, contents) {
+		return nil
+	}
+
+	return WriteFile(path, updated)
+}
+
+// WriteFile writes contents to the file at path, keeping its mode if it
+// exists. New files and missing parent directories are created with the
+// default permissions.
+func WriteFile(path string, contents []byte) error {
+	mode := os.FileMode(0644)
+	info, err := os.Stat(path)
+	switch {
+	case err == nil:
+		mode = info.Mode().Perm()
+	case !os.IsNotExist(err):
+		return fmt.Errorf("unable to fetch info for %s: %s", path, err)
+	default:
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return fmt.Errorf("unable to create directory for %s: %s", path, err)
+		}
+	}
+
+	if err := os.WriteFile(path, contents, mode); err != nil {
+		return fmt.Errorf("unable to write to %s: %s", path, err)
+	}
+	return nil
+}
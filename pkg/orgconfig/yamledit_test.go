@@ -0,0 +1,440 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package orgconfig
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+func readTestdata(t *testing.T, name string) []byte {
+	t.Helper()
+	b, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("reading %s: %v", name, err)
+	}
+	return b
+}
+
+func compareGolden(t *testing.T, golden string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", golden)
+	if *update {
+		if err := os.WriteFile(path, got, 0644); err != nil {
+			t.Fatalf("updating %s: %v", path, err)
+		}
+		return
+	}
+	if want := readTestdata(t, golden); string(want) != string(got) {
+		t.Errorf("output does not match %s\nwant:\n%s\ngot:\n%s", path, want, got)
+	}
+}
+
+func TestDocumentEdits(t *testing.T) {
+	cases := []struct {
+		name    string
+		input   string
+		remove  bool
+		rename  string
+		value   string
+		path    []string
+		changed bool
+		golden  string
+	}{
+		{
+			name:    "add in the middle of org members",
+			input:   "org.yaml",
+			value:   "alice",
+			path:    []string{"members"},
+			changed: true,
+			golden:  "org-add-middle.golden",
+		},
+		{
+			name:    "add at the start of org members",
+			input:   "org.yaml",
+			value:   "007agent",
+			path:    []string{"members"},
+			changed: true,
+			golden:  "org-add-first.golden",
+		},
+		{
+			name:    "add at the end of org members",
+			input:   "org.yaml",
+			value:   "Zoe",
+			path:    []string{"members"},
+			changed: true,
+			golden:  "org-add-last.golden",
+		},
+		{
+			name:    "add a numeric username",
+			input:   "org.yaml",
+			value:   "3000",
+			path:    []string{"members"},
+			changed: true,
+			golden:  "org-add-numeric.golden",
+		},
+		{
+			name:    "add an existing member with different case",
+			input:   "org.yaml",
+			value:   "AOJEA",
+			path:    []string{"members"},
+			changed: false,
+			golden:  "org.yaml",
+		},
+		{
+			name:    "remove org member",
+			input:   "org.yaml",
+			remove:  true,
+			value:   "bob",
+			path:    []string{"members"},
+			changed: true,
+			golden:  "org-remove.golden",
+		},
+		{
+			name:    "remove a missing org member",
+			input:   "org.yaml",
+			remove:  true,
+			value:   "alice",
+			path:    []string{"members"},
+			changed: false,
+			golden:  "org.yaml",
+		},
+		{
+			name:    "add next to commented team members",
+			input:   "teams.yaml",
+			value:   "danwinship",
+			path:    []string{"teams", "sig-network-leads", "members"},
+			changed: true,
+			golden:  "teams-add-commented.golden",
+		},
+		{
+			name:    "add to an empty flow list",
+			input:   "teams.yaml",
+			value:   "danwinship",
+			path:    []string{"teams", "sig-network-reviewers", "members"},
+			changed: true,
+			golden:  "teams-add-empty-flow.golden",
+		},
+		{
+			name:    "add to a missing list",
+			input:   "teams.yaml",
+			value:   "cblecker",
+			path:    []string{"teams", "sig-network-solo", "maintainers"},
+			changed: true,
+			golden:  "teams-add-missing-key.golden",
+		},
+		{
+			name:    "add to an indented list",
+			input:   "teams.yaml",
+			value:   "danwinship",
+			path:    []string{"teams", "sig-network-solo", "members"},
+			changed: true,
+			golden:  "teams-add-indented.golden",
+		},
+		{
+			name:    "remove a member with a line comment",
+			input:   "teams.yaml",
+			remove:  true,
+			value:   "aojea",
+			path:    []string{"teams", "sig-network-leads", "members"},
+			changed: true,
+			golden:  "teams-remove-line-comment.golden",
+		},
+		{
+			name:    "remove a member with a head comment",
+			input:   "teams.yaml",
+			remove:  true,
+			value:   "thockin",
+			path:    []string{"teams", "sig-network-leads", "members"},
+			changed: true,
+			golden:  "teams-remove-head-comment.golden",
+		},
+		{
+			name:    "remove the only member",
+			input:   "teams.yaml",
+			remove:  true,
+			value:   "shaneutt",
+			path:    []string{"teams", "sig-network-solo", "members"},
+			changed: true,
+			golden:  "teams-remove-only-item.golden",
+		},
+		{
+			name:    "rename a member with a line comment",
+			input:   "teams.yaml",
+			rename:  "zaojea",
+			value:   "AOJEA",
+			path:    []string{"teams", "sig-network-leads", "members"},
+			changed: true,
+			golden:  "teams-rename-line-comment.golden",
+		},
+		{
+			name:    "rename a member with a head comment",
+			input:   "teams.yaml",
+			rename:  "abe",
+			value:   "thockin",
+			path:    []string{"teams", "sig-network-leads", "members"},
+			changed: true,
+			golden:  "teams-rename-head-comment.golden",
+		},
+		{
+			name:    "rename the only member",
+			input:   "teams.yaml",
+			rename:  "shane",
+			value:   "shaneutt",
+			path:    []string{"teams", "sig-network-solo", "members"},
+			changed: true,
+			golden:  "teams-rename-only-item.golden",
+		},
+		{
+			name:    "rename an org member",
+			input:   "org.yaml",
+			rename:  "alice",
+			value:   "zed",
+			path:    []string{"members"},
+			changed: true,
+			golden:  "org-rename.golden",
+		},
+		{
+			name:    "rename a quoted org member",
+			input:   "org.yaml",
+			rename:  "2490",
+			value:   "249043822",
+			path:    []string{"members"},
+			changed: true,
+			golden:  "org-rename-quoted.golden",
+		},
+		{
+			name:    "rename a missing org member",
+			input:   "org.yaml",
+			rename:  "bob2",
+			value:   "alice",
+			path:    []string{"members"},
+			changed: false,
+			golden:  "org.yaml",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			doc, err := ParseDocument(readTestdata(t, filepath.Join("yamledit", c.input)))
+			if err != nil {
+				t.Fatalf("unexpected error parsing: %v", err)
+			}
+
+			var changed bool
+			switch {
+			case c.remove:
+				changed, err = doc.RemoveFromList(c.value, c.path...)
+			case c.rename != "":
+				changed, err = doc.RenameInList(c.value, c.rename, c.path...)
+			default:
+				changed, err = doc.AddToList(c.value, c.path...)
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if changed != c.changed {
+				t.Errorf("expected changed=%v, got %v", c.changed, changed)
+			}
+			compareGolden(t, filepath.Join("yamledit", c.golden), doc.Bytes())
+		})
+	}
+}
+
+func TestDocumentMissingParent(t *testing.T) {
+	doc, err := ParseDocument(readTestdata(t, "yamledit/teams.yaml"))
+	if err != nil {
+		t.Fatalf("unexpected error parsing: %v", err)
+	}
+	if _, err := doc.AddToList("alice", "teams", "no-such-team", "members"); err == nil {
+		t.Errorf("expected an error when the team does not exist")
+	}
+}
+
+func TestDocumentFlowList(t *testing.T) {
+	input := []byte("members: [alice, carol]\n")
+	doc, err := ParseDocument(input)
+	if err != nil {
+		t.Fatalf("unexpected error parsing: %v", err)
+	}
+	if _, err := doc.AddToList("bob", "members"); err == nil || !strings.Contains(err.Error(), "unsupported flow list layout") {
+		t.Errorf("expected a flow list error adding, got %v", err)
+	}
+	if _, err := doc.RemoveFromList("alice", "members"); err == nil || !strings.Contains(err.Error(), "unsupported flow list layout") {
+		t.Errorf("expected a flow list error removing, got %v", err)
+	}
+	if got := string(doc.Bytes()); got != string(input) {
+		t.Errorf("expected the document to be unchanged, got:\n%s", got)
+	}
+}
+
+func TestDocumentListsContaining(t *testing.T) {
+	doc, err := ParseDocument(readTestdata(t, "yamledit/teams.yaml"))
+	if err != nil {
+		t.Fatalf("unexpected error parsing: %v", err)
+	}
+	got := doc.ListsContaining("CBLECKER")
+	want := [][]string{{"teams", "sig-network-leads", "maintainers"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+	if got := doc.ListsContaining("closed"); len(got) != 0 {
+		t.Errorf("expected no lists for a mapping value, got %v", got)
+	}
+}
+
+func TestDocumentKeyEdits(t *testing.T) {
+	cases := []struct {
+		name   string
+		edit   func(doc *Document) error
+		golden string
+	}{
+		{
+			name: "remove a team with a head comment",
+			edit: func(doc *Document) error {
+				block, ok, err := doc.RemoveKey("teams", "sig-network-leads")
+				if err == nil && (!ok || block[0] != "# Network owners" || block[1] != "sig-network-leads:") {
+					return fmt.Errorf("unexpected removed block %q", block)
+				}
+				return err
+			},
+			golden: "teams-remove-key.golden",
+		},
+		{
+			name: "move a team under another team",
+			edit: func(doc *Document) error {
+				block, _, err := doc.RemoveKey("teams", "sig-network-solo")
+				if err != nil {
+					return err
+				}
+				return doc.InsertKey("sig-network-solo", block, "teams", "sig-network-leads", "teams")
+			},
+			golden: "teams-move-key.golden",
+		},
+		{
+			name: "move the only child team back to the top level",
+			edit: func(doc *Document) error {
+				block, _, err := doc.RemoveKey("teams", "sig-network-solo")
+				if err != nil {
+					return err
+				}
+				if err := doc.InsertKey("sig-network-solo", block, "teams", "sig-network-leads", "teams"); err != nil {
+					return err
+				}
+				if block, _, err = doc.RemoveKey("teams", "sig-network-leads", "teams", "sig-network-solo"); err != nil {
+					return err
+				}
+				return doc.InsertKey("sig-network-solo", block, "teams")
+			},
+			golden: "teams.yaml",
+		},
+		{
+			name: "rename a team",
+			edit: func(doc *Document) error {
+				return doc.RenameKey("a-network-team", "teams", "sig-network-solo")
+			},
+			golden: "teams-rename-key.golden",
+		},
+		{
+			name: "set repo permissions",
+			edit: func(doc *Document) error {
+				previous, err := doc.SetValue("write", "teams", "sig-network-leads", "repos", "ingress-gce")
+				if err == nil && previous != "admin" {
+					return fmt.Errorf("expected previous value admin, got %q", previous)
+				}
+				if err != nil {
+					return err
+				}
+				if _, err := doc.SetValue("read", "teams", "sig-network-leads", "repos", "community"); err != nil {
+					return err
+				}
+				_, err = doc.SetValue("triage", "teams", "sig-network-reviewers", "repos", "ingress-gce")
+				return err
+			},
+			golden: "teams-set-value.golden",
+		},
+		{
+			name: "rename a team to an existing name",
+			edit: func(doc *Document) error {
+				if err := doc.RenameKey("sig-network-leads", "teams", "sig-network-solo"); err == nil {
+					return fmt.Errorf("expected an error")
+				}
+				return nil
+			},
+			golden: "teams.yaml",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			doc, err := ParseDocument(readTestdata(t, "yamledit/teams.yaml"))
+			if err != nil {
+				t.Fatalf("unexpected error parsing: %v", err)
+			}
+			if err := c.edit(doc); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			compareGolden(t, filepath.Join("yamledit", c.golden), doc.Bytes())
+		})
+	}
+}
+
+func TestDocumentInsertKeyIntoEmptyDocument(t *testing.T) {
+	doc, err := ParseDocument(nil)
+	if err != nil {
+		t.Fatalf("unexpected error parsing: %v", err)
+	}
+	if err := doc.InsertKey("sig-foo", []string{"sig-foo:", "  privacy: closed"}, "teams"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, want := string(doc.Bytes()), "teams:\n  sig-foo:\n    privacy: closed\n"; got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+func TestWriteFileKeepsMode(t *testing.T) {
+	dir := t.TempDir()
+	existing := filepath.Join(dir, "teams.yaml")
+	if err := os.WriteFile(existing, []byte("teams: {}\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteFile(existing, []byte("teams:\n  foo: {}\n")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	info, err := os.Stat(existing)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("expected mode 0600 to be kept, got %v", info.Mode().Perm())
+	}
+
+	created := filepath.Join(dir, "sig-foo", "teams.yaml")
+	if err := WriteFile(created, []byte("teams: {}\n")); err != nil {
+		t.Fatalf("unexpected error creating a file: %v", err)
+	}
+	if b, err := os.ReadFile(created); err != nil || string(b) != "teams: {}\n" {
+		t.Errorf("unexpected contents of the new file: %q (%v)", b, err)
+	}
+}
@@ -0,0 +1,104 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package restrictions limits the repos that the teams defined in each
+// org.yaml or teams.yaml file may be granted access to, as configured in
+// config/restrictions.yaml.
+package restrictions
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+
+	"github.com/bmatcuk/doublestar"
+	"sigs.k8s.io/yaml"
+)
+
+var (
+	emptyRegexp = regexp.MustCompile("")
+	// Default allows every repo, it applies to files no restriction matches.
+	Default = Restriction{Path: "*", AllowedReposRe: []*regexp.Regexp{emptyRegexp}}
+)
+
+// Config is the restrictions config file.
+type Config struct {
+	Restrictions []Restriction `json:"restrictions"`
+}
+
+// Restriction lists the repos the teams of the files matching Path may be
+// granted access to.
+type Restriction struct {
+	// Path is a glob matching the files the restriction applies to, relative
+	// to the config directory, e.g. kubernetes/sig-apps/teams.yaml.
+	Path           string   `json:"path"`
+	AllowedRepos   []string `json:"allowedRepos,omitempty"`
+	AllowedReposRe []*regexp.Regexp
+}
+
+// Load reads the restrictions config at path and compiles its repo patterns.
+func Load(path string) (*Config, error) {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read restrictions config: %v", err)
+	}
+	var cfg Config
+	if err := yaml.Unmarshal(buf, &cfg); err != nil {
+		return nil, fmt.Errorf("unmarshal restrictions config: %v", err)
+	}
+	if cfg.Restrictions, err = Compile(cfg.Restrictions); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// Compile compiles the AllowedRepos patterns of restrictions into their
+// AllowedReposRe.
+func Compile(restrictions []Restriction) ([]Restriction, error) {
+	ret := make([]Restriction, 0, len(restrictions))
+	for _, r := range restrictions {
+		r.AllowedReposRe = make([]*regexp.Regexp, 0, len(r.AllowedRepos))
+		for _, repo := range r.AllowedRepos {
+			re, err := regexp.Compile(repo)
+			if err != nil {
+				return restrictions, fmt.Errorf("failed to parse repo pattern %q: %v", repo, err)
+			}
+			r.AllowedReposRe = append(r.AllowedReposRe, re)
+		}
+		ret = append(ret, r)
+	}
+	return ret, nil
+}
+
+// For returns the first of restrictions matching path, or Default.
+func For(restrictions []Restriction, path string) Restriction {
+	for _, r := range restrictions {
+		if match, err := doublestar.Match(r.Path, path); err == nil && match {
+			return r
+		}
+	}
+	return Default
+}
+
+// Allows reports whether teams may be granted access to repo.
+func (r Restriction) Allows(repo string) bool {
+	for _, re := range r.AllowedReposRe {
+		if re.MatchString(repo) {
+			return true
+		}
+	}
+	return false
+}
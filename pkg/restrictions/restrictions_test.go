@@ -0,0 +1,71 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restrictions
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "restrictions.yaml")
+	err := os.WriteFile(path, []byte(`restrictions:
+  - path: "kubernetes/sig-foo/teams.yaml"
+    allowedRepos:
+      - "^foo"
+  - path: "kubernetes/**/teams.yaml"
+    allowedRepos:
+      - "^kube"
+      - "^website$"
+`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		path    string
+		repo    string
+		allowed bool
+	}{
+		{path: "kubernetes/sig-foo/teams.yaml", repo: "foo-bar", allowed: true},
+		{path: "kubernetes/sig-foo/teams.yaml", repo: "kubectl", allowed: false},
+		{path: "kubernetes/sig-bar/teams.yaml", repo: "kubectl", allowed: true},
+		{path: "kubernetes/sig-bar/teams.yaml", repo: "website", allowed: true},
+		{path: "kubernetes/sig-bar/teams.yaml", repo: "website-tools", allowed: false},
+		{path: "kubernetes-sigs/org.yaml", repo: "anything", allowed: true},
+	}
+	for _, tc := range tests {
+		if got := For(cfg.Restrictions, tc.path).Allows(tc.repo); got != tc.allowed {
+			t.Errorf("%s: expected %s to be allowed=%t, got %t", tc.path, tc.repo, tc.allowed, got)
+		}
+	}
+}
+
+func TestLoadInvalidPattern(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "restrictions.yaml")
+	if err := os.WriteFile(path, []byte("restrictions:\n  - path: \"*\"\n    allowedRepos:\n      - \"(\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil {
+		t.Error("expected an error for an invalid repo pattern")
+	}
+}
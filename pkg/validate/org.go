@@ -0,0 +1,112 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validate
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+
+	"sigs.k8s.io/yaml"
+
+	"k8s.io/org/pkg/diagnostics"
+	"k8s.io/org/pkg/orgconfig"
+)
+
+// Owners is the subset of an OWNERS file checked by the built-in rules.
+type Owners struct {
+	Reviewers []string `json:"reviewers,omitempty"`
+	Approvers []string `json:"approvers,omitempty"`
+}
+
+// Org is the configuration of an org as defined under config/<org>, along
+// with the parsed files it was loaded from so that violations can point at
+// the offending lines.
+type Org struct {
+	*orgconfig.Org
+	// Owners is the OWNERS file of the org directory, nil if there is none.
+	Owners *Owners
+	// OwnersFile is the path of the OWNERS file relative to the repo root.
+	OwnersFile string
+}
+
+// LoadOrgs loads the given orgs from the config directory of repoRoot.
+func LoadOrgs(repoRoot string, names []string) ([]*Org, error) {
+	orgs := make([]*Org, 0, len(names))
+	for _, name := range names {
+		o, err := LoadOrg(repoRoot, name)
+		if err != nil {
+			return nil, err
+		}
+		orgs = append(orgs, o)
+	}
+	return orgs, nil
+}
+
+// LoadOrg loads config/<name>/org.yaml, the teams.yaml files in its
+// subdirectories and config/<name>/OWNERS.
+func LoadOrg(repoRoot, name string) (*Org, error) {
+	repo := &orgconfig.Repo{Root: repoRoot}
+	orgCfg, err := repo.LoadOrg(name)
+	if err != nil {
+		return nil, err
+	}
+	o := &Org{
+		Org:        orgCfg,
+		OwnersFile: filepath.Join("config", name, "OWNERS"),
+	}
+
+	if buf, err := os.ReadFile(filepath.Join(repoRoot, o.OwnersFile)); err == nil {
+		o.Owners = &Owners{}
+		if err := yaml.Unmarshal(buf, o.Owners); err != nil {
+			return nil, diagnostics.FromYAMLError(o.OwnersFile, buf, err)
+		}
+		if err := o.Sources().Add(o.OwnersFile, buf); err != nil {
+			return nil, diagnostics.FromYAMLError(o.OwnersFile, buf, err)
+		}
+	}
+	return o, nil
+}
+
+// TeamNames returns the names of the top-level teams of the org, sorted.
+func (o *Org) TeamNames() []string {
+	names := make([]string, 0, len(o.Config.Teams))
+	for name := range o.Config.Teams {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// TeamSource returns the file defining the team, which may be a child team,
+// and the path of the team within that file.
+func (o *Org) TeamSource(team string) (string, []string) {
+	t, _ := o.Team(team)
+	return t.File, t.Path
+}
+
+// LocateItems returns the locations of every item matching login in the
+// list at path in file.
+func (o *Org) LocateItems(file, login string, path ...string) []Location {
+	return o.Sources().LocateItems(file, login, path...)
+}
+
+// LocateItem returns the location of the first item matching login in the
+// list at path, or the location of the list if there is none.
+func (o *Org) LocateItem(file, login string, path ...string) Location {
+	return o.Sources().LocateItem(file, login, path...)
+}
@@ -0,0 +1,422 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validate
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/prow/pkg/config/org"
+	"sigs.k8s.io/prow/pkg/github"
+)
+
+var builtinRules = []Rule{
+	{
+		ID:          "required-admins",
+		Description: "users listed in requiredAdmins must be org admins",
+		Severity:    SeverityError,
+		Check:       checkRequiredAdmins,
+	},
+	{
+		ID:          "org-admin-member-overlap",
+		Description: "users cannot be both org admins and org members",
+		Severity:    SeverityError,
+		Check:       checkOrgAdminMemberOverlap,
+	},
+	{
+		ID:          "org-duplicate-members",
+		Description: "org admins and members must not be listed more than once",
+		Severity:    SeverityError,
+		Check:       checkOrgDuplicates,
+	},
+	{
+		ID:          "org-sorted-members",
+		Description: "org admins and members must be sorted case-insensitively",
+		Severity:    SeverityError,
+		Check:       checkOrgSorted,
+	},
+	{
+		ID:          "team-privacy-closed",
+		Description: "teams must have privacy: closed",
+		Severity:    SeverityError,
+		Check:       teamCheck(checkTeamPrivacy),
+	},
+	{
+		ID:          "team-maintainers-are-admins",
+		Description: "team maintainers must be org admins",
+		Severity:    SeverityError,
+		Check:       teamCheck(checkTeamMaintainersAreAdmins),
+	},
+	{
+		ID:          "team-admins-are-maintainers",
+		Description: "org admins must be listed as team maintainers, not members",
+		Severity:    SeverityError,
+		Check:       teamCheck(checkTeamAdminsAreMaintainers),
+	},
+	{
+		ID:          "team-maintainer-member-overlap",
+		Description: "users cannot be both team maintainers and team members",
+		Severity:    SeverityError,
+		Check:       teamCheck(checkTeamMaintainerMemberOverlap),
+	},
+	{
+		ID:          "team-duplicate-members",
+		Description: "team maintainers and members must not be listed more than once",
+		Severity:    SeverityError,
+		Check:       teamCheck(checkTeamDuplicates),
+	},
+	{
+		ID:          "team-members-in-org",
+		Description: "team members must be org members",
+		Severity:    SeverityError,
+		Check:       teamCheck(checkTeamMembersInOrg),
+	},
+	{
+		ID:          "team-sorted-members",
+		Description: "team maintainers and members must be sorted case-insensitively",
+		Severity:    SeverityError,
+		Check:       teamCheck(checkTeamSorted),
+	},
+	{
+		ID:          "owners-required",
+		Description: "the org directory must have an OWNERS file",
+		Severity:    SeverityError,
+		Check:       checkOwnersRequired,
+	},
+	{
+		ID:          "owners-min-approvers",
+		Description: "the OWNERS file of the org directory must have at least minApprovers approvers",
+		Severity:    SeverityError,
+		Check:       checkOwnersMinApprovers,
+	},
+	{
+		ID:          "owners-in-org",
+		Description: "approvers and reviewers in the OWNERS file of the org directory must be org members",
+		Severity:    SeverityError,
+		Check:       checkOwnersInOrg,
+	},
+	{
+		ID:          "owners-duplicates",
+		Description: "approvers and reviewers must not be listed more than once",
+		Severity:    SeverityError,
+		Check:       checkOwnersDuplicates,
+	},
+}
+
+func normalize(logins []string) sets.String {
+	out := sets.String{}
+	for _, l := range logins {
+		out.Insert(github.NormLogin(l))
+	}
+	return out
+}
+
+func isSorted(list []string) bool {
+	return sort.SliceIsSorted(list, func(i, j int) bool {
+		return strings.ToLower(list[i]) < strings.ToLower(list[j])
+	})
+}
+
+// duplicates returns the normalized logins listed more than once.
+func duplicates(list []string) []string {
+	seen := sets.String{}
+	dups := sets.String{}
+	for _, l := range list {
+		n := github.NormLogin(l)
+		if seen.Has(n) {
+			dups.Insert(n)
+		}
+		seen.Insert(n)
+	}
+	return dups.List()
+}
+
+func orgMembers(o *Org) sets.String {
+	return normalize(o.Config.Members).Union(normalize(o.Config.Admins))
+}
+
+func checkRequiredAdmins(o *Org, p Policy) []Violation {
+	admins := normalize(o.Config.Admins)
+	var vs []Violation
+	for _, u := range p.RequiredAdmins {
+		if !admins.Has(github.NormLogin(u)) {
+			vs = append(vs, Violation{
+				Location: o.Locate(o.File, "admins"),
+				Message:  fmt.Sprintf("%s must be an admin of org %s", u, o.Name),
+			})
+		}
+	}
+	return vs
+}
+
+func checkOrgAdminMemberOverlap(o *Org, _ Policy) []Violation {
+	var vs []Violation
+	for _, u := range normalize(o.Config.Admins).Intersection(normalize(o.Config.Members)).List() {
+		vs = append(vs, Violation{
+			Location: o.LocateItem(o.File, u, "members"),
+			Message:  fmt.Sprintf("%s is both an admin and a member of org %s", u, o.Name),
+		})
+	}
+	return vs
+}
+
+func checkOrgDuplicates(o *Org, _ Policy) []Violation {
+	var vs []Violation
+	for _, key := range []string{"admins", "members"} {
+		list := o.Config.Admins
+		if key == "members" {
+			list = o.Config.Members
+		}
+		for _, u := range duplicates(list) {
+			locs := o.LocateItems(o.File, u, key)
+			loc := o.Locate(o.File, key)
+			if len(locs) > 1 {
+				loc = locs[1]
+			}
+			vs = append(vs, Violation{
+				Location: loc,
+				Message:  fmt.Sprintf("%s is listed more than once in %s of org %s", u, key, o.Name),
+			})
+		}
+	}
+	return vs
+}
+
+func checkOrgSorted(o *Org, _ Policy) []Violation {
+	var vs []Violation
+	for _, key := range []string{"admins", "members"} {
+		list := o.Config.Admins
+		if key == "members" {
+			list = o.Config.Members
+		}
+		if !isSorted(list) {
+			vs = append(vs, Violation{
+				Location: o.Locate(o.File, key),
+				Message:  fmt.Sprintf("%s of org %s are not sorted", key, o.Name),
+			})
+		}
+	}
+	return vs
+}
+
+// teamContext describes a team being checked by a team rule.
+type teamContext struct {
+	// Name is the name of the team, with parent teams separated by "/".
+	Name string
+	Team org.Team
+	File string
+	// Path is the path of the team within File, e.g. teams.foo.teams.bar.
+	Path []string
+}
+
+func (t teamContext) listPath(key string) []string {
+	return append(append([]string{}, t.Path...), key)
+}
+
+// teamCheck runs check against every team of an org, including child teams.
+func teamCheck(check func(o *Org, t teamContext) []Violation) func(*Org, Policy) []Violation {
+	return func(o *Org, _ Policy) []Violation {
+		var vs []Violation
+		var walk func(teams map[string]org.Team, prefix string)
+		walk = func(teams map[string]org.Team, prefix string) {
+			names := make([]string, 0, len(teams))
+			for name := range teams {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				file, path := o.TeamSource(name)
+				t := teamContext{
+					Name: prefix + name,
+					Team: teams[name],
+					File: file,
+					Path: path,
+				}
+				vs = append(vs, check(o, t)...)
+				walk(t.Team.Children, t.Name+"/")
+			}
+		}
+		walk(o.Config.Teams, "")
+		return vs
+	}
+}
+
+func checkTeamPrivacy(o *Org, t teamContext) []Violation {
+	if t.Team.Privacy != nil && *t.Team.Privacy == org.Closed {
+		return nil
+	}
+	loc := o.Locate(t.File, t.listPath("privacy")...)
+	return []Violation{{
+		Location: loc,
+		Message:  fmt.Sprintf("team %s in org %s doesn't have privacy: closed", t.Name, o.Name),
+	}}
+}
+
+func checkTeamMaintainersAreAdmins(o *Org, t teamContext) []Violation {
+	var vs []Violation
+	for _, u := range normalize(t.Team.Maintainers).Difference(normalize(o.Config.Admins)).List() {
+		vs = append(vs, Violation{
+			Location: o.LocateItem(t.File, u, t.listPath("maintainers")...),
+			Message:  fmt.Sprintf("%s is a maintainer of team %s but not an admin of org %s; move them to members", u, t.Name, o.Name),
+		})
+	}
+	return vs
+}
+
+func checkTeamAdminsAreMaintainers(o *Org, t teamContext) []Violation {
+	var vs []Violation
+	for _, u := range normalize(t.Team.Members).Intersection(normalize(o.Config.Admins)).List() {
+		vs = append(vs, Violation{
+			Location: o.LocateItem(t.File, u, t.listPath("members")...),
+			Message:  fmt.Sprintf("%s is an admin of org %s and must be a maintainer of team %s instead of a member", u, o.Name, t.Name),
+		})
+	}
+	return vs
+}
+
+func checkTeamMaintainerMemberOverlap(o *Org, t teamContext) []Violation {
+	var vs []Violation
+	for _, u := range normalize(t.Team.Maintainers).Intersection(normalize(t.Team.Members)).List() {
+		vs = append(vs, Violation{
+			Location: o.LocateItem(t.File, u, t.listPath("members")...),
+			Message:  fmt.Sprintf("%s is both a maintainer and a member of team %s in org %s", u, t.Name, o.Name),
+		})
+	}
+	return vs
+}
+
+func checkTeamDuplicates(o *Org, t teamContext) []Violation {
+	var vs []Violation
+	for _, key := range []string{"maintainers", "members"} {
+		list := t.Team.Maintainers
+		if key == "members" {
+			list = t.Team.Members
+		}
+		for _, u := range duplicates(list) {
+			locs := o.LocateItems(t.File, u, t.listPath(key)...)
+			loc := o.Locate(t.File, t.listPath(key)...)
+			if len(locs) > 1 {
+				loc = locs[1]
+			}
+			vs = append(vs, Violation{
+				Location: loc,
+				Message:  fmt.Sprintf("%s is listed more than once in %s of team %s in org %s", u, key, t.Name, o.Name),
+			})
+		}
+	}
+	return vs
+}
+
+func checkTeamMembersInOrg(o *Org, t teamContext) []Violation {
+	var vs []Violation
+	for _, u := range normalize(t.Team.Members).Difference(orgMembers(o)).List() {
+		vs = append(vs, Violation{
+			Location: o.LocateItem(t.File, u, t.listPath("members")...),
+			Message:  fmt.Sprintf("%s is a member of team %s but not of org %s", u, t.Name, o.Name),
+		})
+	}
+	return vs
+}
+
+func checkTeamSorted(o *Org, t teamContext) []Violation {
+	var vs []Violation
+	for _, key := range []string{"maintainers", "members"} {
+		list := t.Team.Maintainers
+		if key == "members" {
+			list = t.Team.Members
+		}
+		if !isSorted(list) {
+			vs = append(vs, Violation{
+				Location: o.Locate(t.File, t.listPath(key)...),
+				Message:  fmt.Sprintf("%s of team %s in org %s are not sorted", key, t.Name, o.Name),
+			})
+		}
+	}
+	return vs
+}
+
+func checkOwnersRequired(o *Org, _ Policy) []Violation {
+	if o.Owners != nil {
+		return nil
+	}
+	return []Violation{{
+		Location: Location{File: o.OwnersFile},
+		Message:  fmt.Sprintf("org %s has no OWNERS file", o.Name),
+	}}
+}
+
+func checkOwnersMinApprovers(o *Org, p Policy) []Violation {
+	if o.Owners == nil || p.MinApprovers == nil {
+		return nil
+	}
+	approvers := normalize(o.Owners.Approvers)
+	if approvers.Len() >= *p.MinApprovers {
+		return nil
+	}
+	return []Violation{{
+		Location: o.Locate(o.OwnersFile, "approvers"),
+		Message:  fmt.Sprintf("require at least %d approvers, found %d: %s", *p.MinApprovers, approvers.Len(), strings.Join(approvers.List(), ", ")),
+	}}
+}
+
+func checkOwnersInOrg(o *Org, _ Policy) []Violation {
+	if o.Owners == nil {
+		return nil
+	}
+	members := orgMembers(o)
+	var vs []Violation
+	for _, key := range []string{"approvers", "reviewers"} {
+		list := o.Owners.Approvers
+		if key == "reviewers" {
+			list = o.Owners.Reviewers
+		}
+		for _, u := range normalize(list).Difference(members).List() {
+			vs = append(vs, Violation{
+				Location: o.LocateItem(o.OwnersFile, u, key),
+				Message:  fmt.Sprintf("%s is in %s but is not a member of org %s", u, key, o.Name),
+			})
+		}
+	}
+	return vs
+}
+
+func checkOwnersDuplicates(o *Org, _ Policy) []Violation {
+	if o.Owners == nil {
+		return nil
+	}
+	var vs []Violation
+	for _, key := range []string{"approvers", "reviewers"} {
+		list := o.Owners.Approvers
+		if key == "reviewers" {
+			list = o.Owners.Reviewers
+		}
+		for _, u := range duplicates(list) {
+			locs := o.LocateItems(o.OwnersFile, u, key)
+			loc := o.Locate(o.OwnersFile, key)
+			if len(locs) > 1 {
+				loc = locs[1]
+			}
+			vs = append(vs, Violation{
+				Location: loc,
+				Message:  fmt.Sprintf("%s is listed more than once in %s", u, key),
+			})
+		}
+	}
+	return vs
+}
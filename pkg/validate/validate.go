@@ -0,0 +1,276 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package validate checks org configs against a set of rules. Rules are kept
+// in a Registry and can be disabled or have their severity changed for all
+// orgs or per org through a Config, so that orgs with different policies can
+// share the same engine.
+package validate
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"sigs.k8s.io/yaml"
+
+	"k8s.io/org/pkg/diagnostics"
+)
+
+// Severity is the severity of a violation.
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+func (s Severity) validate() error {
+	switch s {
+	case SeverityError, SeverityWarning:
+		return nil
+	}
+	return fmt.Errorf("invalid severity %q, must be one of: %s, %s", s, SeverityError, SeverityWarning)
+}
+
+// Location points at a position in a file relative to the repo root.
+type Location = diagnostics.Location
+
+// Violation is a single failure of a rule.
+type Violation struct {
+	Rule     string   `json:"rule"`
+	Severity Severity `json:"severity"`
+	Org      string   `json:"org"`
+	Location Location `json:"location"`
+	Message  string   `json:"message"`
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("%s: %s: %s [%s]", v.Location, v.Severity, v.Message, v.Rule)
+}
+
+// Annotation formats the violation as a GitHub Actions annotation.
+func (v Violation) Annotation() string {
+	return diagnostics.Annotation(string(v.Severity), v.Location, fmt.Sprintf("%s [%s]", v.Message, v.Rule))
+}
+
+// Rule is a check run against every org.
+type Rule struct {
+	// ID identifies the rule in the config file and in violations.
+	ID string
+	// Description explains what the rule enforces.
+	Description string
+	// Severity is the default severity of violations of the rule.
+	Severity Severity
+	// Check returns the violations of the rule in o. Only the Location and
+	// Message of the returned violations need to be set.
+	Check func(o *Org, p Policy) []Violation
+}
+
+// Registry holds the rules to validate orgs against.
+type Registry struct {
+	rules []Rule
+	ids   map[string]bool
+}
+
+// NewRegistry returns a registry holding rules.
+func NewRegistry(rules ...Rule) (*Registry, error) {
+	r := &Registry{ids: map[string]bool{}}
+	for _, rule := range rules {
+		if err := r.Register(rule); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// DefaultRegistry returns a registry holding the built-in rules.
+func DefaultRegistry() *Registry {
+	r, err := NewRegistry(builtinRules...)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// Register adds rule to the registry. Rule IDs must be unique.
+func (r *Registry) Register(rule Rule) error {
+	if rule.ID == "" {
+		return fmt.Errorf("rule has no ID")
+	}
+	if r.ids[rule.ID] {
+		return fmt.Errorf("rule %s is already registered", rule.ID)
+	}
+	if err := rule.Severity.validate(); err != nil {
+		return fmt.Errorf("rule %s: %v", rule.ID, err)
+	}
+	if rule.Check == nil {
+		return fmt.Errorf("rule %s has no check", rule.ID)
+	}
+	r.ids[rule.ID] = true
+	r.rules = append(r.rules, rule)
+	return nil
+}
+
+// Rules returns the registered rules in the order they were registered.
+func (r *Registry) Rules() []Rule {
+	return append([]Rule{}, r.rules...)
+}
+
+// Validate runs every enabled rule against orgs and returns all violations,
+// sorted by location. A nil cfg runs every rule with its default severity.
+func (r *Registry) Validate(orgs []*Org, cfg *Config) []Violation {
+	if cfg == nil {
+		cfg = &Config{}
+	}
+
+	var violations []Violation
+	for _, o := range orgs {
+		p := cfg.PolicyFor(o.Name)
+		for _, rule := range r.rules {
+			rc := p.Rules[rule.ID]
+			if rc.Enabled != nil && !*rc.Enabled {
+				continue
+			}
+			severity := rule.Severity
+			if rc.Severity != "" {
+				severity = rc.Severity
+			}
+			for _, v := range rule.Check(o, p) {
+				v.Rule = rule.ID
+				v.Severity = severity
+				v.Org = o.Name
+				violations = append(violations, v)
+			}
+		}
+	}
+
+	sort.SliceStable(violations, func(i, j int) bool {
+		a, b := violations[i].Location, violations[j].Location
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+	return violations
+}
+
+// RuleConfig overrides the defaults of a rule.
+type RuleConfig struct {
+	// Enabled disables the rule if set to false.
+	Enabled *bool `json:"enabled,omitempty"`
+	// Severity overrides the default severity of the rule.
+	Severity Severity `json:"severity,omitempty"`
+}
+
+// Policy configures the rules run against an org.
+type Policy struct {
+	// Rules overrides the defaults of rules by ID.
+	Rules map[string]RuleConfig `json:"rules,omitempty"`
+	// RequiredAdmins are users that must be admins of the org.
+	RequiredAdmins []string `json:"requiredAdmins,omitempty"`
+	// MinApprovers is the minimum number of approvers in the OWNERS file of
+	// the org directory.
+	MinApprovers *int `json:"minApprovers,omitempty"`
+}
+
+// Config is the validation config file. The top-level policy applies to all
+// orgs and is overridden field by field, and rule by rule, by Orgs.
+type Config struct {
+	Policy `json:",inline"`
+	Orgs   map[string]Policy `json:"orgs,omitempty"`
+}
+
+// LoadConfig reads the config file at path.
+func LoadConfig(path string) (*Config, error) {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read: %v", err)
+	}
+	var cfg Config
+	if err := yaml.Unmarshal(buf, &cfg, yaml.DisallowUnknownFields); err != nil {
+		return nil, fmt.Errorf("unable to unmarshal %s: %v", path, err)
+	}
+	return &cfg, nil
+}
+
+// Check verifies that the config only refers to rules in r and uses valid
+// severities.
+func (c *Config) Check(r *Registry) error {
+	policies := map[string]Policy{"": c.Policy}
+	for name, p := range c.Orgs {
+		policies[name] = p
+	}
+	for name, p := range policies {
+		for id, rc := range p.Rules {
+			if !r.ids[id] {
+				return fmt.Errorf("%sunknown rule %s", orgPrefix(name), id)
+			}
+			if rc.Severity == "" {
+				continue
+			}
+			if err := rc.Severity.validate(); err != nil {
+				return fmt.Errorf("%srule %s: %v", orgPrefix(name), id, err)
+			}
+		}
+	}
+	return nil
+}
+
+func orgPrefix(name string) string {
+	if name == "" {
+		return ""
+	}
+	return "org " + name + ": "
+}
+
+// PolicyFor returns the policy of org, the top-level policy overridden by the
+// org specific one.
+func (c *Config) PolicyFor(org string) Policy {
+	p := Policy{
+		Rules:          map[string]RuleConfig{},
+		RequiredAdmins: c.RequiredAdmins,
+		MinApprovers:   c.MinApprovers,
+	}
+	for id, rc := range c.Rules {
+		p.Rules[id] = rc
+	}
+
+	override, ok := c.Orgs[org]
+	if !ok {
+		return p
+	}
+	for id, rc := range override.Rules {
+		merged := p.Rules[id]
+		if rc.Enabled != nil {
+			merged.Enabled = rc.Enabled
+		}
+		if rc.Severity != "" {
+			merged.Severity = rc.Severity
+		}
+		p.Rules[id] = merged
+	}
+	if override.RequiredAdmins != nil {
+		p.RequiredAdmins = override.RequiredAdmins
+	}
+	if override.MinApprovers != nil {
+		p.MinApprovers = override.MinApprovers
+	}
+	return p
+}
@@ -0,0 +1,146 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validate
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+func TestValidate(t *testing.T) {
+	cfg, err := LoadConfig("testdata/validation.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := DefaultRegistry()
+	if err := cfg.Check(r); err != nil {
+		t.Fatal(err)
+	}
+	orgs, err := LoadOrgs("testdata", []string{"example", "other"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var lines []string
+	for _, v := range r.Validate(orgs, cfg) {
+		lines = append(lines, v.String())
+	}
+	got := strings.Join(lines, "\n") + "\n"
+
+	golden := filepath.Join("testdata", "violations.golden")
+	if *update {
+		if err := os.WriteFile(golden, []byte(got), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expected, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != string(expected) {
+		t.Errorf("unexpected violations, run with -update to regenerate.\nexpected:\n%s\ngot:\n%s", expected, got)
+	}
+}
+
+func TestPolicyFor(t *testing.T) {
+	disabled, three, five := false, 3, 5
+	cfg := &Config{
+		Policy: Policy{
+			Rules: map[string]RuleConfig{
+				"a": {Severity: SeverityWarning},
+				"b": {Enabled: &disabled},
+			},
+			RequiredAdmins: []string{"robot"},
+			MinApprovers:   &five,
+		},
+		Orgs: map[string]Policy{
+			"org": {
+				Rules: map[string]RuleConfig{
+					"a": {Enabled: &disabled},
+				},
+				MinApprovers: &three,
+			},
+		},
+	}
+
+	expected := Policy{
+		Rules: map[string]RuleConfig{
+			"a": {Enabled: &disabled, Severity: SeverityWarning},
+			"b": {Enabled: &disabled},
+		},
+		RequiredAdmins: []string{"robot"},
+		MinApprovers:   &three,
+	}
+	if p := cfg.PolicyFor("org"); !reflect.DeepEqual(p, expected) {
+		t.Errorf("expected %+v, got %+v", expected, p)
+	}
+	if p := cfg.PolicyFor("unknown"); *p.MinApprovers != 5 || len(p.Rules) != 2 {
+		t.Errorf("expected the top-level policy, got %+v", p)
+	}
+}
+
+func TestConfigCheck(t *testing.T) {
+	r := DefaultRegistry()
+	cases := []struct {
+		name string
+		cfg  Config
+		err  bool
+	}{
+		{
+			name: "valid",
+			cfg:  Config{Policy: Policy{Rules: map[string]RuleConfig{"team-privacy-closed": {Severity: SeverityWarning}}}},
+		},
+		{
+			name: "unknown rule",
+			cfg:  Config{Orgs: map[string]Policy{"org": {Rules: map[string]RuleConfig{"no-such-rule": {}}}}},
+			err:  true,
+		},
+		{
+			name: "invalid severity",
+			cfg:  Config{Policy: Policy{Rules: map[string]RuleConfig{"team-privacy-closed": {Severity: "fatal"}}}},
+			err:  true,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := c.cfg.Check(r)
+			if c.err != (err != nil) {
+				t.Errorf("expected error: %t, got %v", c.err, err)
+			}
+		})
+	}
+}
+
+func TestRegister(t *testing.T) {
+	r := DefaultRegistry()
+	rule := Rule{ID: "custom", Severity: SeverityWarning, Check: func(*Org, Policy) []Violation { return nil }}
+	if err := r.Register(rule); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := r.Register(rule); err == nil {
+		t.Errorf("expected error registering a rule twice")
+	}
+	if err := r.Register(Rule{ID: "no-check", Severity: SeverityError}); err == nil {
+		t.Errorf("expected error registering a rule without a check")
+	}
+}